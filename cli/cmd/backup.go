@@ -0,0 +1,105 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling-minecraft
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/gojue/moling-minecraft/services"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up, list and restore the Minecraft world",
+	Long: `World backups are archived to the backups directory under the MoLing data directory, the number of backups
+kept is set by backupRetention in the Minecraft config. When the server is running in another process, for
+example under MoLing, the backup pauses its saving over RCON (enable-rcon in server.properties) and fails when
+RCON cannot be reached. Restoring needs a stopped server; while MoLing runs use the minecraft_backup tools instead.
+    moling_mc backup                                       Back up the world
+    moling_mc backup --label before_castle                 Back up the world with a label in the backup name
+    moling_mc backup --list                                List the backups
+    moling_mc backup --restore <name>                      Replace the world with a backup
+`,
+	RunE: BackupCommandFunc,
+}
+
+var (
+	backupLabel      string
+	backupList       bool
+	backupRestore    string
+	backupServerRoot string
+)
+
+// BackupCommandFunc executes the "backup" command.
+func BackupCommandFunc(command *cobra.Command, args []string) error {
+	logger := initLogger(mlConfig.BasePath)
+	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	multi := zerolog.MultiLevelWriter(consoleWriter, logger)
+	logger = zerolog.New(multi).With().Timestamp().Logger()
+	mlConfig.SetLogger(logger)
+
+	overrides := make(map[string]interface{})
+	if backupServerRoot != "" {
+		overrides["serverRootPath"] = backupServerRoot
+	}
+	ctx := context.WithValue(context.Background(), services.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, services.MoLingLoggerKey, logger)
+	ms, err := newMinecraftServer(ctx, overrides)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case backupList:
+		list, err := ms.ListBackups()
+		if err != nil {
+			return err
+		}
+		for _, b := range list {
+			fmt.Printf("%s\t%s\t%.1f MB\n", b.Name, b.Created.Format(time.DateTime), float64(b.Size)/(1<<20))
+		}
+	case backupRestore != "":
+		steps, err := ms.RestoreBackup(backupRestore)
+		for _, step := range steps {
+			fmt.Println("- " + step)
+		}
+		return err
+	default:
+		info, pruned, err := ms.CreateBackup(backupLabel)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created backup %s (%.1f MB)\n", info.Name, float64(info.Size)/(1<<20))
+		if len(pruned) > 0 {
+			fmt.Println("Deleted old backups: " + strings.Join(pruned, ", "))
+		}
+	}
+	return nil
+}
+
+func init() {
+	backupCmd.PersistentFlags().StringVar(&backupLabel, "label", "", "Label added to the name of the new backup")
+	backupCmd.PersistentFlags().BoolVar(&backupList, "list", false, "List the backups")
+	backupCmd.PersistentFlags().StringVar(&backupRestore, "restore", "", "Name of the backup to restore")
+	backupCmd.PersistentFlags().StringVar(&backupServerRoot, "server_root", "", "Override serverRootPath of the Minecraft server")
+	rootCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling-minecraft
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gojue/moling-minecraft/services"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"os"
+	"time"
+)
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Prepare a fresh Minecraft server directory for its first start",
+	Long: `A fresh server directory cannot start until the Minecraft EULA (https://aka.ms/MinecraftEULA) is accepted in eula.txt.
+The bootstrap command checks the server jar, accepts the EULA when asked to, and creates server.properties with RCON
+enabled and sensible defaults. The same checks run every time MoLing starts the server; instead of --accept_eula you
+can set acceptEula to true in the Minecraft config.
+    moling_mc bootstrap                                    Check the server directory
+    moling_mc bootstrap --accept_eula                      Accept the EULA and prepare the server directory
+    moling_mc bootstrap --accept_eula --server_root /srv/mc --server_jar server.jar
+`,
+	RunE: BootstrapCommandFunc,
+}
+
+var (
+	bootstrapAcceptEula bool
+	bootstrapServerRoot string
+	bootstrapServerJar  string
+)
+
+// BootstrapCommandFunc executes the "bootstrap" command.
+func BootstrapCommandFunc(command *cobra.Command, args []string) error {
+	logger := initLogger(mlConfig.BasePath)
+	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	multi := zerolog.MultiLevelWriter(consoleWriter, logger)
+	logger = zerolog.New(multi).With().Timestamp().Logger()
+	mlConfig.SetLogger(logger)
+
+	overrides := make(map[string]interface{})
+	if bootstrapServerRoot != "" {
+		overrides["serverRootPath"] = bootstrapServerRoot
+	}
+	if bootstrapServerJar != "" {
+		overrides["serverJarFile"] = bootstrapServerJar
+	}
+	ctx := context.WithValue(context.Background(), services.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, services.MoLingLoggerKey, logger)
+	ms, err := newMinecraftServer(ctx, overrides)
+	if err != nil {
+		return err
+	}
+
+	steps, err := ms.Bootstrap(bootstrapAcceptEula)
+	for _, step := range steps {
+		fmt.Println("- " + step)
+	}
+	if errors.Is(err, services.ErrEulaNotAccepted) {
+		return fmt.Errorf("%w\nRun this command again with --accept_eula once you agree to the EULA", err)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("The server directory is ready, start MoLing to run the server.")
+	return nil
+}
+
+func init() {
+	bootstrapCmd.PersistentFlags().BoolVar(&bootstrapAcceptEula, "accept_eula", false, "Accept the Minecraft EULA ("+services.EulaURL+")")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapServerRoot, "server_root", "", "Override serverRootPath of the Minecraft server")
+	bootstrapCmd.PersistentFlags().StringVar(&bootstrapServerJar, "server_jar", "", "Override serverJarFile of the Minecraft server")
+	rootCmd.AddCommand(bootstrapCmd)
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling-minecraft
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gojue/moling-minecraft/services"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay a recorded MCP session onto another Minecraft server",
+	Long: `Every tool call of an MCP session is recorded to a session journal under the MoLing data directory.
+The replay command re-executes a recorded session (or a range of it) against the server configured in the
+config file, optionally moved by a coordinate offset, so a build made on a test world can be reproduced elsewhere.
+Tools that administrate the server or MoLing (backups, server.properties, data packs, op, ban, kick, whitelist,
+schedules, waypoints, ...) are only replayed when named with --tools.
+    moling_mc replay --list                                List the recorded sessions
+    moling_mc replay -s <session> --dry_run                 Show the tool calls that would be replayed
+    moling_mc replay -s <session> --from 3 --to 20 --offset 100,0,-50 --server_root /srv/prod
+`,
+	RunE: ReplayCommandFunc,
+}
+
+var (
+	replaySession    string
+	replayList       bool
+	replayFrom       int
+	replayTo         int
+	replayTools      string
+	replayOffset     string
+	replayServerRoot string
+	replayServerJar  string
+	replayDryRun     bool
+	replayWait       int
+	replayKeepErrors bool
+	replayStopOnErr  bool
+)
+
+// ReplayCommandFunc executes the "replay" command.
+func ReplayCommandFunc(command *cobra.Command, args []string) error {
+	logger := initLogger(mlConfig.BasePath)
+	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	multi := zerolog.MultiLevelWriter(consoleWriter, logger)
+	logger = zerolog.New(multi).With().Timestamp().Logger()
+	mlConfig.SetLogger(logger)
+
+	journalDir := filepath.Join(mlConfig.BasePath, "data", services.JournalDirName)
+	if replayList {
+		names, err := services.ListJournals(journalDir)
+		if err != nil {
+			return fmt.Errorf("Error listing session journals in %s: %v\n", journalDir, err)
+		}
+		for _, name := range names {
+			entries, err := services.LoadJournal(journalDir, name)
+			if err != nil {
+				logger.Warn().Err(err).Str("session", name).Msg("Failed to read session journal")
+				continue
+			}
+			fmt.Printf("%s\t%d tool calls\n", name, len(entries))
+		}
+		return nil
+	}
+	if replaySession == "" {
+		return fmt.Errorf("please specify a session with --session, use --list to show the recorded sessions")
+	}
+
+	opts := services.ReplayOptions{
+		FromSeq:     replayFrom,
+		ToSeq:       replayTo,
+		SkipErrors:  !replayKeepErrors,
+		StopOnError: replayStopOnErr,
+	}
+	if replayTools != "" {
+		opts.Tools = strings.Split(replayTools, ",")
+	}
+	if replayOffset != "" {
+		parts := strings.Split(replayOffset, ",")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid offset %q, expected dx,dy,dz", replayOffset)
+		}
+		for i, p := range parts {
+			v, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return fmt.Errorf("invalid offset %q: %v", replayOffset, err)
+			}
+			opts.Offset[i] = v
+		}
+	}
+
+	entries, err := services.LoadJournal(journalDir, replaySession)
+	if err != nil {
+		return fmt.Errorf("Error loading session journal %s: %v\n", replaySession, err)
+	}
+	selected := services.FilterJournal(entries, opts)
+	logger.Info().Str("session", replaySession).Int("entries", len(entries)).Int("selected", len(selected)).Msg("Loaded session journal")
+
+	if replayDryRun {
+		for _, e := range selected {
+			argsJson, _ := json.Marshal(services.TranslateArguments(e.Arguments, opts.Offset))
+			fmt.Printf("#%d\t%s\t%s\n", e.Seq, e.Tool, argsJson)
+		}
+		return nil
+	}
+
+	// Load the Minecraft config of the target server
+	overrides := make(map[string]interface{})
+	if replayServerRoot != "" {
+		overrides["serverRootPath"] = replayServerRoot
+	}
+	if replayServerJar != "" {
+		overrides["serverJarFile"] = replayServerJar
+	}
+	ctx := context.WithValue(context.Background(), services.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, services.MoLingLoggerKey, logger)
+	ms, err := newMinecraftServer(ctx, overrides)
+	if err != nil {
+		return err
+	}
+	if err = ms.Init(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = ms.Close()
+	}()
+
+	logger.Info().Int("timeout", replayWait).Msg("Waiting for the Minecraft server to be ready")
+	if err = ms.WaitReady(time.Duration(replayWait) * time.Second); err != nil {
+		return err
+	}
+
+	results, err := ms.Replay(ctx, entries, opts)
+	failed := 0
+	for _, r := range results {
+		if r.IsError {
+			failed++
+			logger.Warn().Int("seq", r.Entry.Seq).Str("tool", r.Entry.Tool).Msg(r.Result)
+			continue
+		}
+		logger.Info().Int("seq", r.Entry.Seq).Str("tool", r.Entry.Tool).Msg(r.Result)
+	}
+	logger.Info().Int("replayed", len(results)).Int("failed", failed).Msg("Replay finished")
+	return err
+}
+
+func init() {
+	replayCmd.PersistentFlags().StringVarP(&replaySession, "session", "s", "", "Name of the recorded session to replay")
+	replayCmd.PersistentFlags().BoolVar(&replayList, "list", false, "List the recorded sessions")
+	replayCmd.PersistentFlags().IntVar(&replayFrom, "from", 0, "First sequence number to replay, default: from the beginning")
+	replayCmd.PersistentFlags().IntVar(&replayTo, "to", 0, "Last sequence number to replay, default: until the end")
+	replayCmd.PersistentFlags().StringVar(&replayTools, "tools", "", "Only replay these tools, separated by commas; admin tools are only replayed when listed")
+	replayCmd.PersistentFlags().StringVar(&replayOffset, "offset", "", "Translate absolute coordinates by dx,dy,dz")
+	replayCmd.PersistentFlags().StringVar(&replayServerRoot, "server_root", "", "Override serverRootPath of the target Minecraft server")
+	replayCmd.PersistentFlags().StringVar(&replayServerJar, "server_jar", "", "Override serverJarFile of the target Minecraft server")
+	replayCmd.PersistentFlags().BoolVar(&replayDryRun, "dry_run", false, "Only print the tool calls that would be replayed")
+	replayCmd.PersistentFlags().IntVar(&replayWait, "wait", 180, "Seconds to wait for the target server to start")
+	replayCmd.PersistentFlags().BoolVar(&replayKeepErrors, "include_errors", false, "Also replay tool calls that failed when they were recorded")
+	replayCmd.PersistentFlags().BoolVar(&replayStopOnErr, "stop_on_error", false, "Stop at the first tool call that fails during replay")
+	rootCmd.AddCommand(replayCmd)
+}
@@ -1 +1,58 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling-minecraft
+
 package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gojue/moling-minecraft/services"
+	"os"
+	"path/filepath"
+)
+
+// newMinecraftServer creates the Minecraft service with the config of the config file,
+// overridden by overrides. The server process is not started until Init is called.
+func newMinecraftServer(ctx context.Context, overrides map[string]interface{}) (*services.MinecraftServer, error) {
+	mcConfig := make(map[string]interface{})
+	configFilePath := filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile)
+	if nowConfig, err := os.ReadFile(configFilePath); err == nil {
+		nowConfigJson := make(map[string]interface{})
+		if err = json.Unmarshal(nowConfig, &nowConfigJson); err != nil {
+			return nil, fmt.Errorf("Error unmarshaling JSON: %v, config file:%s\n", err, configFilePath)
+		}
+		if cfg, ok := nowConfigJson[services.MinecraftServerName].(map[string]interface{}); ok {
+			mcConfig = cfg
+		}
+	}
+	for k, v := range overrides {
+		mcConfig[k] = v
+	}
+
+	srv, err := services.NewMinecraftServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ms, ok := srv.(*services.MinecraftServer)
+	if !ok {
+		return nil, fmt.Errorf("unexpected service type %T", srv)
+	}
+	if err = ms.LoadConfig(mcConfig); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
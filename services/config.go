@@ -17,6 +17,7 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/rs/zerolog"
 	"reflect"
@@ -85,7 +86,14 @@ func mergeJSONToStruct(target interface{}, jsonMap map[string]interface{}) error
 					if jsonVal.Type().ConvertibleTo(fieldVal.Type()) {
 						fieldVal.Set(jsonVal.Convert(fieldVal.Type()))
 					} else {
-						return fmt.Errorf("type mismatch for field %s, value:%v", jsonKey, jsonValue)
+						// 嵌套的对象和数组通过JSON重新解析
+						data, err := json.Marshal(jsonValue)
+						if err == nil {
+							err = json.Unmarshal(data, fieldVal.Addr().Interface())
+						}
+						if err != nil {
+							return fmt.Errorf("type mismatch for field %s, value:%v", jsonKey, jsonValue)
+						}
 					}
 				}
 			}
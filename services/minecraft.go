@@ -27,8 +27,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -54,12 +54,31 @@ type MinecraftServer struct {
 	serverCancel context.CancelFunc // Function to cancel the server context
 	serverWg     sync.WaitGroup     // WaitGroup for server goroutines
 	isRunning    bool               // Flag indicating if the server process is running
+	launching    bool               // A server process is being started but is not running yet
 	mu           sync.Mutex         // Mutex to protect access to shared resources (cmd, pipes, isRunning)
 
 	responseChans  map[string]chan string // 命令ID到响应通道的映射
 	responseMu     sync.Mutex             // 保护responseChans的互斥锁
 	pipesClosedMu  sync.Mutex
 	pipesClosedMap map[string]bool // 记录每个管道是否已关闭
+
+	journal   *SessionJournal // Records tool calls of every MCP session for replay
+	waypoints *WaypointStore  // Named anchors usable in every coordinate argument
+	backups   *BackupStore    // World backups in BasePath/data/backups
+	logs      logStream       // Recent server output and the sessions subscribed to it
+	ready     chan struct{}   // Closed once the server logged its "Done" line
+	fontMu    sync.Mutex
+	fonts     map[string]*BitmapFont // Parsed .hex fonts by path
+
+	events      eventBus        // Game events parsed from the server output
+	eventParser *LogEventParser // Event patterns of the configured game version
+	chat        chatQueue       // Requests players sent from the game chat
+	tracker     playerTracker   // Last known states of the online players
+
+	configPath  string            // Config file the scheduled jobs are saved to
+	jobsMu      sync.Mutex        // Protects config.ScheduledJobs, jobRuns and jobsRunning
+	jobRuns     map[string]jobRun // Last run of each scheduled job
+	jobsRunning map[string]bool   // Scheduled jobs currently running
 }
 
 // NewMinecraftServer creates a new MinecraftServer instance with the given context and configuration.
@@ -69,6 +88,7 @@ func NewMinecraftServer(ctx context.Context) (Service, error) {
 	if !ok {
 		return nil, fmt.Errorf("MinecraftServer: invalid global config type: %T", ctx.Value(MoLingConfigKey))
 	}
+	mc.AssetsPath = filepath.Join(globalConf.BasePath, "data", "assets")
 
 	logger, ok := ctx.Value(MoLingLoggerKey).(zerolog.Logger)
 	if !ok {
@@ -90,6 +110,13 @@ func NewMinecraftServer(ctx context.Context) (Service, error) {
 		isRunning:      false,
 		responseChans:  make(map[string]chan string),
 		pipesClosedMap: make(map[string]bool),
+		journal:        NewSessionJournal(filepath.Join(globalConf.BasePath, "data", JournalDirName)),
+		waypoints:      NewWaypointStore(filepath.Join(globalConf.BasePath, "data", WaypointFileName)),
+		backups:        NewBackupStore(filepath.Join(globalConf.BasePath, "data", BackupDirName)),
+		configPath:     filepath.Join(globalConf.BasePath, globalConf.ConfigFile),
+		jobRuns:        make(map[string]jobRun),
+		jobsRunning:    make(map[string]bool),
+		ready:          make(chan struct{}),
 	}
 
 	//Init loads config and sets up tools/prompts
@@ -126,19 +153,35 @@ func (ms *MinecraftServer) Init() error {
 	// Register tools (commands)
 	ms.registerTools()
 
+	// Recognize the log messages of the configured game version
+	ms.eventParser = NewLogEventParser(ms.config.GameVersion)
+
 	// Start the server process in a goroutine *after* config is loaded and tools are registered
+	ms.launchServerProcess()
+
+	// Run the scheduled jobs until the service is closed
 	ms.serverWg.Add(1)
 	go func() {
 		defer ms.serverWg.Done()
-		err := ms.startMinecraftServerProcess() // Renamed internal function
-		if err != nil && !errors.Is(err, context.Canceled) {
-			ms.logger.Error().Err(err).Msg("Minecraft server process failed")
-			ms.mu.Lock()
-			ms.isRunning = false
-			ms.mu.Unlock()
-		}
+		ms.runScheduler(ms.serverCtx)
 	}()
 
+	// Keep track of the players joining and leaving
+	ms.serverWg.Add(1)
+	go func() {
+		defer ms.serverWg.Done()
+		ms.runPlayerTracker(ms.serverCtx)
+	}()
+
+	// Forward chat requests of the allowed players to the assistant
+	if len(ms.config.ChatPlayers) > 0 {
+		ms.serverWg.Add(1)
+		go func() {
+			defer ms.serverWg.Done()
+			ms.runChatBridge(ms.serverCtx)
+		}()
+	}
+
 	// Wait briefly for the server to potentially start up
 	// A more robust check would involve parsing server logs for a "Done" message
 	//ms.logger.Info().Msgf("Waiting up to %d seconds for server startup...", ms.config.StartupTimeout)
@@ -161,6 +204,7 @@ func (ms *MinecraftServer) registerTools() {
 		mcp.WithString("z2", mcp.Description("Ending Z coordinate"), mcp.Required()),
 		mcp.WithString("block", mcp.Description("Block ID (e.g., minecraft:stone)"), mcp.Required()),
 		mcp.WithString("oldBlockHandling", mcp.Description("How to handle existing blocks (replace, destroy, keep, hollow, outline) (optional)")),
+		mcp.WithString("player", mcp.Description("Player whose position relative (~) and local (^) coordinates refer to (optional, default: the world spawn, where console commands run)")),
 	), ms.handleFill)
 
 	ms.AddTool(mcp.NewTool(
@@ -171,6 +215,7 @@ func (ms *MinecraftServer) registerTools() {
 		mcp.WithString("z", mcp.Description("Z coordinate"), mcp.Required()),
 		mcp.WithString("block", mcp.Description("Block ID (e.g., minecraft:torch[lit=true])"), mcp.Required()),
 		mcp.WithString("oldBlockHandling", mcp.Description("How to handle existing blocks (replace, destroy, keep) (optional)")),
+		mcp.WithString("player", mcp.Description("Player whose position relative (~) and local (^) coordinates refer to (optional, default: the world spawn, where console commands run)")),
 	), ms.handleSetblock)
 
 	ms.AddTool(mcp.NewTool(
@@ -188,6 +233,7 @@ func (ms *MinecraftServer) registerTools() {
 		mcp.WithString("maskMode", mcp.Description("Mask mode (replace, masked, filtered) (optional, default: replace)")), // Renamed from filterMode for clarity
 		mcp.WithString("cloneMode", mcp.Description("Clone mode (force, move, normal) (optional, default: normal)")),
 		mcp.WithString("filterBlock", mcp.Description("Filter block ID (required if maskMode is 'filtered')")),
+		mcp.WithString("player", mcp.Description("Player whose position relative (~) and local (^) coordinates refer to (optional, default: the world spawn, where console commands run)")),
 	), ms.handleClone)
 
 	ms.AddTool(mcp.NewTool(
@@ -208,10 +254,11 @@ func (ms *MinecraftServer) registerTools() {
 
 	ms.AddTool(mcp.NewTool(
 		"minecraft_give",
-		mcp.WithDescription("Give an item to a player"),
-		mcp.WithString("target", mcp.Description("Target player selector (e.g., @p, PlayerName)"), mcp.Required()),
-		mcp.WithString("item", mcp.Description("Item ID (e.g., minecraft:diamond_sword)"), mcp.Required()),
-		mcp.WithNumber("amount", mcp.Description("Amount (optional, default: 1)")),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Give an item to a player, optionally with a custom name, lore, enchantments and attribute modifiers"),
+			mcp.WithString("target", mcp.Description("Target player selector (e.g., @p, PlayerName)"), mcp.Required()),
+			mcp.WithNumber("amount", mcp.Description("Amount (optional, default: 1)")),
+		}, itemStackOptions()...)...,
 	), ms.handleGive)
 
 	ms.AddTool(mcp.NewTool(
@@ -271,6 +318,28 @@ func (ms *MinecraftServer) registerTools() {
 		mcp.WithString("y", mcp.Description("Y coordinate (optional)")),
 		mcp.WithString("z", mcp.Description("Z coordinate (optional)")),
 	), ms.handleSpawnpoint)
+
+	ms.registerWaypointTools()
+	ms.registerPixelArtTools()
+	ms.registerModelTools()
+	ms.registerTextTools()
+	ms.registerNatureTools()
+	ms.registerTerrainTools()
+	ms.registerRoadTools()
+	ms.registerRailwayTools()
+	ms.registerRedstoneTools()
+	ms.registerDatapackTools()
+	ms.registerPropertiesTools()
+	ms.registerBackupTools()
+	ms.registerSchedulerTools()
+	ms.registerLogTools()
+	ms.registerChatTools()
+	ms.registerLogSearchTools()
+	ms.registerPlayerTools()
+	ms.registerTrackingTools()
+	ms.registerScoreboardTools()
+	ms.registerRichTextTools()
+	ms.registerItemTools()
 }
 
 // Helper function for extracting and validating string parameters
@@ -292,26 +361,110 @@ func getStringArg(args map[string]interface{}, key string, required bool) (strin
 	return strVal, nil
 }
 
-// Helper function for extracting and validating coordinate parameters
-func getCoordArgs(args map[string]interface{}, keys ...string) ([]string, error) {
+// Helper function for extracting and validating coordinate parameters.
+// Waypoint references (e.g. "plaza" or "plaza+5,0,-3") are resolved to absolute coordinates,
+// keys given as x/y/z triples must not mix local (^) with absolute or relative (~) coordinates.
+func (ms *MinecraftServer) getCoordArgs(args map[string]interface{}, keys ...string) ([]string, error) {
+	resolved, err := ms.resolveCoordRefs(args, keys)
+	if err != nil {
+		return nil, err
+	}
 	coords := make([]string, len(keys))
+	parsed := make([]Coord, len(keys))
 	for i, key := range keys {
-		coordStr, err := getStringArg(args, key, true)
+		coordStr, ok := resolved[key]
+		if !ok {
+			if _, err := getStringArg(args, key, true); err != nil {
+				return nil, err
+			}
+		}
+		if coordStr == "" {
+			return nil, fmt.Errorf("required parameter %s cannot be empty", key)
+		}
+		c, err := ParseCoord(coordStr)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid coordinate format for %s: %s", key, coordStr)
 		}
-		// Basic validation: check if it can be parsed as a float (allows integers, decimals, relative coords ~)
-		if _, err := strconv.ParseFloat(strings.Replace(coordStr, "~", "0", -1), 64); err != nil && !strings.HasPrefix(coordStr, "~") {
-			// Allow pure "~" but reject invalid numbers
-			if coordStr != "~" {
-				return nil, fmt.Errorf("invalid coordinate format for %s: %s", key, coordStr)
+		parsed[i] = c
+		coords[i] = c.String()
+	}
+	if len(keys)%3 == 0 {
+		for i := 0; i < len(keys); i += 3 {
+			if err := (Position{parsed[i], parsed[i+1], parsed[i+2]}).check(); err != nil {
+				return nil, fmt.Errorf("invalid coordinates %s/%s/%s: %w", keys[i], keys[i+1], keys[i+2], err)
 			}
 		}
-		coords[i] = coordStr
 	}
 	return coords, nil
 }
 
+// getPositionArg extracts the x/y/z coordinate arguments xKey, yKey and zKey as a Position.
+func (ms *MinecraftServer) getPositionArg(args map[string]interface{}, xKey, yKey, zKey string) (Position, error) {
+	coords, err := ms.getCoordArgs(args, xKey, yKey, zKey)
+	if err != nil {
+		return Position{}, err
+	}
+	return ParsePosition(coords[0], coords[1], coords[2])
+}
+
+// getExecutorArg reads the optional player argument, the player whose position
+// relative (~) and local (^) coordinates in positions refer to. Without it they refer
+// to the world spawn, where the server console runs commands, and the executor is nil.
+func (ms *MinecraftServer) getExecutorArg(args map[string]interface{}, positions ...Position) (string, *Executor, error) {
+	player, err := getStringArg(args, "player", false)
+	if err != nil {
+		return "", nil, err
+	}
+	if player = strings.TrimSpace(player); player == "" {
+		return "", nil, nil
+	}
+	if !playerNameRegex.MatchString(player) {
+		return "", nil, fmt.Errorf("invalid player name %q", player)
+	}
+	relative, local := false, false
+	for _, p := range positions {
+		relative = relative || !p.IsAbsolute()
+		local = local || p.IsLocal()
+	}
+	if !relative {
+		return player, nil, nil
+	}
+	exec, err := ms.playerExecutor(player, local)
+	if err != nil {
+		return "", nil, err
+	}
+	return player, exec, nil
+}
+
+// executeAt returns command run at the position and rotation of player, or command
+// itself without a player.
+func (ms *MinecraftServer) executeAt(player, command string) string {
+	if player == "" {
+		return command
+	}
+	if !ms.config.VersionAtLeast("1.13") {
+		return fmt.Sprintf("/execute %s ~ ~ ~ %s", player, strings.TrimPrefix(command, "/"))
+	}
+	return fmt.Sprintf("/execute at %s run %s", player, strings.TrimPrefix(command, "/"))
+}
+
+// checkRegionVolume enforces the MaxFillVolume policy for a region command such as /fill or /clone.
+// exec is the position relative and local coordinates refer to, if known. Regions whose size
+// cannot be computed without it (e.g. absolute mixed with relative) are rejected.
+func (ms *MinecraftServer) checkRegionVolume(p1, p2 Position, exec *Executor) error {
+	if ms.config.MaxFillVolume <= 0 {
+		return nil
+	}
+	volume, err := RegionVolume(p1, p2, exec)
+	if err != nil {
+		return fmt.Errorf("the size of region %s to %s is unknown: use absolute coordinates, or the player argument for relative (~) and local (^) ones", p1, p2)
+	}
+	if volume > ms.config.MaxFillVolume {
+		return fmt.Errorf("region %s to %s contains %d blocks, more than the limit of %d, please split it into smaller regions", p1, p2, volume, ms.config.MaxFillVolume)
+	}
+	return nil
+}
+
 // Helper function for validating block ID format (basic)
 func validateBlockID(blockID string) error {
 	if blockID == "" {
@@ -427,20 +580,98 @@ func (ms *MinecraftServer) LoadConfig(jsonData map[string]interface{}) error {
 	return nil
 }
 
-// startMinecraftServerProcess starts the actual Minecraft server process.
+// launchServerProcess starts the server process in a goroutine. The caller holds
+// ms.mu, as Init and StartServer do, so the running check and the launch are atomic.
+func (ms *MinecraftServer) launchServerProcess() {
+	ms.launching = true
+	// Each process gets its own ready channel, output of an old process still being
+	// read must not close the channel of the new one
+	ready := make(chan struct{})
+	var readyOnce sync.Once
+	ms.ready = ready
+	markReady := func() { readyOnce.Do(func() { close(ready) }) }
+	ms.pipesClosedMu.Lock()
+	ms.pipesClosedMap = make(map[string]bool)
+	ms.pipesClosedMu.Unlock()
+
+	ms.serverWg.Add(1)
+	go func() {
+		defer ms.serverWg.Done()
+		err := ms.startMinecraftServerProcess(markReady) // Renamed internal function
+		if err != nil && !errors.Is(err, context.Canceled) {
+			ms.logger.Error().Err(err).Msg("Minecraft server process failed")
+		}
+	}()
+}
+
+// StopServer stops the server process with the shutdown command, killing it when it
+// is still running after timeout. Unlike Close the service stays usable, so the
+// server can be started again with StartServer.
+func (ms *MinecraftServer) StopServer(timeout time.Duration) error {
+	if !ms.isServerRunning() {
+		return nil
+	}
+	ms.logger.Info().Str("command", ms.config.ShutdownCommand).Msg("Stopping Minecraft server")
+	if _, err := ms.WriteCommand(ms.config.ShutdownCommand); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for ms.isServerRunning() {
+		if time.Now().After(deadline) {
+			ms.logger.Warn().Dur("timeout", timeout).Msg("Minecraft server did not stop in time, killing it")
+			ms.mu.Lock()
+			if ms.cmd != nil && ms.cmd.Process != nil {
+				_ = ms.cmd.Process.Kill()
+			}
+			ms.mu.Unlock()
+			deadline = time.Now().Add(timeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return nil
+}
+
+// StartServer starts the server process again after StopServer.
+func (ms *MinecraftServer) StartServer() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.isRunning || ms.launching {
+		return fmt.Errorf("server already running")
+	}
+	if ms.serverCtx.Err() != nil {
+		return fmt.Errorf("the Minecraft service is closed")
+	}
+	ms.launchServerProcess()
+	return nil
+}
+
+// startMinecraftServerProcess starts the actual Minecraft server process and calls
+// markReady once it is done loading.
 // This runs in its own goroutine managed by Init.
-func (ms *MinecraftServer) startMinecraftServerProcess() error {
+func (ms *MinecraftServer) startMinecraftServerProcess(markReady func()) error {
 	ms.mu.Lock()
 	if ms.isRunning {
+		ms.launching = false
 		ms.mu.Unlock()
 		ms.logger.Warn().Msg("Attempted to start server process, but it is already running.")
 		return fmt.Errorf("server already running")
 	}
 
 	ms.logger.Info().Msg("Attempting to start Minecraft server process...")
+	steps, err := ms.Bootstrap(false)
+	for _, step := range steps {
+		ms.logger.Info().Str("path", ms.config.ServerRootPath).Msg("Server directory: " + step)
+	}
+	if err != nil {
+		ms.launching = false
+		ms.mu.Unlock()
+		ms.logger.Error().Err(err).Msg("Cannot start the Minecraft server")
+		return err
+	}
 	ms.logger.Info().Str("path", ms.config.ServerRootPath).Msg("Changing directory")
-	err := os.Chdir(ms.config.ServerRootPath)
+	err = os.Chdir(ms.config.ServerRootPath)
 	if err != nil {
+		ms.launching = false
 		ms.mu.Unlock()
 		ms.logger.Err(err).Str("path", ms.config.ServerRootPath).Msg("Failed to change directory")
 		return fmt.Errorf("failed to change directory to %s: %w", ms.config.ServerRootPath, err)
@@ -469,6 +700,7 @@ func (ms *MinecraftServer) startMinecraftServerProcess() error {
 	// Get pipes
 	stdinPipe, err := cmd.StdinPipe()
 	if err != nil {
+		ms.launching = false
 		ms.mu.Unlock()
 		ms.logger.Err(err).Msg("Failed to get stdin pipe")
 		return fmt.Errorf("failed to get stdin pipe: %w", err)
@@ -477,6 +709,7 @@ func (ms *MinecraftServer) startMinecraftServerProcess() error {
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
+		ms.launching = false
 		ms.mu.Unlock()
 		ms.logger.Err(err).Msg("Failed to get stdout pipe")
 		return fmt.Errorf("failed to get stdout pipe: %w", err)
@@ -485,6 +718,7 @@ func (ms *MinecraftServer) startMinecraftServerProcess() error {
 
 	stderrPipe, err := cmd.StderrPipe()
 	if err != nil {
+		ms.launching = false
 		ms.mu.Unlock()
 		ms.logger.Err(err).Msg("Failed to get stderr pipe")
 		return fmt.Errorf("failed to get stderr pipe: %w", err)
@@ -500,6 +734,7 @@ func (ms *MinecraftServer) startMinecraftServerProcess() error {
 	if err != nil {
 		ms.mu.Lock()
 		ms.isRunning = false // Ensure flag is false if start fails
+		ms.launching = false
 		ms.mu.Unlock()
 		ms.logger.Err(err).Msg("Failed to start Minecraft server process")
 		// Clean up pipes? StdinPipe.Close() maybe?
@@ -508,17 +743,18 @@ func (ms *MinecraftServer) startMinecraftServerProcess() error {
 
 	ms.mu.Lock()
 	ms.isRunning = true
+	ms.launching = false
 	pid := cmd.Process.Pid
 	ms.logger.Info().Int("pid", pid).Msg("Minecraft server process started successfully.")
 	ms.mu.Unlock()
 
 	// Goroutine to log stdout
 	ms.serverWg.Add(1)
-	go ms.logPipe("stdout", ms.stdoutPipe)
+	go ms.logPipe("stdout", stdoutPipe, markReady)
 
 	// Goroutine to log stderr
 	ms.serverWg.Add(1)
-	go ms.logPipe("stderr", ms.stderrPipe)
+	go ms.logPipe("stderr", stderrPipe, markReady)
 
 	// Wait for the process to exit in this goroutine
 	err = cmd.Wait()
@@ -544,9 +780,10 @@ func (ms *MinecraftServer) startMinecraftServerProcess() error {
 	return nil
 }
 
-// logPipe reads from a pipe (stdout/stderr) and logs it line by line.
+// logPipe reads from a pipe (stdout/stderr) and logs it line by line, calling
+// markReady when the server logs that it is done loading.
 // Runs in its own goroutine managed by serverWg.
-func (ms *MinecraftServer) logPipe(pipeName string, pipe io.ReadCloser) {
+func (ms *MinecraftServer) logPipe(pipeName string, pipe io.ReadCloser, markReady func()) {
 	defer ms.serverWg.Done()
 	defer func() {
 		ms.pipesClosedMu.Lock()
@@ -566,8 +803,17 @@ func (ms *MinecraftServer) logPipe(pipeName string, pipe io.ReadCloser) {
 		// Log server output - adjust level as needed (e.g., Info or Debug)
 		logger.Info().Msg(line) // Using Info to make server logs visible by default
 
+		fallback := mcp.LoggingLevelInfo
+		if pipeName == "stderr" {
+			fallback = mcp.LoggingLevelError
+		}
+		event, ok := ms.publishLogLine(parseServerLog(line, fallback))
+
 		// 仅处理stdout管道的输出
 		if pipeName == "stdout" {
+			if ok && event.Type == EventServerDone {
+				markReady()
+			}
 			// 将输出发送给所有等待响应的通道
 			ms.responseMu.Lock()
 			for _, ch := range ms.responseChans {
@@ -690,51 +936,160 @@ func (ms *MinecraftServer) WriteCommand(command string) (*mcp.CallToolResult, er
 	return mcp.NewToolResultText(fmt.Sprintf("Command '%s' executed: %s", command, getMcMessage(fullResponse))), nil
 }
 
+// watchCommand registers a response channel receiving the stdout lines of the server,
+// then writes a command. The returned function unregisters the channel.
+func (ms *MinecraftServer) watchCommand(command string) (<-chan string, func(), error) {
+	respChan := make(chan string, 100)
+	cmdID := fmt.Sprintf("watch-%d", time.Now().UnixNano())
+	ms.responseMu.Lock()
+	ms.responseChans[cmdID] = respChan
+	ms.responseMu.Unlock()
+	done := func() {
+		ms.responseMu.Lock()
+		delete(ms.responseChans, cmdID)
+		close(respChan)
+		ms.responseMu.Unlock()
+	}
+
+	ms.mu.Lock()
+	if !ms.isRunning || ms.stdinPipe == nil {
+		ms.mu.Unlock()
+		done()
+		return nil, nil, fmt.Errorf("Minecraft server is not running")
+	}
+	_, err := ms.stdinPipe.Write([]byte(command + "\n"))
+	ms.mu.Unlock()
+	if err != nil {
+		done()
+		return nil, nil, fmt.Errorf("failed to write command: %w", err)
+	}
+	return respChan, done, nil
+}
+
+// waitForCommand writes a command and waits until the server logs a line containing
+// want, for commands that finish later than their first response such as
+// "save-all flush", which logs "Saved the game" once all chunks are written.
+func (ms *MinecraftServer) waitForCommand(command, want string, timeout time.Duration) error {
+	respChan, done, err := ms.watchCommand(command)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case line := <-respChan:
+			if strings.Contains(line, want) {
+				return nil
+			}
+		case <-deadline:
+			return fmt.Errorf("%s did not finish within %s", command, timeout)
+		}
+	}
+}
+
+// commandLines writes a command and returns the messages the server logs until it is
+// quiet for commandQuietPeriod, for commands whose response spans several lines such
+// as "banlist".
+func (ms *MinecraftServer) commandLines(command string) ([]string, error) {
+	respChan, done, err := ms.watchCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	deadline := time.After(time.Duration(ms.config.CommandTimeout) * time.Second)
+	var lines []string
+	for {
+		var quiet <-chan time.Time
+		if len(lines) > 0 {
+			quiet = time.After(commandQuietPeriod)
+		}
+		select {
+		case line := <-respChan:
+			lines = append(lines, parseServerLog(line, mcp.LoggingLevelInfo).Message)
+		case <-quiet:
+			return lines, nil
+		case <-deadline:
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("no response to %s within %d seconds", command, ms.config.CommandTimeout)
+			}
+			return lines, nil
+		}
+	}
+}
+
 func init() {
 	RegisterServ(MinecraftServerName, NewMinecraftServer)
 }
 
-// isMcSuccessLog checks if a log line indicates a successful command execution in Minecraft.
-func isMcSuccessLog(line string) bool {
-	// 命令执行成功的典型模式
-	successPatterns := []string{
-		"Successfully",     // 通用成功消息
-		"blocks filled",    // fill命令成功
-		"blocks changed",   // setblock命令成功
-		"blocks copied",    // clone命令成功
-		"summoned",         // summon命令成功
-		"Given ",           // give命令成功
-		"Teleported ",      // teleport命令成功
-		"players match",    // 选择器匹配成功
-		"entity was found", // 实体查找成功
-		"Setting ",         // 设置游戏规则等成功
-	}
-
-	// 命令执行失败的典型模式
-	failurePatterns := []string{
-		"Error:",                 // 通用错误消息
-		"failed",                 // 通用失败消息
-		"Could not ",             // 常见错误前缀
-		"Unknown command",        // 未知命令
-		"Usage:",                 // 命令用法错误
-		"position is not loaded", // 位置未加载
-		"Expected ",              // 命令参数期望错误
-		"Not a valid ",           // 无效参数
-		"No entity was found",    // 未找到实体
-		"no elements",            // 没有元素
-		"No game rule",           // 游戏规则不存在
-		"is not valid",           // 通用无效消息
-	}
+// mcSuccessPatterns are the typical responses of successful commands.
+var mcSuccessPatterns = []string{
+	"Successfully",     // 通用成功消息
+	"blocks filled",    // fill命令成功
+	"blocks changed",   // setblock命令成功
+	"blocks copied",    // clone命令成功
+	"summoned",         // summon命令成功
+	"Given ",           // give命令成功
+	"Teleported ",      // teleport命令成功
+	"players match",    // 选择器匹配成功
+	"entity was found", // 实体查找成功
+	"Setting ",         // 设置游戏规则等成功
+	"Set the ",         // weather/time命令成功
+	"saving is now",    // save-off/save-on命令成功
+	"Reloading!",       // reload命令成功
+	"difficulty ",      // difficulty命令成功或难度未改变
+	"game mode is now", // defaultgamemode命令成功
+	"Whitelist is ",    // whitelist on/off命令成功
+	"entity data:",     // data get命令成功
+	"Made ",            // op/deop命令成功
+	"Banned ",          // ban/ban-ip命令成功
+	"Unbanned ",        // pardon/pardon-ip命令成功
+	"Kicked ",          // kick命令成功
+	"the whitelist",    // whitelist add/remove/reload命令成功
+	"game mode to",     // gamemode命令成功
+	"Showing new ",     // title命令成功
+	"title times",      // title times命令成功
+	"Gave ",            // give命令成功
+	"Replaced a slot",  // item replace/replaceitem命令成功
+}
 
-	// 首先检查是否包含失败模式，因为失败优先级更高
-	for _, pattern := range failurePatterns {
+// mcFailurePatterns are the typical responses of failed commands.
+var mcFailurePatterns = []string{
+	"Error:",                 // 通用错误消息
+	"failed",                 // 通用失败消息
+	"Could not ",             // 常见错误前缀
+	"Unknown command",        // 未知命令
+	"Usage:",                 // 命令用法错误
+	"position is not loaded", // 位置未加载
+	"Expected ",              // 命令参数期望错误
+	"Not a valid ",           // 无效参数
+	"No entity was found",    // 未找到实体
+	"no elements",            // 没有元素
+	"No game rule",           // 游戏规则不存在
+	"is not valid",           // 通用无效消息
+}
+
+// isMcFailureLog checks if a log line indicates a failed command execution in Minecraft.
+func isMcFailureLog(line string) bool {
+	for _, pattern := range mcFailurePatterns {
 		if strings.Contains(line, pattern) {
-			return false
+			return true
 		}
 	}
+	return false
+}
+
+// isMcSuccessLog checks if a log line indicates a successful command execution in Minecraft.
+func isMcSuccessLog(line string) bool {
+	// 首先检查是否包含失败模式，因为失败优先级更高
+	if isMcFailureLog(line) {
+		return false
+	}
 
 	// 然后检查是否包含成功模式
-	for _, pattern := range successPatterns {
+	for _, pattern := range mcSuccessPatterns {
 		if strings.Contains(line, pattern) {
 			return true
 		}
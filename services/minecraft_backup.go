@@ -0,0 +1,545 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// BackupDirName is the directory under BasePath/data where world backups are stored.
+	BackupDirName = "backups"
+	// serverStopTimeout is how long a restore waits for the server to stop.
+	serverStopTimeout = 60 * time.Second
+	// serverSaveTimeout is how long a backup waits for the world to be saved.
+	serverSaveTimeout = 120 * time.Second
+)
+
+var backupLabelRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// BackupInfo describes a world backup archive.
+type BackupInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+// BackupStore manages the world backup archives in a directory.
+type BackupStore struct {
+	dir string
+}
+
+// NewBackupStore creates a store for the backups in dir.
+func NewBackupStore(dir string) *BackupStore {
+	return &BackupStore{dir: dir}
+}
+
+// isBackupName reports whether name is an archive name of the store.
+func isBackupName(name string) bool {
+	return name == filepath.Base(name) && (strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".zip"))
+}
+
+// Create archives dirs, relative to root, into a new backup named after the first
+// directory, the time and label; a number is appended when the name is taken.
+// Session locks are left out.
+func (s *BackupStore) Create(root string, dirs []string, label, format string) (BackupInfo, error) {
+	if len(dirs) == 0 {
+		return BackupInfo{}, fmt.Errorf("nothing to back up")
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return BackupInfo{}, err
+	}
+	now := time.Now()
+	base := filepath.Base(dirs[0]) + "-" + now.Format("20060102-150405.000")
+	if label = strings.Trim(backupLabelRegex.ReplaceAllString(label, "_"), "_"); label != "" {
+		base += "-" + label
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".partial-*")
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	defer os.Remove(tmp.Name())
+	var add func(rel string, info fs.FileInfo, file string) error
+	var finish func() error
+	switch format {
+	case "tar.gz":
+		gw := gzip.NewWriter(tmp)
+		tw := tar.NewWriter(gw)
+		add = func(rel string, info fs.FileInfo, file string) error {
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = rel
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			return copyFileTo(tw, file, info)
+		}
+		finish = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return gw.Close()
+		}
+	case "zip":
+		zw := zip.NewWriter(tmp)
+		add = func(rel string, info fs.FileInfo, file string) error {
+			hdr, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			hdr.Name = rel
+			if info.IsDir() {
+				hdr.Name += "/"
+			} else {
+				hdr.Method = zip.Deflate
+			}
+			w, err := zw.CreateHeader(hdr)
+			if err != nil {
+				return err
+			}
+			return copyFileTo(w, file, info)
+		}
+		finish = zw.Close
+	default:
+		_ = tmp.Close()
+		return BackupInfo{}, fmt.Errorf("unsupported backup format %s", format)
+	}
+
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(file string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Name() == "session.lock" || !(info.IsDir() || info.Mode().IsRegular()) {
+				return nil
+			}
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			return add(filepath.ToSlash(rel), info, file)
+		})
+		if err != nil {
+			_ = tmp.Close()
+			return BackupInfo{}, fmt.Errorf("failed to archive %s: %w", dir, err)
+		}
+	}
+	if err := finish(); err != nil {
+		_ = tmp.Close()
+		return BackupInfo{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return BackupInfo{}, err
+	}
+	// Link fails when the name is taken, unlike Rename which replaces the backup
+	name := base + "." + format
+	for i := 2; ; i++ {
+		err := os.Link(tmp.Name(), filepath.Join(s.dir, name))
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return BackupInfo{}, err
+		}
+		name = fmt.Sprintf("%s-%d.%s", base, i, format)
+	}
+	info, err := os.Stat(filepath.Join(s.dir, name))
+	if err != nil {
+		return BackupInfo{}, err
+	}
+	return BackupInfo{Name: name, Size: info.Size(), Created: info.ModTime()}, nil
+}
+
+// copyFileTo copies the content of a regular file to w.
+func copyFileTo(w io.Writer, file string, info fs.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// List returns the backups, newest first.
+func (s *BackupStore) List() ([]BackupInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []BackupInfo
+	for _, e := range entries {
+		if e.IsDir() || !isBackupName(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, BackupInfo{Name: e.Name(), Size: info.Size(), Created: info.ModTime()})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Created.Equal(list[j].Created) {
+			return list[i].Created.After(list[j].Created)
+		}
+		return list[i].Name > list[j].Name
+	})
+	return list, nil
+}
+
+// Prune deletes all but the keep newest backups and returns the deleted names.
+func (s *BackupStore) Prune(keep int) ([]string, error) {
+	list, err := s.List()
+	if err != nil || keep <= 0 || len(list) <= keep {
+		return nil, err
+	}
+	var deleted []string
+	for _, b := range list[keep:] {
+		if err := os.Remove(filepath.Join(s.dir, b.Name)); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, b.Name)
+	}
+	return deleted, nil
+}
+
+// Extract unpacks the backup name into dest and returns the top level directories.
+func (s *BackupStore) Extract(name, dest string) ([]string, error) {
+	if !isBackupName(name) {
+		return nil, fmt.Errorf("invalid backup name %s", name)
+	}
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("backup %s not found", name)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	tops := make(map[string]bool)
+	write := func(entry string, dir bool, mode fs.FileMode, r io.Reader) error {
+		clean := path.Clean(entry)
+		if clean == "." || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid path %s in backup", entry)
+		}
+		tops[strings.SplitN(clean, "/", 2)[0]] = true
+		target := filepath.Join(dest, filepath.FromSlash(clean))
+		if dir {
+			return os.MkdirAll(target, 0o755)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, r); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = write(zf.Name, zf.FileInfo().IsDir(), zf.Mode(), rc)
+			_ = rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Typeflag != tar.TypeDir && hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := write(hdr.Name, hdr.Typeflag == tar.TypeDir, fs.FileMode(hdr.Mode), tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(tops))
+	for d := range tops {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// worldDirs returns the directories of the world save. Servers like Spigot keep the
+// Nether and the End next to the overworld instead of inside it.
+func (ms *MinecraftServer) worldDirs() []string {
+	world := ms.worldDir()
+	dirs := []string{world}
+	for _, suffix := range []string{"_nether", "_the_end"} {
+		if info, err := os.Stat(world + suffix); err == nil && info.IsDir() {
+			dirs = append(dirs, world+suffix)
+		}
+	}
+	return dirs
+}
+
+// CreateBackup archives the world. A running server is told to flush its chunks
+// and to stop saving while the files are copied, over RCON when another process runs
+// it. Old backups are pruned to BackupRetention, the pruned names are returned with
+// the new backup.
+func (ms *MinecraftServer) CreateBackup(label string) (BackupInfo, []string, error) {
+	if _, err := os.Stat(ms.worldDir()); err != nil {
+		return BackupInfo{}, nil, fmt.Errorf("world %s not found: %w", ms.worldDir(), err)
+	}
+	if ms.isServerRunning() {
+		result, err := ms.WriteCommand("/save-off")
+		if err != nil {
+			return BackupInfo{}, nil, err
+		}
+		if result.IsError {
+			return BackupInfo{}, nil, fmt.Errorf("failed to turn off saving: %s", toolResultText(result))
+		}
+		defer func() {
+			if result, err := ms.WriteCommand("/save-on"); err != nil || result.IsError {
+				ms.logger.Error().Err(err).Msg("Failed to turn saving back on after the backup, run /save-on")
+			}
+		}()
+		if err := ms.waitForCommand("/save-all flush", "Saved the game", serverSaveTimeout); err != nil {
+			return BackupInfo{}, nil, fmt.Errorf("failed to save the world: %w", err)
+		}
+	} else {
+		rcon, err := ms.dialRcon()
+		if err != nil {
+			return BackupInfo{}, nil, fmt.Errorf("the server may be running but cannot be reached over RCON: %w", err)
+		}
+		if rcon != nil {
+			defer rcon.Close()
+			if _, err := rcon.Command("save-off"); err != nil {
+				return BackupInfo{}, nil, fmt.Errorf("failed to turn off saving: %w", err)
+			}
+			defer func() {
+				if _, err := rcon.Command("save-on"); err != nil {
+					ms.logger.Error().Err(err).Msg("Failed to turn saving back on after the backup, run /save-on")
+				}
+			}()
+			out, err := rcon.Command("save-all flush")
+			if err != nil {
+				return BackupInfo{}, nil, fmt.Errorf("failed to save the world: %w", err)
+			}
+			if !strings.Contains(out, "Saved the game") {
+				return BackupInfo{}, nil, fmt.Errorf("failed to save the world: %s", out)
+			}
+		}
+	}
+
+	info, err := ms.backups.Create(ms.config.ServerRootPath, ms.worldDirs(), label, ms.config.BackupFormat)
+	if err != nil {
+		return BackupInfo{}, nil, err
+	}
+	ms.logger.Info().Str("backup", info.Name).Int64("size", info.Size).Msg("Created world backup")
+	pruned, err := ms.backups.Prune(ms.config.BackupRetention)
+	if err != nil {
+		ms.logger.Warn().Err(err).Msg("Failed to prune old backups")
+	}
+	return info, pruned, nil
+}
+
+// ListBackups returns the world backups, newest first.
+func (ms *MinecraftServer) ListBackups() ([]BackupInfo, error) {
+	return ms.backups.List()
+}
+
+// RestoreBackup replaces the world with the backup name. A running server is stopped
+// first and started again afterwards. The replaced directories are kept with a
+// ".before-restore" suffix until the next restore. It returns what was done.
+func (ms *MinecraftServer) RestoreBackup(name string) ([]string, error) {
+	root := ms.config.ServerRootPath
+	tmp, err := os.MkdirTemp(root, ".restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	dirs, err := ms.backups.Extract(name, tmp)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("backup %s is empty", name)
+	}
+
+	var steps []string
+	running := ms.isServerRunning()
+	if !running {
+		// A server run by another process cannot be stopped and started again here
+		rcon, err := ms.dialRcon()
+		if err != nil {
+			return nil, fmt.Errorf("the server may be running but cannot be reached over RCON: %w", err)
+		}
+		if rcon != nil {
+			_ = rcon.Close()
+			return nil, fmt.Errorf("the server is running in another process, stop it first or restore with the minecraft_backup_restore tool")
+		}
+	}
+	if running {
+		if err := ms.StopServer(serverStopTimeout); err != nil {
+			return nil, fmt.Errorf("failed to stop the server: %w", err)
+		}
+		steps = append(steps, "stopped the server")
+	}
+	for _, d := range dirs {
+		target := filepath.Join(root, d)
+		if _, err := os.Stat(target); err == nil {
+			old := target + ".before-restore"
+			if err := os.RemoveAll(old); err != nil {
+				return steps, err
+			}
+			if err := os.Rename(target, old); err != nil {
+				return steps, err
+			}
+			steps = append(steps, fmt.Sprintf("moved the current %s to %s", d, filepath.Base(old)))
+		}
+		if err := os.Rename(filepath.Join(tmp, d), target); err != nil {
+			return steps, err
+		}
+		steps = append(steps, fmt.Sprintf("restored %s from %s", d, name))
+	}
+	ms.logger.Info().Str("backup", name).Strs("dirs", dirs).Msg("Restored world backup")
+	if running {
+		if err := ms.StartServer(); err != nil {
+			return steps, fmt.Errorf("failed to start the server: %w", err)
+		}
+		steps = append(steps, "started the server")
+	}
+	return steps, nil
+}
+
+// registerBackupTools registers the world backup tools.
+func (ms *MinecraftServer) registerBackupTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_backup_create",
+		mcp.WithDescription("Back up the world before large or destructive edits. Saving is paused while the world is archived; old backups are pruned to the configured retention."),
+		mcp.WithString("label", mcp.Description("Short label added to the backup name, e.g. before_castle (optional)")),
+	), ms.handleBackupCreate)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_backup_list",
+		mcp.WithDescription("List the world backups, newest first"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleBackupList)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_backup_restore",
+		mcp.WithDescription("Restore the world from a backup. The server is stopped, the world directories are swapped and the server is started again; players are disconnected. Only use it when the player asks for it."),
+		mcp.WithString("name", mcp.Description("Backup name from minecraft_backup_list"), mcp.Required()),
+	), ms.handleBackupRestore)
+}
+
+// handleBackupCreate implements the minecraft_backup_create tool.
+func (ms *MinecraftServer) handleBackupCreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	label, _ := getStringArg(request.Params.Arguments, "label", false)
+	info, pruned, err := ms.CreateBackup(label)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	text := fmt.Sprintf("Created backup %s (%.1f MB)", info.Name, float64(info.Size)/(1<<20))
+	if len(pruned) > 0 {
+		text += "; deleted old backups: " + strings.Join(pruned, ", ")
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// handleBackupList implements the minecraft_backup_list tool.
+func (ms *MinecraftServer) handleBackupList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	list, err := ms.ListBackups()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(list) == 0 {
+		return mcp.NewToolResultText("No backups"), nil
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleBackupRestore implements the minecraft_backup_restore tool.
+func (ms *MinecraftServer) handleBackupRestore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := getStringArg(request.Params.Arguments, "name", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	steps, err := ms.RestoreBackup(name)
+	if err != nil {
+		if len(steps) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("%s (after: %s)", err, strings.Join(steps, "; "))), nil
+		}
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText("Restored backup " + name + ": " + strings.Join(steps, "; ")), nil
+}
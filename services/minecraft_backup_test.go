@@ -0,0 +1,242 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeTestFiles creates files (path: content) under root.
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupStore(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"world/level.dat":         "level",
+		"world/region/r.0.0.mca":  "region",
+		"world/session.lock":      "lock",
+		"world_nether/DIM-1/data": "nether",
+	})
+	store := NewBackupStore(filepath.Join(t.TempDir(), "backups"))
+	for _, format := range []string{"tar.gz", "zip"} {
+		info, err := store.Create(root, []string{filepath.Join(root, "world"), filepath.Join(root, "world_nether")}, "before castle!", format)
+		if err != nil {
+			t.Fatalf("Create(%s) failed: %v", format, err)
+		}
+		if !strings.HasPrefix(info.Name, "world-") || !strings.HasSuffix(info.Name, "-before_castle."+format) {
+			t.Errorf("backup name = %s", info.Name)
+		}
+		dest := t.TempDir()
+		dirs, err := store.Extract(info.Name, dest)
+		if err != nil || strings.Join(dirs, ",") != "world,world_nether" {
+			t.Fatalf("Extract = %v, %v", dirs, err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dest, "world", "region", "r.0.0.mca")); string(data) != "region" {
+			t.Errorf("region = %q", data)
+		}
+		if _, err := os.Stat(filepath.Join(dest, "world", "session.lock")); !os.IsNotExist(err) {
+			t.Errorf("session.lock should not be backed up")
+		}
+	}
+
+	list, err := store.List()
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List = %v, %v", list, err)
+	}
+
+	// Backups made in quick succession never replace each other
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		info, err := store.Create(root, []string{filepath.Join(root, "world")}, "again", "zip")
+		if err != nil || seen[info.Name] {
+			t.Fatalf("Create = %v, %v", info.Name, err)
+		}
+		seen[info.Name] = true
+	}
+	if list, _ := store.List(); len(list) != 5 {
+		t.Errorf("List = %v", list)
+	}
+	if _, err := store.Prune(2); err != nil {
+		t.Fatal(err)
+	}
+	if list, err = store.List(); err != nil || len(list) != 2 {
+		t.Fatalf("List = %v, %v", list, err)
+	}
+	deleted, err := store.Prune(1)
+	if err != nil || len(deleted) != 1 || deleted[0] != list[1].Name {
+		t.Errorf("Prune = %v, %v", deleted, err)
+	}
+	if _, err := store.Extract("../secret.zip", t.TempDir()); err == nil {
+		t.Errorf("names outside the store should fail")
+	}
+
+	// Entries escaping the destination are rejected
+	f, err := os.Create(filepath.Join(store.dir, "evil.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("../evil.txt")
+	_, _ = w.Write([]byte("evil"))
+	_ = zw.Close()
+	_ = f.Close()
+	if _, err := store.Extract("evil.zip", t.TempDir()); err == nil {
+		t.Errorf("zip slip should fail")
+	}
+}
+
+func TestMinecraftServer_RestoreBackup(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{"world/level.dat": "v1"})
+	ms := &MinecraftServer{
+		config:  &MinecraftConfig{ServerRootPath: root, BackupFormat: "tar.gz", BackupRetention: 3},
+		backups: NewBackupStore(filepath.Join(t.TempDir(), "backups")),
+	}
+	info, _, err := ms.CreateBackup("")
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	writeTestFiles(t, root, map[string]string{"world/level.dat": "v2"})
+
+	steps, err := ms.RestoreBackup(info.Name)
+	if err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if strings.Join(steps, "; ") != "moved the current world to world.before-restore; restored world from "+info.Name {
+		t.Errorf("steps = %v", steps)
+	}
+	for path, want := range map[string]string{"world/level.dat": "v1", "world.before-restore/level.dat": "v2"} {
+		if data, _ := os.ReadFile(filepath.Join(root, path)); string(data) != want {
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, ".restore-*")); len(matches) != 0 {
+		t.Errorf("temporary directories left: %v", matches)
+	}
+}
+
+// fakeRcon serves the RCON protocol on a local port, answering every command with
+// reply and recording it.
+func fakeRcon(t *testing.T, password string, reply func(string) string) (int, func() []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	var mu sync.Mutex
+	var commands []string
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					var size, id, typ int32
+					if binary.Read(conn, binary.LittleEndian, &size) != nil {
+						return
+					}
+					_ = binary.Read(conn, binary.LittleEndian, &id)
+					_ = binary.Read(conn, binary.LittleEndian, &typ)
+					body := make([]byte, size-8)
+					if _, err := io.ReadFull(conn, body); err != nil {
+						return
+					}
+					text := strings.TrimRight(string(body), "\x00")
+					out := ""
+					if typ == rconTypeLogin {
+						if text != password {
+							id = -1
+						}
+					} else {
+						mu.Lock()
+						commands = append(commands, text)
+						mu.Unlock()
+						out = reply(text)
+					}
+					_ = binary.Write(conn, binary.LittleEndian, int32(len(out)+10))
+					_ = binary.Write(conn, binary.LittleEndian, id)
+					_ = binary.Write(conn, binary.LittleEndian, int32(rconTypeCommand))
+					_, _ = conn.Write(append([]byte(out), 0, 0))
+				}
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), commands...)
+	}
+}
+
+func TestMinecraftServer_CreateBackup_Rcon(t *testing.T) {
+	root := t.TempDir()
+	port, commands := fakeRcon(t, "secret", func(command string) string {
+		if command == "save-all flush" {
+			return "Saving the game (this may take a moment!)Saved the game"
+		}
+		return "Automatic saving is now disabled"
+	})
+	writeTestFiles(t, root, map[string]string{
+		"world/level.dat":   "v1",
+		"server.properties": "enable-rcon=true\nrcon.port=" + strconv.Itoa(port) + "\nrcon.password=secret\n",
+	})
+	ms := &MinecraftServer{
+		config:  &MinecraftConfig{ServerRootPath: root, BackupFormat: "zip", BackupRetention: 3},
+		backups: NewBackupStore(filepath.Join(t.TempDir(), "backups")),
+	}
+	info, _, err := ms.CreateBackup("")
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	if got := strings.Join(commands(), ","); got != "save-off,save-all flush,save-on" {
+		t.Errorf("commands = %s", got)
+	}
+	if _, err := ms.RestoreBackup(info.Name); err == nil || !strings.Contains(err.Error(), "another process") {
+		t.Errorf("restoring under a server of another process should fail, got %v", err)
+	}
+
+	writeTestFiles(t, root, map[string]string{"server.properties": "enable-rcon=true\nrcon.port=" + strconv.Itoa(port) + "\nrcon.password=wrong\n"})
+	if _, _, err := ms.CreateBackup(""); err == nil {
+		t.Errorf("a backup without pausing the saves should fail")
+	}
+}
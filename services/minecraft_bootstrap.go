@@ -0,0 +1,155 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// EulaURL is the Minecraft End User License Agreement the server asks to accept.
+	EulaURL = "https://aka.ms/MinecraftEULA"
+	// defaultRconPort is the vanilla RCON port.
+	defaultRconPort = 25575
+)
+
+// ErrEulaNotAccepted is returned when the server cannot start because the EULA was not accepted.
+var ErrEulaNotAccepted = errors.New("the Minecraft EULA (" + EulaURL + ") has not been accepted: read it and set acceptEula to true in the Minecraft config, or run `moling_mc bootstrap --accept_eula`")
+
+// defaultServerProperties are written to a new server.properties, besides the RCON settings.
+var defaultServerProperties = [][2]string{
+	{"motd", "A MoLing Minecraft Server"},
+	{"difficulty", "normal"},
+	{"gamemode", "survival"},
+	{"enable-command-block", "true"},
+	{"spawn-protection", "0"},
+	{"view-distance", "10"},
+	{"simulation-distance", "10"},
+	{"max-players", "20"},
+	{"online-mode", "true"},
+	{"level-name", "world"},
+}
+
+// eulaAccepted reports whether eula.txt in root exists and accepts the EULA.
+func eulaAccepted(root string) (accepted, exists bool, err error) {
+	props, err := ReadServerProperties(filepath.Join(root, "eula.txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	v, _ := props.Get("eula")
+	accepted, _ = strconv.ParseBool(v)
+	return accepted, true, nil
+}
+
+// writeEula accepts the EULA in eula.txt, keeping the rest of an existing file.
+func writeEula(root string) error {
+	path := filepath.Join(root, "eula.txt")
+	props, err := ReadServerProperties(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		props, _ = ParseServerProperties([]byte(fmt.Sprintf("#By changing the setting below to TRUE you are indicating your agreement to our EULA (%s).\n#%s\n",
+			EulaURL, time.Now().Format(time.UnixDate))))
+	}
+	props.Set("eula", "true")
+	return props.Write(path)
+}
+
+// randomPassword returns a random hex password for RCON.
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Bootstrap prepares ServerRootPath for the first start of the server: it checks the
+// server jar, writes eula.txt when the EULA is accepted (by acceptEula or the config)
+// and creates server.properties with RCON enabled and sensible defaults. An existing
+// server.properties is left alone. It returns what was done, one step per line, and
+// ErrEulaNotAccepted if the server would refuse to start.
+func (ms *MinecraftServer) Bootstrap(acceptEula bool) ([]string, error) {
+	var steps []string
+	root := ms.config.ServerRootPath
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return steps, fmt.Errorf("failed to create the server directory: %w", err)
+	}
+
+	jar := ms.config.ServerJarFile
+	if !filepath.IsAbs(jar) {
+		jar = filepath.Join(root, jar)
+	}
+	if _, err := os.Stat(jar); err != nil {
+		return steps, fmt.Errorf("server jar %s not found, download it from https://www.minecraft.net/download/server and set serverJarFile", jar)
+	}
+
+	path := ms.propertiesPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		props := &ServerProperties{}
+		password := ms.config.Password
+		if password == "" {
+			if password, err = randomPassword(); err != nil {
+				return steps, err
+			}
+		}
+		props.Set("enable-rcon", "true")
+		props.Set("rcon.port", strconv.Itoa(defaultRconPort))
+		props.Set("rcon.password", password)
+		for _, kv := range defaultServerProperties {
+			props.Set(kv[0], kv[1])
+		}
+		if err := props.Write(path); err != nil {
+			return steps, fmt.Errorf("failed to write server.properties: %w", err)
+		}
+		steps = append(steps, fmt.Sprintf("created server.properties with RCON on port %d (the password is in rcon.password)", defaultRconPort))
+	} else {
+		steps = append(steps, "kept the existing server.properties")
+	}
+
+	accepted, exists, err := eulaAccepted(root)
+	if err != nil {
+		return steps, fmt.Errorf("failed to read eula.txt: %w", err)
+	}
+	switch {
+	case accepted:
+		steps = append(steps, "the EULA is accepted")
+	case acceptEula || ms.config.AcceptEula:
+		if err := writeEula(root); err != nil {
+			return steps, fmt.Errorf("failed to write eula.txt: %w", err)
+		}
+		steps = append(steps, "accepted the EULA in eula.txt")
+	case exists:
+		return steps, fmt.Errorf("eula.txt declines the EULA: %w", ErrEulaNotAccepted)
+	default:
+		return steps, ErrEulaNotAccepted
+	}
+	return steps, nil
+}
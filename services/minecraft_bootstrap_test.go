@@ -0,0 +1,76 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMinecraftServer_Bootstrap(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "server")
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root, ServerJarFile: "server.jar"}}
+	if _, err := ms.Bootstrap(true); err == nil || !strings.Contains(err.Error(), "server.jar not found") {
+		t.Fatalf("missing jar: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "server.jar"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server writes a declined eula.txt on its first start
+	eula := "#By changing the setting below to TRUE you are indicating your agreement to our EULA (https://aka.ms/MinecraftEULA).\n#Tue Jan 07 10:00:00 UTC 2025\neula=false\n"
+	if err := os.WriteFile(filepath.Join(root, "eula.txt"), []byte(eula), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	steps, err := ms.Bootstrap(false)
+	if !errors.Is(err, ErrEulaNotAccepted) {
+		t.Fatalf("declined EULA: %v", err)
+	}
+	if len(steps) != 1 || !strings.Contains(steps[0], "created server.properties") {
+		t.Errorf("steps = %v", steps)
+	}
+	props, err := ReadServerProperties(filepath.Join(root, "server.properties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := props.Get("enable-rcon"); v != "true" {
+		t.Errorf("enable-rcon = %s", v)
+	}
+	if v, _ := props.Get("rcon.password"); len(v) != 24 {
+		t.Errorf("rcon.password = %q", v)
+	}
+
+	ms.config.AcceptEula = true
+	steps, err = ms.Bootstrap(false)
+	if err != nil || strings.Join(steps, "; ") != "kept the existing server.properties; accepted the EULA in eula.txt" {
+		t.Fatalf("Bootstrap = %v, %v", steps, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "eula.txt"))
+	if string(data) != strings.Replace(eula, "eula=false", "eula=true", 1) {
+		t.Errorf("eula.txt = %q", data)
+	}
+	if accepted, _, _ := eulaAccepted(root); !accepted {
+		t.Errorf("the EULA should be accepted")
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxDryRunCommands is the number of commands listed in a dry run result.
+	maxDryRunCommands = 200
+	// maxReportedErrors is the number of failed commands listed in a build result.
+	maxReportedErrors = 10
+)
+
+var (
+	validFillModes     = map[string]bool{"replace": true, "destroy": true, "keep": true, "hollow": true, "outline": true}
+	validSetblockModes = map[string]bool{"replace": true, "destroy": true, "keep": true}
+)
+
+// fillCommand constructs a /fill command, mode (oldBlockHandling) is optional.
+func fillCommand(p1, p2 Position, block, mode string) (string, error) {
+	if err := validateBlockID(block); err != nil {
+		return "", err
+	}
+	command := fmt.Sprintf("/fill %s %s %s", p1, p2, block)
+	if mode != "" {
+		if !validFillModes[mode] {
+			return "", fmt.Errorf("invalid oldBlockHandling mode: %s", mode)
+		}
+		command += " " + mode
+	}
+	return command, nil
+}
+
+// setblockCommand constructs a /setblock command, mode (oldBlockHandling) is optional.
+func setblockCommand(p Position, block, mode string) (string, error) {
+	if err := validateBlockID(block); err != nil {
+		return "", err
+	}
+	command := fmt.Sprintf("/setblock %s %s", p, block)
+	if mode != "" {
+		if !validSetblockModes[mode] {
+			return "", fmt.Errorf("invalid oldBlockHandling mode: %s", mode)
+		}
+		command += " " + mode
+	}
+	return command, nil
+}
+
+// BlockGrid is a sparse voxel structure, keyed by the block position relative to an origin.
+type BlockGrid map[[3]int]string
+
+// Set places block at x, y, z, replacing any previous block.
+func (g BlockGrid) Set(x, y, z int, block string) {
+	g[[3]int{x, y, z}] = block
+}
+
+// SetIfEmpty places block at x, y, z unless another block is already there.
+func (g BlockGrid) SetIfEmpty(x, y, z int, block string) {
+	if _, ok := g[[3]int{x, y, z}]; !ok {
+		g[[3]int{x, y, z}] = block
+	}
+}
+
+// Bounds returns the minimum and maximum corner of the grid.
+func (g BlockGrid) Bounds() (min, max [3]int) {
+	first := true
+	for p := range g {
+		for i := range p {
+			if first || p[i] < min[i] {
+				min[i] = p[i]
+			}
+			if first || p[i] > max[i] {
+				max[i] = p[i]
+			}
+		}
+		first = false
+	}
+	return min, max
+}
+
+// Commands converts the grid into /fill and /setblock commands placed at origin.
+// Neighbouring blocks of the same type are merged greedily into boxes (first along X,
+// then Z, then Y) that hold at most maxVolume blocks.
+func (g BlockGrid) Commands(origin Position, maxVolume int) ([]string, error) {
+	return g.CommandsWithMode(origin, maxVolume, "")
+}
+
+// CommandsWithMode is Commands with an oldBlockHandling mode valid for both /fill and
+// /setblock, e.g. "keep" to only place blocks where there is air.
+func (g BlockGrid) CommandsWithMode(origin Position, maxVolume int, mode string) ([]string, error) {
+	if origin.IsLocal() {
+		return nil, fmt.Errorf("local coordinates (^) cannot be used as the origin of a build")
+	}
+	if maxVolume <= 0 {
+		maxVolume = 32768
+	}
+
+	positions := make([][3]int, 0, len(g))
+	for p := range g {
+		positions = append(positions, p)
+	}
+	// Order by y, z, x so boxes grow from the bottom north-west corner
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		if a[2] != b[2] {
+			return a[2] < b[2]
+		}
+		return a[0] < b[0]
+	})
+
+	done := make(map[[3]int]bool, len(g))
+	free := func(x, y, z int, block string) bool {
+		p := [3]int{x, y, z}
+		return !done[p] && g[p] == block
+	}
+
+	var commands []string
+	for _, start := range positions {
+		if done[start] {
+			continue
+		}
+		block := g[start]
+		x0, y0, z0 := start[0], start[1], start[2]
+
+		// Grow along X
+		dx := 1
+		for dx < maxVolume && free(x0+dx, y0, z0, block) {
+			dx++
+		}
+		// Grow along Z while the whole row matches
+		dz := 1
+	growZ:
+		for dx*(dz+1) <= maxVolume {
+			for x := x0; x < x0+dx; x++ {
+				if !free(x, y0, z0+dz, block) {
+					break growZ
+				}
+			}
+			dz++
+		}
+		// Grow along Y while the whole rectangle matches
+		dy := 1
+	growY:
+		for dx*dz*(dy+1) <= maxVolume {
+			for z := z0; z < z0+dz; z++ {
+				for x := x0; x < x0+dx; x++ {
+					if !free(x, y0+dy, z, block) {
+						break growY
+					}
+				}
+			}
+			dy++
+		}
+
+		for y := y0; y < y0+dy; y++ {
+			for z := z0; z < z0+dz; z++ {
+				for x := x0; x < x0+dx; x++ {
+					done[[3]int{x, y, z}] = true
+				}
+			}
+		}
+
+		p1, err := origin.Offset(float64(x0), float64(y0), float64(z0))
+		if err != nil {
+			return nil, err
+		}
+		var command string
+		if dx == 1 && dy == 1 && dz == 1 {
+			command, err = setblockCommand(p1, block, mode)
+		} else {
+			p2, oErr := origin.Offset(float64(x0+dx-1), float64(y0+dy-1), float64(z0+dz-1))
+			if oErr != nil {
+				return nil, oErr
+			}
+			command, err = fillCommand(p1, p2, block, mode)
+		}
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// runBuild sends the generated commands to the server one by one and summarizes the
+// outcome. With dryRun the commands are only returned to the client.
+func (ms *MinecraftServer) runBuild(ctx context.Context, name string, commands []string, dryRun bool) (*mcp.CallToolResult, error) {
+	if len(commands) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("%s: nothing to build", name)), nil
+	}
+	if dryRun {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s: %d commands (dry run, nothing was executed)\n", name, len(commands)))
+		for i, c := range commands {
+			if i >= maxDryRunCommands {
+				sb.WriteString(fmt.Sprintf("... %d more commands\n", len(commands)-maxDryRunCommands))
+				break
+			}
+			sb.WriteString(c + "\n")
+		}
+		return mcp.NewToolResultText(sb.String()), nil
+	}
+
+	ms.logger.Info().Str("build", name).Int("commands", len(commands)).Msg("Executing build commands")
+	var failures []string
+	for i, c := range commands {
+		select {
+		case <-ctx.Done():
+			return mcp.NewToolResultError(fmt.Sprintf("%s: cancelled after %d of %d commands", name, i, len(commands))), nil
+		default:
+		}
+		result, err := ms.WriteCommand(c)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsError {
+			if !ms.isServerRunning() {
+				return mcp.NewToolResultError(fmt.Sprintf("%s: stopped after %d of %d commands: %s", name, i, len(commands), toolResultText(result))), nil
+			}
+			failures = append(failures, toolResultText(result))
+		}
+	}
+
+	summary := fmt.Sprintf("%s: executed %d commands, %d failed", name, len(commands), len(failures))
+	if len(failures) == 0 {
+		return mcp.NewToolResultText(summary), nil
+	}
+	if len(failures) > maxReportedErrors {
+		failures = append(failures[:maxReportedErrors], fmt.Sprintf("... %d more failures", len(failures)-maxReportedErrors))
+	}
+	return mcp.NewToolResultError(summary + "\n" + strings.Join(failures, "\n")), nil
+}
+
+// isServerRunning reports whether the Minecraft server process is running.
+func (ms *MinecraftServer) isServerRunning() bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.isRunning
+}
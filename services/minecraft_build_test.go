@@ -0,0 +1,131 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestBlockGrid_Commands(t *testing.T) {
+	grid := make(BlockGrid)
+	for x := 0; x < 4; x++ {
+		for z := 0; z < 3; z++ {
+			grid.Set(x, 0, z, "minecraft:stone")
+			grid.Set(x, 1, z, "minecraft:stone")
+		}
+	}
+	grid.Set(5, 0, 0, "minecraft:dirt")
+
+	origin, _ := ParsePosition("100", "64", "~")
+	commands, err := grid.Commands(origin, 0)
+	if err != nil {
+		t.Fatalf("Commands failed: %v", err)
+	}
+	want := []string{
+		"/fill 100 64 ~ 103 65 ~2 minecraft:stone",
+		"/setblock 105 64 ~ minecraft:dirt",
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Commands = %q, want %q", commands, want)
+	}
+
+	// A volume limit splits the box
+	commands, err = grid.Commands(origin, 12)
+	if err != nil || len(commands) != 3 {
+		t.Errorf("limited Commands = %q, %v", commands, err)
+	}
+
+	local, _ := ParsePosition("^", "^", "^")
+	if _, err := grid.Commands(local, 0); err == nil {
+		t.Errorf("local origin should be rejected")
+	}
+}
+
+func TestPixelArt(t *testing.T) {
+	palette, err := NewPalette("wool, concrete")
+	if err != nil {
+		t.Fatalf("NewPalette failed: %v", err)
+	}
+	if _, err := NewPalette("glass"); err == nil {
+		t.Errorf("unknown palette should fail")
+	}
+	if c := palette.Color(palette.Nearest(250, 250, 250)); c.Block != "minecraft:white_wool" {
+		t.Errorf("nearest to white = %s", c.Block)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{R: 160, G: 39, B: 34, A: 255})
+	img.Set(1, 0, color.NRGBA{R: 160, G: 39, B: 34, A: 255})
+	img.Set(0, 1, color.NRGBA{R: 20, G: 21, B: 25, A: 255})
+	// (1, 1) stays transparent
+	indices := quantizeImage(resampleImage(img, 2, 2), palette, false)
+	if indices[1][1] != -1 {
+		t.Errorf("transparent pixel mapped to %d", indices[1][1])
+	}
+
+	grid, err := pixelArtGrid(indices, palette, "vertical", "south", "")
+	if err != nil {
+		t.Fatalf("pixelArtGrid failed: %v", err)
+	}
+	if len(grid) != 3 || grid[[3]int{1, 1, 0}] != "minecraft:red_wool" || grid[[3]int{0, 0, 0}] != "minecraft:black_wool" {
+		t.Errorf("unexpected grid %v", grid)
+	}
+	if _, err := pixelArtGrid(indices, palette, "vertical", "up", ""); err == nil {
+		t.Errorf("invalid facing should fail")
+	}
+
+	if w, h, err := pixelArtSize(1024, 512, 0, 0); err != nil || w != 128 || h != 64 {
+		t.Errorf("pixelArtSize = %dx%d, %v", w, h, err)
+	}
+	if _, _, err := pixelArtSize(100, 100, 1000, 0); err == nil {
+		t.Errorf("oversized pixel art should fail")
+	}
+}
+
+func TestMinecraftServer_loadImageArg(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MinecraftServer{}
+	args := map[string]interface{}{"imageBase64": "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())}
+	if img, err := ms.loadImageArg(args); err != nil || img.Bounds().Dx() != 2 {
+		t.Fatalf("loadImageArg = %v, %v", img, err)
+	}
+
+	// The same image declaring 60000x60000 pixels in its header
+	huge := buf.Bytes()
+	binary.BigEndian.PutUint32(huge[16:], 60000)
+	binary.BigEndian.PutUint32(huge[20:], 60000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	args["imageBase64"] = base64.StdEncoding.EncodeToString(huge)
+	if _, err := ms.loadImageArg(args); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("huge image should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxChatRequests is the number of unanswered chat requests kept, older ones are dropped.
+const maxChatRequests = 50
+
+// ChatRequest is a message a player sent to the assistant from the game chat.
+type ChatRequest struct {
+	ID       int       `json:"id"`
+	Player   string    `json:"player"`
+	Message  string    `json:"message"`
+	Position string    `json:"position,omitempty"` // block position of the player when asking, if known
+	Time     time.Time `json:"time"`
+}
+
+// String describes the request for the assistant.
+func (r ChatRequest) String() string {
+	pos := "at an unknown position"
+	if r.Position != "" {
+		pos = "at " + r.Position
+	}
+	return fmt.Sprintf("#%d %s (%s) asks: %s", r.ID, r.Player, pos, r.Message)
+}
+
+// chatQueue holds the chat requests until the assistant answers them.
+type chatQueue struct {
+	mu      sync.Mutex
+	pending []ChatRequest
+	next    int
+}
+
+// Add queues a request and returns it with its ID.
+func (q *chatQueue) Add(r ChatRequest) ChatRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.next++
+	r.ID = q.next
+	q.pending = append(q.pending, r)
+	if len(q.pending) > maxChatRequests {
+		q.pending = q.pending[len(q.pending)-maxChatRequests:]
+	}
+	return r
+}
+
+// Pending returns the unanswered requests, oldest first.
+func (q *chatQueue) Pending() []ChatRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]ChatRequest(nil), q.pending...)
+}
+
+// Done removes a request and returns it.
+func (q *chatQueue) Done(id int) (ChatRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, r := range q.pending {
+		if r.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return r, true
+		}
+	}
+	return ChatRequest{}, false
+}
+
+// chatBridgeMessage returns the message for the assistant in a chat line, or false if
+// the line does not start with the prefix.
+func chatBridgeMessage(prefix, text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(strings.ToLower(text), strings.ToLower(prefix)) {
+		return "", false
+	}
+	rest := text[len(prefix):]
+	if rest != "" && rest[0] != ' ' {
+		// "!aim" is not "!ai"
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// chatAllowed reports whether a player may talk to the assistant.
+func (mc *MinecraftConfig) chatAllowed(player string) bool {
+	for _, p := range mc.ChatPlayers {
+		if strings.EqualFold(p, player) {
+			return true
+		}
+	}
+	return false
+}
+
+// tellrawCommand returns a /tellraw command showing text to a player.
+func tellrawCommand(player, text, color string) string {
+	component, _ := json.Marshal([]map[string]string{
+		{"text": "[MoLing] ", "color": "gold"},
+		{"text": text, "color": color},
+	})
+	return fmt.Sprintf("/tellraw %s %s", player, component)
+}
+
+// runChatBridge turns the chat messages starting with the chat prefix into requests
+// for the assistant until ctx is done. The requests are announced to the sessions
+// subscribed to the server log, with a prompts/list_changed notification, and listed by minecraft_chat_pending and the
+// minecraft_chat prompt. Players are told when no session is subscribed, since then
+// nobody learns about the request until an assistant looks for it.
+func (ms *MinecraftServer) runChatBridge(ctx context.Context) {
+	events, cancel := ms.SubscribeEvents(EventChatMessage)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			message, ok := chatBridgeMessage(ms.config.ChatPrefix, event.Text)
+			if !ok {
+				continue
+			}
+			go ms.handleChatRequest(event.Player, message)
+		}
+	}
+}
+
+// handleChatRequest queues a chat request of a player and confirms it in the game.
+func (ms *MinecraftServer) handleChatRequest(player, message string) {
+	if !ms.config.chatAllowed(player) {
+		ms.logger.Info().Str("player", player).Msg("Ignored chat request of a player not in chatPlayers")
+		_, _ = ms.WriteCommand(tellrawCommand(player, "Sorry, you are not allowed to talk to the assistant.", "red"))
+		return
+	}
+	if message == "" {
+		_, _ = ms.WriteCommand(tellrawCommand(player, fmt.Sprintf("Tell me what you need, e.g. %s build me a bridge here", ms.config.ChatPrefix), "gray"))
+		return
+	}
+	request := ChatRequest{Player: player, Message: message, Time: time.Now()}
+	if state, err := ms.playerState(player); err != nil {
+		ms.logger.Warn().Err(err).Str("player", player).Msg("Failed to get the position of the player")
+	} else {
+		request.Position = state.Block
+	}
+	request = ms.chat.Add(request)
+	ms.logger.Info().Int("id", request.ID).Str("player", player).Str("message", message).Msg("Queued chat request")
+	// The minecraft_chat prompt lists the new request, clients refresh it on list_changed
+	ms.logs.Notify("notifications/prompts/list_changed", nil)
+	if ms.logs.Broadcast(mcp.LoggingLevelNotice, "minecraft.chat", request) == 0 {
+		ms.logger.Info().Int("id", request.ID).Msg("No session is subscribed to the chat requests")
+		_, _ = ms.WriteCommand(tellrawCommand(player, fmt.Sprintf("No assistant is connected right now, your request #%d waits until one checks the chat.", request.ID), "yellow"))
+		return
+	}
+	_, _ = ms.WriteCommand(tellrawCommand(player, "Got it, the assistant will answer soon.", "gray"))
+}
+
+// registerChatTools registers the chat bridge prompt and tools.
+func (ms *MinecraftServer) registerChatTools() {
+	ms.AddPrompt(PromptEntry{
+		prompt: mcp.Prompt{
+			Name:        "minecraft_chat",
+			Description: "Answer the requests players sent to the assistant from the game chat.",
+		},
+		phf: ms.handleChatPrompt,
+	})
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_chat_pending",
+		mcp.WithDescription(fmt.Sprintf("List the unanswered requests players sent from the game chat with %q, with their position when asking", ms.config.ChatPrefix)),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleChatPending)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_chat_reply",
+		mcp.WithDescription("Send a message to a player in the game chat, e.g. the answer to a chat request"),
+		mcp.WithString("player", mcp.Description("Player to send the message to (optional when requestId is given)")),
+		mcp.WithString("message", mcp.Description("The message"), mcp.Required()),
+		mcp.WithNumber("requestId", mcp.Description("ID of the chat request this answers, it is removed from the pending requests (optional)")),
+		mcp.WithString("color", mcp.Description("Color of the message (optional, default: white)")),
+	), ms.handleChatReply)
+}
+
+// handleChatPrompt returns the pending chat requests as a prompt.
+func (ms *MinecraftServer) handleChatPrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	pending := ms.chat.Pending()
+	var sb strings.Builder
+	if len(pending) == 0 {
+		sb.WriteString("No player is waiting for an answer.")
+	} else {
+		sb.WriteString("Players asked for help in the Minecraft chat. Handle each request with the Minecraft tools, using the player's position for relative words such as \"here\", then answer the player with minecraft_chat_reply and the request ID.\n\n")
+		for _, r := range pending {
+			sb.WriteString(r.String() + "\n")
+		}
+	}
+	return mcp.NewGetPromptResult("Minecraft chat requests", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(sb.String())),
+	}), nil
+}
+
+// handleChatPending implements the minecraft_chat_pending tool.
+func (ms *MinecraftServer) handleChatPending(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	pending := ms.chat.Pending()
+	if len(pending) == 0 {
+		return mcp.NewToolResultText("No pending chat requests"), nil
+	}
+	lines := make([]string, len(pending))
+	for i, r := range pending {
+		lines[i] = r.String()
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
+
+// handleChatReply implements the minecraft_chat_reply tool.
+func (ms *MinecraftServer) handleChatReply(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	message, err := getStringArg(args, "message", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	player, _ := getStringArg(args, "player", false)
+	if player != "" {
+		if player, err = checkTarget(player); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	color, _ := getStringArg(args, "color", false)
+	if color == "" {
+		color = "white"
+	}
+	if _, ok := args["requestId"]; ok {
+		id, err := getIntArg(args, "requestId", 0)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		r, ok := ms.chat.Done(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("no pending chat request %d", id)), nil
+		}
+		if player == "" {
+			player = r.Player
+		}
+	}
+	if player == "" {
+		return mcp.NewToolResultError("player or requestId is required"), nil
+	}
+	result, err := ms.WriteCommand(tellrawCommand(player, message, color))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if result.IsError {
+		return result, nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Sent the message to %s", player)), nil
+}
@@ -0,0 +1,88 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestChatBridgeMessage(t *testing.T) {
+	tests := []struct {
+		text, want string
+		ok         bool
+	}{
+		{"!ai build me a bridge here", "build me a bridge here", true},
+		{"  !AI  hello ", "hello", true},
+		{"!ai", "", true},
+		{"!aim at the creeper", "", false},
+		{"hello !ai", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := chatBridgeMessage("!ai", tt.text); got != tt.want || ok != tt.ok {
+			t.Errorf("chatBridgeMessage(%q) = %q, %v", tt.text, got, ok)
+		}
+	}
+
+	mc := &MinecraftConfig{ChatPlayers: []string{"Steve"}}
+	if !mc.chatAllowed("steve") || mc.chatAllowed("Alex") {
+		t.Errorf("chatAllowed should only allow the configured players")
+	}
+}
+
+func TestChatQueue(t *testing.T) {
+	var q chatQueue
+	for i := 0; i < maxChatRequests+2; i++ {
+		q.Add(ChatRequest{Player: "Steve", Message: "hi"})
+	}
+	pending := q.Pending()
+	if len(pending) != maxChatRequests || pending[0].ID != 3 {
+		t.Fatalf("pending = %d requests, first %d", len(pending), pending[0].ID)
+	}
+	if r, ok := q.Done(3); !ok || r.Player != "Steve" {
+		t.Errorf("Done = %+v, %v", r, ok)
+	}
+	if _, ok := q.Done(3); ok {
+		t.Errorf("a request should only be done once")
+	}
+
+	want := `/tellraw Steve [{"color":"gold","text":"[MoLing] "},{"color":"white","text":"Say \"hi\""}]`
+	if got := tellrawCommand("Steve", `Say "hi"`, "white"); got != want {
+		t.Errorf("tellrawCommand = %s", got)
+	}
+}
+
+func TestMinecraftServer_handleChatReply(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{}}
+	id := ms.chat.Add(ChatRequest{Player: "Steve", Message: "hi"}).ID
+	for _, player := range []string{`@a {"text":"x"} @a`, "Steve\n/op Alex", "@a]"} {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = map[string]interface{}{"player": player, "message": "hello", "requestId": float64(id)}
+		if result, _ := ms.handleChatReply(context.Background(), request); !result.IsError {
+			t.Errorf("player %q should be rejected", player)
+		}
+	}
+	if len(ms.chat.Pending()) != 1 {
+		t.Errorf("a rejected reply should keep the request pending")
+	}
+}
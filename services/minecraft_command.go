@@ -22,7 +22,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -92,81 +91,82 @@ When I ask you about building something in Minecraft, provide me with the exact
 
 // handleFill implements the /fill command.
 func (ms *MinecraftServer) handleFill(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	coords1, err := getCoordArgs(request.Params.Arguments, "x1", "y1", "z1")
+	pos1, err := ms.getPositionArg(request.Params.Arguments, "x1", "y1", "z1")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	coords2, err := getCoordArgs(request.Params.Arguments, "x2", "y2", "z2")
+	pos2, err := ms.getPositionArg(request.Params.Arguments, "x2", "y2", "z2")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	block, err := getStringArg(request.Params.Arguments, "block", true)
+	player, exec, err := ms.getExecutorArg(request.Params.Arguments, pos1, pos2)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	if err := validateBlockID(block); err != nil {
+	if err := ms.checkRegionVolume(pos1, pos2, exec); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	block, err := getStringArg(request.Params.Arguments, "block", true)
+	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	oldBlockHandling, _ := getStringArg(request.Params.Arguments, "oldBlockHandling", false) // Optional
 
 	// Construct the command
-	command := fmt.Sprintf("/fill %s %s %s %s %s %s %s", coords1[0], coords1[1], coords1[2], coords2[0], coords2[1], coords2[2], block)
-
-	if oldBlockHandling != "" {
-		// Validate handling mode? (replace, destroy, keep, hollow, outline)
-		validModes := map[string]bool{"replace": true, "destroy": true, "keep": true, "hollow": true, "outline": true}
-		if !validModes[oldBlockHandling] {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid oldBlockHandling mode: %s", oldBlockHandling)), nil
-		}
-		command += " " + oldBlockHandling
+	command, err := fillCommand(pos1, pos2, block, oldBlockHandling)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	return ms.WriteCommand(command)
+	return ms.WriteCommand(ms.executeAt(player, command))
 }
 
 // handleSetblock implements the /setblock command.
 func (ms *MinecraftServer) handleSetblock(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	coords, err := getCoordArgs(request.Params.Arguments, "x", "y", "z")
+	pos, err := ms.getPositionArg(request.Params.Arguments, "x", "y", "z")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	block, err := getStringArg(request.Params.Arguments, "block", true)
+	player, _, err := ms.getExecutorArg(request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	if err := validateBlockID(block); err != nil {
+	block, err := getStringArg(request.Params.Arguments, "block", true)
+	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	oldBlockHandling, _ := getStringArg(request.Params.Arguments, "oldBlockHandling", false) // Optional
 
 	// Construct the command
-	command := fmt.Sprintf("/setblock %s %s %s %s", coords[0], coords[1], coords[2], block)
-
-	if oldBlockHandling != "" {
-		// Validate handling mode? (replace, destroy, keep)
-		validModes := map[string]bool{"replace": true, "destroy": true, "keep": true}
-		if !validModes[oldBlockHandling] {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid oldBlockHandling mode: %s", oldBlockHandling)), nil
-		}
-		command += " " + oldBlockHandling
+	command, err := setblockCommand(pos, block, oldBlockHandling)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	return ms.WriteCommand(command)
+	return ms.WriteCommand(ms.executeAt(player, command))
 }
 
 // handleClone implements the /clone command.
 func (ms *MinecraftServer) handleClone(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	coords1, err := getCoordArgs(request.Params.Arguments, "x1", "y1", "z1")
+	pos1, err := ms.getPositionArg(request.Params.Arguments, "x1", "y1", "z1")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pos2, err := ms.getPositionArg(request.Params.Arguments, "x2", "y2", "z2")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	coords2, err := getCoordArgs(request.Params.Arguments, "x2", "y2", "z2")
+	player, exec, err := ms.getExecutorArg(request.Params.Arguments, pos1, pos2)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	destCoords, err := getCoordArgs(request.Params.Arguments, "x", "y", "z")
+	if err := ms.checkRegionVolume(pos1, pos2, exec); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	coords1, coords2 := pos1.Strings(), pos2.Strings()
+	destCoords, err := ms.getCoordArgs(request.Params.Arguments, "x", "y", "z")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -211,7 +211,7 @@ func (ms *MinecraftServer) handleClone(ctx context.Context, request mcp.CallTool
 		command += " " + cloneMode
 	}
 
-	return ms.WriteCommand(command)
+	return ms.WriteCommand(ms.executeAt(player, command))
 }
 
 // handleSummon implements the /summon command.
@@ -226,7 +226,7 @@ func (ms *MinecraftServer) handleSummon(ctx context.Context, request mcp.CallToo
 		ms.logger.Warn().Str("entityId", entity).Msg("Entity ID does not contain ':', assuming default namespace 'minecraft:'")
 	}
 
-	coords, err := getCoordArgs(request.Params.Arguments, "x", "y", "z")
+	coords, err := ms.getCoordArgs(request.Params.Arguments, "x", "y", "z")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -271,14 +271,17 @@ func (ms *MinecraftServer) handleGive(ctx context.Context, request mcp.CallToolR
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	item, err := getStringArg(request.Params.Arguments, "item", true)
+	if target, err = checkTarget(target); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// The item with its name, lore, enchantments and attributes
+	stack, err := itemStackFromArgs(request.Params.Arguments)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	// Basic item ID validation
-	if !strings.Contains(item, ":") {
-		// return mcp.NewToolResultError(fmt.Sprintf("invalid item ID format: %s (expected namespace:id)", item)), nil
-		ms.logger.Warn().Str("itemId", item).Msg("Item ID does not contain ':', assuming default namespace 'minecraft:'")
+	item, err := stack.Format(ms.config)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	// Optional amount
@@ -298,7 +301,8 @@ func (ms *MinecraftServer) handleGive(ctx context.Context, request mcp.CallToolR
 	// Construct the command
 	command := fmt.Sprintf("/give %s %s %d", target, item, amount)
 
-	return ms.WriteCommand(command)
+	dryRun, _ := getBoolArg(request.Params.Arguments, "dryRun", false)
+	return ms.sendCommands([]string{command}, dryRun)
 }
 
 // handleTeleport implements the /teleport or /tp command.
@@ -312,7 +316,12 @@ func (ms *MinecraftServer) handleTeleport(ctx context.Context, request mcp.CallT
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 	// Destination could be coords "x y z" or an entity selector "@e[...]"
-	// We don't validate the content deeply here, assume LLM provides valid format.
+	// Coordinates are checked for valid notation, selectors are left to the server.
+	if parts := strings.Fields(destination); len(parts) == 3 {
+		if _, err := ParsePosition(parts[0], parts[1], parts[2]); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid destination %s: %s", destination, err.Error())), nil
+		}
+	}
 
 	rotation, _ := getStringArg(request.Params.Arguments, "rotation", false) // Optional "yaw pitch"
 
@@ -325,12 +334,10 @@ func (ms *MinecraftServer) handleTeleport(ctx context.Context, request mcp.CallT
 		if len(parts) != 2 {
 			return mcp.NewToolResultError(fmt.Sprintf("invalid rotation format: %s (expected 'yaw pitch')", rotation)), nil
 		}
-		// Check if parts are numbers or relative ~
+		// Check if parts are numbers or relative ~, local ^ is not valid for rotations
 		for _, part := range parts {
-			if _, err := strconv.ParseFloat(strings.Replace(part, "~", "0", -1), 64); err != nil && !strings.HasPrefix(part, "~") {
-				if part != "~" {
-					return mcp.NewToolResultError(fmt.Sprintf("invalid rotation value: %s", part)), nil
-				}
+			if c, err := ParseCoord(part); err != nil || c.Kind == CoordLocal {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid rotation value: %s", part)), nil
 			}
 		}
 		command += " " + rotation
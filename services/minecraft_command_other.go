@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/mark3labs/mcp-go/mcp"
+	"strconv"
 )
 
 // handleGameRule implements the /gamerule command.
@@ -225,7 +226,7 @@ func (ms *MinecraftServer) handleSpawnpoint(ctx context.Context, request mcp.Cal
 
 	// Position is optional
 	if _, ok := hasAllCoordArgs(request.Params.Arguments, "x", "y", "z"); ok {
-		coords, err := getCoordArgs(request.Params.Arguments, "x", "y", "z")
+		coords, err := ms.getCoordArgs(request.Params.Arguments, "x", "y", "z")
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
@@ -260,3 +261,52 @@ func getBoolArg(args map[string]interface{}, key string, required bool) (bool, e
 	}
 	return boolVal, nil
 }
+
+// Helper function for extracting optional integer parameters, def is returned if the parameter is absent
+func getIntArg(args map[string]interface{}, key string, def int) (int, error) {
+	val, ok := args[key]
+	if !ok {
+		return def, nil
+	}
+	switch v := val.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("parameter %s must be an integer, got %v", key, v)
+		}
+		return int(v), nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("parameter %s must be an integer, got %q", key, v)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("parameter %s must be a number, got %T", key, val)
+	}
+}
+
+// Helper function for extracting optional float parameters, def is returned if the parameter is absent
+func getFloatArg(args map[string]interface{}, key string, def float64) (float64, error) {
+	val, ok := args[key]
+	if !ok {
+		return def, nil
+	}
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parameter %s must be a number, got %q", key, v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("parameter %s must be a number, got %T", key, val)
+	}
+}
@@ -21,6 +21,8 @@ package services
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // MinecraftConfig represents the configuration for the Minecraft service.
@@ -40,9 +42,22 @@ type MinecraftConfig struct {
 	ServerLogFile   string `json:"serverLogFile"`   // Path to the server log file (relative to ServerRootPath or absolute)
 	StartupTimeout  int    `json:"startupTimeout"`  // Seconds to wait for server startup (approximate)
 	ShutdownCommand string `json:"shutdownCommand"` // Command to gracefully stop the server (e.g., "stop")
+	AcceptEula      bool   `json:"acceptEula"`      // Accept the Minecraft EULA (https://aka.ms/MinecraftEULA) in eula.txt on the first start
 
 	GameVersion    string `json:"game_version"`    // Informational, used in prompts
 	CommandTimeout int    `json:"command_timeout"` // Timeout for individual command execution (if applicable in future connection methods)
+	MaxFillVolume  int    `json:"maxFillVolume"`   // Maximum number of blocks a single /fill or /clone may touch (0: unlimited)
+	AssetsPath     string `json:"assetsPath"`      // Directory with images and models the build tools may read (default: BasePath/data/assets)
+
+	BackupFormat    string `json:"backupFormat"`    // Archive format of world backups: "tar.gz" or "zip"
+	BackupRetention int    `json:"backupRetention"` // Number of world backups to keep (0: keep all)
+
+	ScheduledJobs []ScheduledJob `json:"scheduledJobs"` // Recurring commands, announcements, backups and restarts
+
+	ChatPrefix  string   `json:"chatPrefix"`  // Chat messages starting with it are requests for the assistant (default: "!ai")
+	ChatPlayers []string `json:"chatPlayers"` // Players allowed to talk to the assistant from the game chat (empty: chat bridge off)
+
+	PlayerRefresh int `json:"playerRefresh"` // Seconds minecraft_players reuses the known player states (0: query on every call)
 }
 
 // NewMinecraftConfig creates a new MinecraftConfig with default values.
@@ -63,6 +78,11 @@ func NewMinecraftConfig() *MinecraftConfig {
 		ShutdownCommand: "stop",
 		GameVersion:     "1.20.2", // Default, should reflect jar version ideally
 		CommandTimeout:  3,
+		MaxFillVolume:   32768, // Vanilla limit (gamerule commandModificationBlockLimit)
+		BackupFormat:    "tar.gz",
+		BackupRetention: 10,
+		ChatPrefix:      "!ai",
+		PlayerRefresh:   0, // Query the players on demand
 	}
 
 	return mc
@@ -83,6 +103,23 @@ func (mc *MinecraftConfig) Check() error {
 	if mc.ShutdownCommand == "" {
 		return fmt.Errorf("minecraft config error: shutdownCommand cannot be empty")
 	}
+	if mc.BackupFormat != "tar.gz" && mc.BackupFormat != "zip" {
+		return fmt.Errorf("minecraft config error: backupFormat must be tar.gz or zip")
+	}
+	if mc.BackupRetention < 0 {
+		return fmt.Errorf("minecraft config error: backupRetention cannot be negative")
+	}
+	for _, job := range mc.ScheduledJobs {
+		if _, err := job.Validate(); err != nil {
+			return fmt.Errorf("minecraft config error: scheduledJobs: %w", err)
+		}
+	}
+	if mc.PlayerRefresh < 0 {
+		return fmt.Errorf("minecraft config error: playerRefresh cannot be negative")
+	}
+	if len(mc.ChatPlayers) > 0 && strings.TrimSpace(mc.ChatPrefix) == "" {
+		return fmt.Errorf("minecraft config error: chatPrefix cannot be empty when chatPlayers is set")
+	}
 	// Basic check for ServerAddress/Port if they were intended for connection (though not used now)
 	// if mc.ServerAddress == "" {
 	// 	return fmt.Errorf("server_address cannot be empty")
@@ -96,3 +133,45 @@ func (mc *MinecraftConfig) Check() error {
 
 	return nil
 }
+
+// parseGameVersion splits a release version like "1.20.2" into its numbers.
+func parseGameVersion(v string) ([]int, bool) {
+	parts := strings.Split(strings.TrimSpace(v), ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, len(nums) >= 2
+}
+
+// VersionAtLeast reports whether GameVersion is the given release or newer. Versions
+// that are not releases (e.g. snapshots) are assumed to be newer than any release.
+func (mc *MinecraftConfig) VersionAtLeast(version string) bool {
+	return gameVersionAtLeast(mc.GameVersion, version)
+}
+
+// gameVersionAtLeast reports whether the game version have is the release want or newer.
+func gameVersionAtLeast(have, want string) bool {
+	h, ok := parseGameVersion(have)
+	if !ok {
+		return true
+	}
+	w, _ := parseGameVersion(want)
+	for i := 0; i < max(len(h), len(w)); i++ {
+		var a, b int
+		if i < len(h) {
+			a = h[i]
+		}
+		if i < len(w) {
+			b = w[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return true
+}
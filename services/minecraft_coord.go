@@ -0,0 +1,273 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CoordKind is the notation of a single coordinate.
+type CoordKind int
+
+const (
+	CoordAbsolute CoordKind = iota // 12, -3.5
+	CoordRelative                  // ~, ~2, ~-0.5 (relative to the executor position)
+	CoordLocal                     // ^, ^1 (left, up, forward relative to the executor rotation)
+)
+
+var (
+	ErrMixedLocalCoord   = errors.New("local coordinates (^) cannot be mixed with absolute or relative (~) coordinates")
+	ErrUnresolvableCoord = errors.New("coordinates cannot be resolved without a known executor position")
+)
+
+// Coord is a single parsed coordinate.
+type Coord struct {
+	Kind  CoordKind
+	Value float64 // absolute value, or the offset for relative and local coordinates
+}
+
+// ParseCoord parses an absolute (12), relative (~, ~-3) or local (^, ^1.5) coordinate.
+func ParseCoord(s string) (Coord, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Coord{}, fmt.Errorf("empty coordinate")
+	}
+	c := Coord{Kind: CoordAbsolute}
+	num := s
+	switch s[0] {
+	case '~':
+		c.Kind = CoordRelative
+		num = s[1:]
+	case '^':
+		c.Kind = CoordLocal
+		num = s[1:]
+	}
+	if num == "" {
+		if c.Kind == CoordAbsolute {
+			return Coord{}, fmt.Errorf("invalid coordinate: %s", s)
+		}
+		return c, nil
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return Coord{}, fmt.Errorf("invalid coordinate: %s", s)
+	}
+	c.Value = v
+	return c, nil
+}
+
+// String formats the coordinate in Minecraft command notation.
+func (c Coord) String() string {
+	v := ""
+	if c.Kind == CoordAbsolute || c.Value != 0 {
+		v = strconv.FormatFloat(c.Value, 'f', -1, 64)
+	}
+	switch c.Kind {
+	case CoordRelative:
+		return "~" + v
+	case CoordLocal:
+		return "^" + v
+	}
+	return v
+}
+
+// Vec3 is a resolved position in the world.
+type Vec3 [3]float64
+
+// BlockPos returns the integer block position containing v.
+func (v Vec3) BlockPos() [3]int {
+	return [3]int{int(math.Floor(v[0])), int(math.Floor(v[1])), int(math.Floor(v[2]))}
+}
+
+// IsFinite reports whether no coordinate of v is NaN or infinite.
+func (v Vec3) IsFinite() bool {
+	for _, c := range v {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rotation is the yaw and pitch of a command executor in degrees.
+type Rotation struct {
+	Yaw   float64
+	Pitch float64
+}
+
+// Executor is the known position and rotation a command is executed from.
+type Executor struct {
+	Pos Vec3
+	Rot Rotation
+}
+
+// Position is a parsed x y z coordinate triple.
+type Position [3]Coord
+
+// ParsePosition parses three coordinates and enforces that local (^) coordinates
+// are not mixed with absolute or relative ones.
+func ParsePosition(x, y, z string) (Position, error) {
+	var p Position
+	for i, s := range []string{x, y, z} {
+		c, err := ParseCoord(s)
+		if err != nil {
+			return Position{}, err
+		}
+		p[i] = c
+	}
+	if err := p.check(); err != nil {
+		return Position{}, err
+	}
+	return p, nil
+}
+
+// check enforces the "no mixing ^ with absolute/~" rule.
+func (p Position) check() error {
+	local := 0
+	for _, c := range p {
+		if c.Kind == CoordLocal {
+			local++
+		}
+	}
+	if local != 0 && local != 3 {
+		return ErrMixedLocalCoord
+	}
+	return nil
+}
+
+// Strings returns the coordinates in Minecraft command notation.
+func (p Position) Strings() []string {
+	return []string{p[0].String(), p[1].String(), p[2].String()}
+}
+
+// String returns the position as "x y z".
+func (p Position) String() string {
+	return strings.Join(p.Strings(), " ")
+}
+
+// IsAbsolute reports whether all coordinates are absolute.
+func (p Position) IsAbsolute() bool {
+	return p[0].Kind == CoordAbsolute && p[1].Kind == CoordAbsolute && p[2].Kind == CoordAbsolute
+}
+
+// IsLocal reports whether the position uses local (^) coordinates.
+func (p Position) IsLocal() bool {
+	return p[0].Kind == CoordLocal
+}
+
+// Offset returns p moved by dx, dy, dz along the world axes. Absolute and relative
+// coordinates keep their notation, local coordinates cannot be moved along world axes.
+func (p Position) Offset(dx, dy, dz float64) (Position, error) {
+	if p.IsLocal() {
+		return Position{}, ErrMixedLocalCoord
+	}
+	p[0].Value += dx
+	p[1].Value += dy
+	p[2].Value += dz
+	return p, nil
+}
+
+// Resolve returns the world position of p. Relative and local coordinates need a
+// known executor, otherwise ErrUnresolvableCoord is returned.
+func (p Position) Resolve(exec *Executor) (Vec3, error) {
+	if p.IsAbsolute() {
+		return Vec3{p[0].Value, p[1].Value, p[2].Value}, nil
+	}
+	if exec == nil {
+		return Vec3{}, ErrUnresolvableCoord
+	}
+	if p.IsLocal() {
+		return resolveLocal(exec, p[0].Value, p[1].Value, p[2].Value), nil
+	}
+	var v Vec3
+	for i, c := range p {
+		v[i] = c.Value
+		if c.Kind == CoordRelative {
+			v[i] += exec.Pos[i]
+		}
+	}
+	return v, nil
+}
+
+// resolveLocal converts local left/up/forward offsets into a world position, using
+// the same math as Minecraft's LocalCoordinates.
+func resolveLocal(exec *Executor, left, up, forward float64) Vec3 {
+	yaw := (exec.Rot.Yaw + 90) * math.Pi / 180
+	pitch := -exec.Rot.Pitch * math.Pi / 180
+	pitchUp := (-exec.Rot.Pitch + 90) * math.Pi / 180
+
+	forwardDir := Vec3{math.Cos(yaw) * math.Cos(pitch), math.Sin(pitch), math.Sin(yaw) * math.Cos(pitch)}
+	upDir := Vec3{math.Cos(yaw) * math.Cos(pitchUp), math.Sin(pitchUp), math.Sin(yaw) * math.Cos(pitchUp)}
+	// left = up x forward, negated
+	leftDir := Vec3{
+		-(forwardDir[1]*upDir[2] - forwardDir[2]*upDir[1]),
+		-(forwardDir[2]*upDir[0] - forwardDir[0]*upDir[2]),
+		-(forwardDir[0]*upDir[1] - forwardDir[1]*upDir[0]),
+	}
+	var v Vec3
+	for i := range v {
+		v[i] = exec.Pos[i] + forwardDir[i]*forward + upDir[i]*up + leftDir[i]*left
+	}
+	return v
+}
+
+// RegionSize returns the block dimensions of the world-aligned region between p1 and p2
+// (inclusive), the box /fill and /clone operate on. When the executor is unknown the
+// size can still be computed if, per axis, both corners use the same relative or
+// absolute notation (e.g. "~ ~ ~" to "~10 ~5 ~10"). Local (^) corners always need the
+// executor, since rotated local axes span a larger world box.
+func RegionSize(p1, p2 Position, exec *Executor) ([3]int, error) {
+	if v1, err := p1.Resolve(exec); err == nil {
+		if v2, err := p2.Resolve(exec); err == nil {
+			b1, b2 := v1.BlockPos(), v2.BlockPos()
+			return [3]int{absInt(b1[0]-b2[0]) + 1, absInt(b1[1]-b2[1]) + 1, absInt(b1[2]-b2[2]) + 1}, nil
+		}
+	}
+	if p1.IsLocal() || p2.IsLocal() {
+		return [3]int{}, ErrUnresolvableCoord
+	}
+	var size [3]int
+	for i := range size {
+		if p1[i].Kind != p2[i].Kind {
+			return [3]int{}, ErrUnresolvableCoord
+		}
+		size[i] = int(math.Abs(math.Floor(p1[i].Value)-math.Floor(p2[i].Value))) + 1
+	}
+	return size, nil
+}
+
+// RegionVolume returns the number of blocks in the region between p1 and p2.
+func RegionVolume(p1, p2 Position, exec *Executor) (int, error) {
+	size, err := RegionSize(p1, p2, exec)
+	if err != nil {
+		return 0, err
+	}
+	return size[0] * size[1] * size[2], nil
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
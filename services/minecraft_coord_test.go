@@ -0,0 +1,153 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseCoord(t *testing.T) {
+	valid := map[string]Coord{
+		"12":    {CoordAbsolute, 12},
+		"-3.5":  {CoordAbsolute, -3.5},
+		"~":     {CoordRelative, 0},
+		"~-2":   {CoordRelative, -2},
+		"^":     {CoordLocal, 0},
+		"^1.5":  {CoordLocal, 1.5},
+		" ~4 ":  {CoordRelative, 4},
+		"~0.25": {CoordRelative, 0.25},
+	}
+	for s, want := range valid {
+		got, err := ParseCoord(s)
+		if err != nil {
+			t.Errorf("ParseCoord(%q) failed: %v", s, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseCoord(%q) = %+v, want %+v", s, got, want)
+		}
+	}
+	for _, s := range []string{"", "~abc", "~~", "^~", "abc", "1,2", "NaN", "~Inf"} {
+		if _, err := ParseCoord(s); err == nil {
+			t.Errorf("ParseCoord(%q) should fail", s)
+		}
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	if _, err := ParsePosition("^", "^1", "^"); err != nil {
+		t.Errorf("local position should be valid: %v", err)
+	}
+	if _, err := ParsePosition("~", "64", "~-1"); err != nil {
+		t.Errorf("mixed absolute/relative position should be valid: %v", err)
+	}
+	if _, err := ParsePosition("^", "~", "^"); !errors.Is(err, ErrMixedLocalCoord) {
+		t.Errorf("expected ErrMixedLocalCoord, got %v", err)
+	}
+	p, err := ParsePosition("~", "~0", "-7.5")
+	if err != nil || p.String() != "~ ~ -7.5" {
+		t.Errorf("unexpected position %q: %v", p.String(), err)
+	}
+}
+
+func TestPosition_Resolve(t *testing.T) {
+	exec := &Executor{Pos: Vec3{10, 64, 10}}
+
+	p, _ := ParsePosition("~1", "70", "~-2")
+	v, err := p.Resolve(exec)
+	if err != nil || v != (Vec3{11, 70, 8}) {
+		t.Errorf("relative resolve = %v, %v", v, err)
+	}
+	if _, err := p.Resolve(nil); !errors.Is(err, ErrUnresolvableCoord) {
+		t.Errorf("expected ErrUnresolvableCoord, got %v", err)
+	}
+
+	// Facing south (yaw 0): forward is +z and left is +x
+	p, _ = ParsePosition("^1", "^2", "^3")
+	v, err = p.Resolve(exec)
+	if err != nil {
+		t.Fatalf("local resolve failed: %v", err)
+	}
+	want := Vec3{11, 66, 13}
+	for i := range v {
+		if math.Abs(v[i]-want[i]) > 1e-9 {
+			t.Errorf("local resolve = %v, want %v", v, want)
+			break
+		}
+	}
+}
+
+func TestRegionVolume(t *testing.T) {
+	p1, _ := ParsePosition("0", "60", "0")
+	p2, _ := ParsePosition("9", "64", "-9")
+	if v, err := RegionVolume(p1, p2, nil); err != nil || v != 10*5*10 {
+		t.Errorf("absolute volume = %d, %v", v, err)
+	}
+
+	p1, _ = ParsePosition("~", "~", "~")
+	p2, _ = ParsePosition("~31", "~31", "~31")
+	if v, err := RegionVolume(p1, p2, nil); err != nil || v != 32768 {
+		t.Errorf("relative volume = %d, %v", v, err)
+	}
+
+	p2, _ = ParsePosition("31", "~31", "~31")
+	if _, err := RegionVolume(p1, p2, nil); !errors.Is(err, ErrUnresolvableCoord) {
+		t.Errorf("expected ErrUnresolvableCoord, got %v", err)
+	}
+	if v, err := RegionVolume(p1, p2, &Executor{Pos: Vec3{0, 0, 0}}); err != nil || v != 32768 {
+		t.Errorf("resolved volume = %d, %v", v, err)
+	}
+
+	// Local corners span a larger world box once rotated, so they need the rotation
+	p1, _ = ParsePosition("^", "^", "^")
+	p2, _ = ParsePosition("^10", "^0", "^0")
+	if _, err := RegionVolume(p1, p2, nil); !errors.Is(err, ErrUnresolvableCoord) {
+		t.Errorf("local corners without an executor: expected ErrUnresolvableCoord, got %v", err)
+	}
+	if v, err := RegionVolume(p1, p2, &Executor{Rot: Rotation{Yaw: 45}}); err != nil || v != 8*1*8 {
+		t.Errorf("rotated local volume = %d, %v", v, err)
+	}
+}
+
+func TestMinecraftServer_checkRegionVolume(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{MaxFillVolume: 1000, GameVersion: "1.20.4"}}
+	p1, _ := ParsePosition("~", "64", "~")
+	p2, _ := ParsePosition("100", "64", "100")
+	if err := ms.checkRegionVolume(p1, p2, nil); err == nil {
+		t.Errorf("a region of unknown size should be rejected")
+	}
+	if err := ms.checkRegionVolume(p1, p2, &Executor{Pos: Vec3{95, 64, 95}}); err != nil {
+		t.Errorf("a small region at the player should pass: %v", err)
+	}
+	if err := ms.checkRegionVolume(p1, p2, &Executor{Pos: Vec3{0, 64, 0}}); err == nil {
+		t.Errorf("a large region at the player should be rejected")
+	}
+
+	if got := ms.executeAt("Steve", "/fill ~ ~ ~ ~1 ~1 ~1 minecraft:stone"); got != "/execute at Steve run fill ~ ~ ~ ~1 ~1 ~1 minecraft:stone" {
+		t.Errorf("executeAt = %s", got)
+	}
+	ms.config.GameVersion = "1.12.2"
+	if got := ms.executeAt("Steve", "/setblock ~ ~ ~ stone"); got != "/execute Steve ~ ~ ~ setblock ~ ~ ~ stone" {
+		t.Errorf("executeAt before 1.13 = %s", got)
+	}
+}
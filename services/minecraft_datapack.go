@@ -0,0 +1,552 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultPackDescription is the description of generated data packs.
+	defaultPackDescription = "Generated by MoLing"
+	// maxFunctionLines is the longest function written in one call.
+	maxFunctionLines = 65536
+)
+
+var (
+	packNameRegex     = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	namespaceRegex    = regexp.MustCompile(`^[a-z0-9_.-]+$`)
+	resourcePathRegex = regexp.MustCompile(`^[a-z0-9_.-]+(/[a-z0-9_.-]+)*$`)
+)
+
+// packFormats lists the data pack format of each release that changed it, newest first.
+var packFormats = []struct {
+	Since  string
+	Format int
+}{
+	{"1.21.7", 81},
+	{"1.21.6", 80},
+	{"1.21.5", 71},
+	{"1.21.4", 61},
+	{"1.21.2", 57},
+	{"1.21", 48},
+	{"1.20.5", 41},
+	{"1.20.3", 26},
+	{"1.20.2", 18},
+	{"1.20", 15},
+	{"1.19.4", 12},
+	{"1.19", 10},
+	{"1.18.2", 9},
+	{"1.18", 8},
+	{"1.17", 7},
+	{"1.16.2", 6},
+	{"1.15", 5},
+	{"1.13", 4},
+}
+
+// PackFormat returns the data pack format for GameVersion. Versions newer than the
+// table use the newest known format.
+func (mc *MinecraftConfig) PackFormat() (int, error) {
+	for _, f := range packFormats {
+		if mc.VersionAtLeast(f.Since) {
+			return f.Format, nil
+		}
+	}
+	return 0, fmt.Errorf("data packs need Minecraft 1.13 or newer, the server is %s", mc.GameVersion)
+}
+
+// functionDir returns the data pack directory of functions and function tags, which
+// lost its plural in 1.21.
+func (mc *MinecraftConfig) functionDir() string {
+	if mc.VersionAtLeast("1.21") {
+		return "function"
+	}
+	return "functions"
+}
+
+// parseResourceID splits a resource location like "castle:build/walls" into namespace
+// and path, using ns when the namespace is omitted.
+func parseResourceID(id, ns string) (string, string, error) {
+	path := id
+	if n, p, ok := strings.Cut(id, ":"); ok {
+		ns, path = n, p
+	}
+	if !namespaceRegex.MatchString(ns) {
+		return "", "", fmt.Errorf("invalid namespace in %s: only a-z, 0-9, '_', '.' and '-' are allowed", id)
+	}
+	if ns == "." || ns == ".." {
+		return "", "", fmt.Errorf("invalid namespace in %s", id)
+	}
+	if !resourcePathRegex.MatchString(path) {
+		return "", "", fmt.Errorf("invalid path in %s: only a-z, 0-9, '_', '.', '-' and '/' are allowed", id)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return "", "", fmt.Errorf("invalid path in %s: '.' and '..' are not allowed as path segments", id)
+		}
+	}
+	return ns, path, nil
+}
+
+// withinDir reports whether the cleaned path target is dir or inside it.
+func withinDir(dir, target string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(target))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// functionLines turns commands, one per line, into the lines of a .mcfunction file.
+// Leading slashes are removed since functions do not accept them.
+func functionLines(commands string) ([]string, error) {
+	var lines []string
+	for i, line := range strings.Split(commands, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		line = strings.TrimLeft(line, "/")
+		if line == "" {
+			return nil, fmt.Errorf("line %d: empty command", i+1)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no commands given")
+	}
+	if len(lines) > maxFunctionLines {
+		return nil, fmt.Errorf("a function may have at most %d commands, got %d", maxFunctionLines, len(lines))
+	}
+	return lines, nil
+}
+
+// Datapack is a data pack in the datapacks directory of a world.
+type Datapack struct {
+	Dir         string // pack directory
+	Format      int    // pack_format of pack.mcmeta
+	FunctionDir string // "function" or "functions", depending on the version
+}
+
+// packMeta is the content of pack.mcmeta.
+type packMeta struct {
+	Pack struct {
+		PackFormat  int    `json:"pack_format"`
+		Description string `json:"description"`
+	} `json:"pack"`
+}
+
+// functionTag is the content of a function tag file.
+type functionTag struct {
+	Replace bool     `json:"replace,omitempty"`
+	Values  []string `json:"values"`
+}
+
+// WriteMeta creates or updates pack.mcmeta. An existing description is kept unless
+// a new one is given.
+func (d *Datapack) WriteMeta(description string) error {
+	var meta packMeta
+	path := filepath.Join(d.Dir, "pack.mcmeta")
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+	meta.Pack.PackFormat = d.Format
+	if description != "" {
+		meta.Pack.Description = description
+	} else if meta.Pack.Description == "" {
+		meta.Pack.Description = defaultPackDescription
+	}
+	return writeJSONFile(path, meta)
+}
+
+// packFile joins elem to the pack directory and refuses paths that leave it.
+func (d *Datapack) packFile(elem ...string) (string, error) {
+	file := filepath.Join(append([]string{d.Dir}, elem...)...)
+	if !withinDir(d.Dir, file) || filepath.Clean(file) == filepath.Clean(d.Dir) {
+		return "", fmt.Errorf("path %s is outside the data pack", filepath.Join(elem...))
+	}
+	return file, nil
+}
+
+// functionPath returns the file of the function ns:path.
+func (d *Datapack) functionPath(ns, path string) (string, error) {
+	return d.packFile("data", ns, d.FunctionDir, filepath.FromSlash(path)+".mcfunction")
+}
+
+// WriteFunction writes the function ns:path, replacing it or appending to it.
+func (d *Datapack) WriteFunction(ns, path string, lines []string, appendLines bool) error {
+	file, err := d.functionPath(ns, path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendLines {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(file, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DeleteFunction removes the function ns:path and its entries in the function tags.
+func (d *Datapack) DeleteFunction(ns, path string) error {
+	file, err := d.functionPath(ns, path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	id := ns + ":" + path
+	tags, _ := filepath.Glob(filepath.Join(d.Dir, "data", "*", "tags", d.FunctionDir, "*.json"))
+	for _, file := range tags {
+		var tag functionTag
+		data, err := os.ReadFile(file)
+		if err != nil || json.Unmarshal(data, &tag) != nil {
+			continue
+		}
+		if i := indexOf(tag.Values, id); i >= 0 {
+			tag.Values = append(tag.Values[:i], tag.Values[i+1:]...)
+			if err := writeJSONFile(file, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddToTag adds the function id to a function tag such as minecraft:load or minecraft:tick.
+func (d *Datapack) AddToTag(tag, id string) error {
+	ns, path, err := parseResourceID(tag, "minecraft")
+	if err != nil {
+		return err
+	}
+	file, err := d.packFile("data", ns, "tags", d.FunctionDir, filepath.FromSlash(path)+".json")
+	if err != nil {
+		return err
+	}
+	var t functionTag
+	if data, err := os.ReadFile(file); err == nil {
+		if err := json.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("invalid %s: %w", file, err)
+		}
+	}
+	if indexOf(t.Values, id) >= 0 {
+		return nil
+	}
+	t.Values = append(t.Values, id)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return writeJSONFile(file, t)
+}
+
+// Functions returns the ids of all functions in the pack, sorted.
+func (d *Datapack) Functions() ([]string, error) {
+	var ids []string
+	data := filepath.Join(d.Dir, "data")
+	namespaces, err := os.ReadDir(data)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		root := filepath.Join(data, ns.Name(), d.FunctionDir)
+		err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if entry.IsDir() || !strings.HasSuffix(path, ".mcfunction") {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, strings.TrimSuffix(path, ".mcfunction"))
+			ids = append(ids, ns.Name()+":"+filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// writeJSONFile writes v as indented JSON.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// datapack returns the data pack name in the datapacks directory of the world.
+func (ms *MinecraftServer) datapack(name string) (*Datapack, error) {
+	if !packNameRegex.MatchString(name) || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid data pack name %q: only letters, digits, '_', '.' and '-' are allowed", name)
+	}
+	format, err := ms.config.PackFormat()
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Join(ms.worldDir(), "datapacks")
+	dir := filepath.Join(root, name)
+	if !withinDir(root, dir) || filepath.Clean(dir) == filepath.Clean(root) {
+		return nil, fmt.Errorf("invalid data pack name %q", name)
+	}
+	return &Datapack{
+		Dir:         dir,
+		Format:      format,
+		FunctionDir: ms.config.functionDir(),
+	}, nil
+}
+
+// reloadDatapacks runs /reload on a running server and describes the outcome.
+func (ms *MinecraftServer) reloadDatapacks() string {
+	if !ms.isServerRunning() {
+		return "the server is not running, the pack is loaded on the next start"
+	}
+	result, err := ms.WriteCommand("/reload")
+	if err != nil || result.IsError {
+		ms.logger.Warn().Err(err).Msg("Failed to reload the data packs")
+		return "reloading the data packs failed, run /reload manually"
+	}
+	return "data packs reloaded"
+}
+
+// registerDatapackTools registers the data pack tools.
+func (ms *MinecraftServer) registerDatapackTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_datapack_write",
+		mcp.WithDescription("Save commands as a function of a data pack in the world, so long builds and logic persist and can be re-run cheaply with minecraft_function. Creates the pack with the pack_format of the server version, optionally adds the function to tags such as load (run after every reload) or tick (run every game tick), and runs /reload."),
+		mcp.WithString("pack", mcp.Description("Data pack name, the directory under <world>/datapacks (letters, digits, '_', '.' and '-')"), mcp.Required()),
+		mcp.WithString("function", mcp.Description("Function id, e.g. castle:build/walls; the namespace defaults to the lowercase pack name"), mcp.Required()),
+		mcp.WithString("commands", mcp.Description("Commands, one per line; a leading '/' is removed and lines starting with '#' are comments"), mcp.Required()),
+		mcp.WithBoolean("append", mcp.Description("Append to the function instead of replacing it (optional, default: false)")),
+		mcp.WithString("tags", mcp.Description("Comma separated function tags to add the function to, e.g. 'load', 'tick' or 'castle:rebuild' (optional)")),
+		mcp.WithString("description", mcp.Description("Description of the data pack (optional)")),
+		mcp.WithBoolean("reload", mcp.Description("Run /reload when the server is running (optional, default: true)")),
+	), ms.handleDatapackWrite)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_datapack_list",
+		mcp.WithDescription("List the data packs in the world and the functions they contain"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleDatapackList)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_datapack_delete",
+		mcp.WithDescription("Delete a function of a data pack, or the whole data pack when no function is given, and run /reload"),
+		mcp.WithString("pack", mcp.Description("Data pack name"), mcp.Required()),
+		mcp.WithString("function", mcp.Description("Function id (optional)")),
+	), ms.handleDatapackDelete)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_function",
+		mcp.WithDescription("Run a function of a data pack. Relative coordinates in the function are relative to the player when one is given, otherwise to the world spawn."),
+		mcp.WithString("function", mcp.Description("Function id, e.g. castle:build/walls, or a function tag like #castle:rebuild"), mcp.Required()),
+		mcp.WithString("player", mcp.Description("Player or selector to run the function as and at (optional)")),
+	), ms.handleFunction)
+}
+
+// handleDatapackWrite implements the minecraft_datapack_write tool.
+func (ms *MinecraftServer) handleDatapackWrite(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	name, err := getStringArg(args, "pack", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pack, err := ms.datapack(name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	function, err := getStringArg(args, "function", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ns, path, err := parseResourceID(function, strings.ToLower(name))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	commands, err := getStringArg(args, "commands", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lines, err := functionLines(commands)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	appendLines, _ := getBoolArg(args, "append", false)
+	description, _ := getStringArg(args, "description", false)
+	tagList, _ := getStringArg(args, "tags", false)
+	var tags []string
+	for _, tag := range strings.Split(tagList, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+			if _, _, err := parseResourceID(tag, "minecraft"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			tags = append(tags, tag)
+		}
+	}
+	reload := true
+	if _, ok := args["reload"]; ok {
+		if reload, err = getBoolArg(args, "reload", false); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	if err := os.MkdirAll(pack.Dir, 0o755); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := pack.WriteMeta(description); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := pack.WriteFunction(ns, path, lines, appendLines); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	id := ns + ":" + path
+	for _, tag := range tags {
+		if err := pack.AddToTag(tag, id); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	ms.logger.Info().Str("pack", name).Str("function", id).Int("commands", len(lines)).Msg("Wrote data pack function")
+
+	summary := fmt.Sprintf("Wrote %d lines to function %s in data pack %s (pack_format %d)", len(lines), id, name, pack.Format)
+	if len(tags) > 0 {
+		summary += ", tags: " + strings.Join(tags, ", ")
+	}
+	if reload {
+		summary += "; " + ms.reloadDatapacks()
+	}
+	return mcp.NewToolResultText(summary), nil
+}
+
+// handleDatapackList implements the minecraft_datapack_list tool.
+func (ms *MinecraftServer) handleDatapackList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	entries, err := os.ReadDir(filepath.Join(ms.worldDir(), "datapacks"))
+	if err != nil && !os.IsNotExist(err) {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var sb strings.Builder
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			// Zipped packs are not generated by the tools, just list them
+			sb.WriteString(entry.Name() + "\n")
+			continue
+		}
+		pack, err := ms.datapack(entry.Name())
+		if err != nil {
+			sb.WriteString(entry.Name() + "\n")
+			continue
+		}
+		functions, err := pack.Functions()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		sb.WriteString(fmt.Sprintf("%s: %d functions\n", entry.Name(), len(functions)))
+		for _, f := range functions {
+			sb.WriteString("  " + f + "\n")
+		}
+	}
+	if sb.Len() == 0 {
+		return mcp.NewToolResultText("No data packs in the world"), nil
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleDatapackDelete implements the minecraft_datapack_delete tool.
+func (ms *MinecraftServer) handleDatapackDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	name, err := getStringArg(args, "pack", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pack, err := ms.datapack(name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if _, err := os.Stat(filepath.Join(pack.Dir, "pack.mcmeta")); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("data pack %s not found", name)), nil
+	}
+
+	var summary string
+	if function, _ := getStringArg(args, "function", false); function != "" {
+		ns, path, err := parseResourceID(function, strings.ToLower(name))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := pack.DeleteFunction(ns, path); err != nil {
+			if os.IsNotExist(err) {
+				return mcp.NewToolResultError(fmt.Sprintf("function %s:%s not found in data pack %s", ns, path, name)), nil
+			}
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		summary = fmt.Sprintf("Deleted function %s:%s from data pack %s", ns, path, name)
+	} else {
+		if err := os.RemoveAll(pack.Dir); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		summary = fmt.Sprintf("Deleted data pack %s", name)
+	}
+	return mcp.NewToolResultText(summary + "; " + ms.reloadDatapacks()), nil
+}
+
+// handleFunction implements the minecraft_function tool.
+func (ms *MinecraftServer) handleFunction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	function, err := getStringArg(args, "function", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	id := strings.TrimPrefix(function, "#")
+	if !strings.Contains(id, ":") {
+		return mcp.NewToolResultError(fmt.Sprintf("function %s needs a namespace, e.g. castle:%s", function, id)), nil
+	}
+	if _, _, err := parseResourceID(id, ""); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	command := "/function " + function
+	if player, _ := getStringArg(args, "player", false); player != "" {
+		command = fmt.Sprintf("/execute as %s at @s run function %s", player, function)
+	}
+	return ms.WriteCommand(command)
+}
@@ -0,0 +1,138 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMinecraftConfig_PackFormat(t *testing.T) {
+	for version, want := range map[string]int{"1.13.2": 4, "1.16.5": 6, "1.20.2": 18, "1.20.4": 26, "1.21": 48, "1.21.1": 48, "1.21.4": 61, "25w14a": 81} {
+		mc := &MinecraftConfig{GameVersion: version}
+		if f, err := mc.PackFormat(); err != nil || f != want {
+			t.Errorf("PackFormat(%s) = %d, %v, want %d", version, f, err, want)
+		}
+	}
+	if _, err := (&MinecraftConfig{GameVersion: "1.12.2"}).PackFormat(); err == nil {
+		t.Errorf("1.12.2 has no data packs")
+	}
+}
+
+func TestMinecraftServer_handleDatapackWrite(t *testing.T) {
+	root := t.TempDir()
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root, GameVersion: "1.21"}}
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) string {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		if err != nil || result.IsError {
+			t.Fatalf("%v failed: %v, %v", args, result, err)
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+	read := func(path string) string {
+		data, err := os.ReadFile(filepath.Join(root, "world", "datapacks", "Castle", path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	call(ms.handleDatapackWrite, map[string]interface{}{
+		"pack": "Castle", "function": "build/walls", "commands": "# walls\n/fill 0 64 0 9 70 0 minecraft:stone\n\n setblock 0 71 0 minecraft:torch", "tags": "load, #castle:rebuild",
+	})
+	call(ms.handleDatapackWrite, map[string]interface{}{
+		"pack": "Castle", "function": "castle:build/walls", "commands": "say done", "append": true, "tags": "load",
+	})
+	call(ms.handleDatapackWrite, map[string]interface{}{"pack": "Castle", "function": "tick", "commands": "say tick", "tags": "tick"})
+
+	if s := read("pack.mcmeta"); !strings.Contains(s, `"pack_format": 48`) || !strings.Contains(s, defaultPackDescription) {
+		t.Errorf("pack.mcmeta = %s", s)
+	}
+	if s := read("data/castle/function/build/walls.mcfunction"); s != "# walls\nfill 0 64 0 9 70 0 minecraft:stone\nsetblock 0 71 0 minecraft:torch\nsay done\n" {
+		t.Errorf("function = %q", s)
+	}
+	if s := read("data/minecraft/tags/function/load.json"); strings.Count(s, "castle:build/walls") != 1 {
+		t.Errorf("load tag = %s", s)
+	}
+	if s := read("data/castle/tags/function/rebuild.json"); !strings.Contains(s, "castle:build/walls") {
+		t.Errorf("rebuild tag = %s", s)
+	}
+	if s := call(ms.handleDatapackList, nil); s != "Castle: 2 functions\n  castle:build/walls\n  castle:tick\n" {
+		t.Errorf("list = %q", s)
+	}
+
+	call(ms.handleDatapackDelete, map[string]interface{}{"pack": "Castle", "function": "build/walls"})
+	if s := read("data/minecraft/tags/function/load.json"); strings.Contains(s, "castle:build/walls") {
+		t.Errorf("deleted function is still in the load tag: %s", s)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"pack": "../escape", "function": "a", "commands": "say hi"},
+		{"pack": "Castle", "function": "Castle:Walls", "commands": "say hi"},
+		{"pack": "Castle", "function": "a", "commands": "\n/\n"},
+		{"pack": "..", "function": "a", "commands": "say hi"},
+		{"pack": ".", "function": "a", "commands": "say hi"},
+		{"pack": "Castle", "function": "castle:../../../../ops", "commands": "say hi"},
+		{"pack": "Castle", "function": "..:a", "commands": "say hi"},
+		{"pack": "Castle", "function": "a", "commands": "say hi", "tags": "minecraft:../../../../../../../ops"},
+	} {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		if result, _ := ms.handleDatapackWrite(context.Background(), request); !result.IsError {
+			t.Errorf("%v should fail", args)
+		}
+	}
+	for _, args := range []map[string]interface{}{
+		{"pack": ".."},
+		{"pack": "Castle", "function": "castle:../../../../../pack"},
+	} {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		if result, _ := ms.handleDatapackDelete(context.Background(), request); !result.IsError {
+			t.Errorf("%v should fail", args)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "world", "datapacks", "Castle", "pack.mcmeta")); err != nil {
+		t.Errorf("pack was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "ops.json")); !os.IsNotExist(err) {
+		t.Errorf("tag written outside the pack: %v", err)
+	}
+}
+
+func TestDatapack_packFile(t *testing.T) {
+	d := &Datapack{Dir: filepath.Join("world", "datapacks", "castle"), FunctionDir: "function"}
+	for _, elem := range [][]string{{".."}, {"data", "..", "..", "x"}, {"."}} {
+		if file, err := d.packFile(elem...); err == nil {
+			t.Errorf("packFile(%v) = %s, want an error", elem, file)
+		}
+	}
+	if file, err := d.packFile("data", "castle", "function", "a.mcfunction"); err != nil || file != filepath.Join(d.Dir, "data", "castle", "function", "a.mcfunction") {
+		t.Errorf("packFile = %s, %v", file, err)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// LogEventType is the kind of a game event recognized in the server output.
+type LogEventType string
+
+const (
+	EventServerStarting  LogEventType = "server_starting"  // Text: the game version
+	EventServerDone      LogEventType = "server_done"      // Text: the startup time, e.g. "3.21s"
+	EventServerStopping  LogEventType = "server_stopping"  // Text: the message
+	EventCantKeepUp      LogEventType = "cant_keep_up"     // Text: the lag, e.g. "2500ms"
+	EventPlayerJoined    LogEventType = "player_joined"    // Player
+	EventPlayerLeft      LogEventType = "player_left"      // Player
+	EventChatMessage     LogEventType = "chat"             // Player, Text: the message
+	EventDeath           LogEventType = "death"            // Player, Text: the death message
+	EventAdvancement     LogEventType = "advancement"      // Player, Text: the advancement title
+	EventCommandFeedback LogEventType = "command_feedback" // Player: who ran the command (empty for the console), Text: the response, Success
+)
+
+// logEventTypes lists the known event types in the order of the constants above.
+var logEventTypes = []string{
+	string(EventServerStarting), string(EventServerDone), string(EventServerStopping), string(EventCantKeepUp),
+	string(EventPlayerJoined), string(EventPlayerLeft), string(EventChatMessage), string(EventDeath),
+	string(EventAdvancement), string(EventCommandFeedback),
+}
+
+// LogEvent is a game event parsed from a line of the server output.
+type LogEvent struct {
+	Type    LogEventType     `json:"type"`
+	Time    string           `json:"time,omitempty"`
+	Thread  string           `json:"thread,omitempty"`
+	Level   mcp.LoggingLevel `json:"level"`
+	Player  string           `json:"player,omitempty"`
+	Text    string           `json:"text,omitempty"`
+	Success bool             `json:"success,omitempty"` // for command feedback
+	Line    ServerLogLine    `json:"-"`
+}
+
+// eventPattern recognizes an event in the message of a log line. The named groups
+// "player" and "text" fill the fields of the event; without a "text" group, Text is
+// the whole message.
+type eventPattern struct {
+	Type  LogEventType
+	Regex *regexp.Regexp
+	Since string // first game version with this message, empty: all
+	Until string // first game version without this message, empty: all
+}
+
+// newEventPattern compiles an event pattern, {player} stands for a player name.
+func newEventPattern(typ LogEventType, expr, since, until string) eventPattern {
+	expr = strings.ReplaceAll(expr, "{player}", `(?P<player>[A-Za-z0-9_]{1,16})`)
+	return eventPattern{Type: typ, Regex: regexp.MustCompile(expr), Since: since, Until: until}
+}
+
+// deathPhrases start the vanilla death messages after the player name.
+var deathPhrases = []string{
+	"was ", "drowned", "died", "blew up", "burned to death", "burnt to a crisp", "hit the ground too hard",
+	"fell ", "tried to swim in lava", "starved to death", "suffocated in a wall", "withered away",
+	"froze to death", "went up in flames", "went off with a bang", "walked into", "discovered the floor was lava",
+	"experienced kinetic energy", "didn't want to live", "left the confines of this world", "was squashed",
+}
+
+// eventPatterns are tried in order, the first match wins.
+var eventPatterns = []eventPattern{
+	newEventPattern(EventServerStarting, `^Starting minecraft server version (?P<text>\S+)`, "", ""),
+	newEventPattern(EventServerDone, `^Done \((?P<text>[0-9.,]+s)\)!`, "", ""),
+	newEventPattern(EventServerStopping, `^Stopping (?:the )?server$`, "", ""),
+	newEventPattern(EventCantKeepUp, `^Can't keep up! Is the server overloaded\? Running (?P<text>\d+ms)`, "", ""),
+	newEventPattern(EventPlayerJoined, `^{player}(?: \(formerly known as \w+\))? joined the game$`, "", ""),
+	newEventPattern(EventPlayerLeft, `^{player} left the game$`, "", ""),
+	// Since 1.19.1 chat that is not signed by the client is marked as such
+	newEventPattern(EventChatMessage, `^(?:\[Not Secure\] )?<{player}> (?P<text>.*)$`, "1.19.1", ""),
+	newEventPattern(EventChatMessage, `^<{player}> (?P<text>.*)$`, "", "1.19.1"),
+	newEventPattern(EventAdvancement, `^{player} has (?:made the advancement|completed the challenge|reached the goal) \[(?P<text>.+)\]$`, "1.12", ""),
+	newEventPattern(EventAdvancement, `^{player} has just earned the achievement \[(?P<text>.+)\]$`, "", "1.12"),
+	newEventPattern(EventDeath, `^{player} (?:`+strings.Join(quoteAll(deathPhrases), "|")+`)`, "", ""),
+	// Operators see the responses of commands run by others as "[Steve: Set the time to 1000]"
+	newEventPattern(EventCommandFeedback, `^\[(?P<player>[^:\]]+): (?P<text>.*)\]$`, "", ""),
+}
+
+// quoteAll escapes the regular expression metacharacters of every string.
+func quoteAll(list []string) []string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = regexp.QuoteMeta(s)
+	}
+	return quoted
+}
+
+// LogEventParser turns server log lines into events with the message formats of a game version.
+type LogEventParser struct {
+	patterns []eventPattern
+}
+
+// NewLogEventParser returns a parser for the messages of the given game version.
+func NewLogEventParser(gameVersion string) *LogEventParser {
+	p := &LogEventParser{}
+	for _, pattern := range eventPatterns {
+		if pattern.Since != "" && !gameVersionAtLeast(gameVersion, pattern.Since) {
+			continue
+		}
+		if pattern.Until != "" && gameVersionAtLeast(gameVersion, pattern.Until) {
+			continue
+		}
+		p.patterns = append(p.patterns, pattern)
+	}
+	return p
+}
+
+// Parse returns the event of a log line, or false if the line is no known event.
+// Responses of commands sent to the console are recognized by the same patterns as
+// the command results.
+func (p *LogEventParser) Parse(l ServerLogLine) (LogEvent, bool) {
+	event := LogEvent{Time: l.Time, Thread: l.Thread, Level: l.Level, Text: l.Message, Line: l}
+	if l.Time == "" {
+		// Not a log record, e.g. a stack trace
+		return event, false
+	}
+	for _, pattern := range p.patterns {
+		m := pattern.Regex.FindStringSubmatch(l.Message)
+		if m == nil {
+			continue
+		}
+		event.Type = pattern.Type
+		for i, name := range pattern.Regex.SubexpNames() {
+			switch name {
+			case "player":
+				event.Player = m[i]
+			case "text":
+				event.Text = m[i]
+			}
+		}
+		if event.Type == EventCommandFeedback {
+			event.Success = isMcSuccessLog(event.Text)
+		}
+		return event, true
+	}
+	if l.Level == mcp.LoggingLevelInfo && (isMcSuccessLog(l.Message) || isMcFailureLog(l.Message)) {
+		event.Type = EventCommandFeedback
+		event.Success = isMcSuccessLog(l.Message)
+		return event, true
+	}
+	return event, false
+}
+
+// eventSubscriber is a consumer of the events.
+type eventSubscriber struct {
+	ch    chan LogEvent
+	types map[LogEventType]bool // nil: all types
+}
+
+// eventBus distributes the parsed events to the subsystems that subscribed to them.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[int]*eventSubscriber
+	next int
+}
+
+// eventBufferSize is the number of undelivered events a subscriber can fall behind
+// before further events are dropped for it.
+const eventBufferSize = 64
+
+// Subscribe returns a channel receiving the events of the given types (all if none) and
+// a function that ends the subscription and closes the channel. Events are dropped
+// for subscribers that do not keep up, so the server output is never blocked.
+func (b *eventBus) Subscribe(types ...LogEventType) (<-chan LogEvent, func()) {
+	sub := &eventSubscriber{ch: make(chan LogEvent, eventBufferSize)}
+	if len(types) > 0 {
+		sub.types = make(map[LogEventType]bool)
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[int]*eventSubscriber)
+	}
+	id := b.next
+	b.next++
+	b.subs[id] = sub
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, id)
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish delivers an event to its subscribers without blocking.
+func (b *eventBus) Publish(event LogEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents returns a channel receiving the game events of the given types (all
+// if none) and a function that ends the subscription.
+func (ms *MinecraftServer) SubscribeEvents(types ...LogEventType) (<-chan LogEvent, func()) {
+	return ms.events.Subscribe(types...)
+}
+
+// publishLogLine hands a line of the server output to the log stream and the event bus.
+func (ms *MinecraftServer) publishLogLine(l ServerLogLine) (LogEvent, bool) {
+	ms.logs.Publish(l)
+	parser := ms.eventParser
+	if parser == nil {
+		parser = NewLogEventParser(ms.config.GameVersion)
+	}
+	event, ok := parser.Parse(l)
+	if ok {
+		ms.events.Publish(event)
+	}
+	return event, ok
+}
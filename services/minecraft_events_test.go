@@ -0,0 +1,109 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLogEventParser(t *testing.T) {
+	parser := NewLogEventParser("1.20.2")
+	tests := []struct {
+		line   string
+		typ    LogEventType
+		player string
+		text   string
+	}{
+		{"[10:00:00] [Server thread/INFO]: Starting minecraft server version 1.20.2", EventServerStarting, "", "1.20.2"},
+		{`[10:00:05] [Server thread/INFO]: Done (3.210s)! For help, type "help"`, EventServerDone, "", "3.210s"},
+		{"[10:01:00] [Server thread/INFO]: Steve joined the game", EventPlayerJoined, "Steve", "Steve joined the game"},
+		{"[10:01:05] [Async Chat Thread - #0/INFO]: [Not Secure] <Steve> hello world", EventChatMessage, "Steve", "hello world"},
+		{"[10:02:00] [Server thread/INFO]: Steve has made the advancement [Stone Age]", EventAdvancement, "Steve", "Stone Age"},
+		{"[10:03:00] [Server thread/INFO]: Steve was slain by Zombie", EventDeath, "Steve", "Steve was slain by Zombie"},
+		{"[10:03:30] [Server thread/INFO]: Steve fell from a high place", EventDeath, "Steve", "Steve fell from a high place"},
+		{"[10:04:00] [Server thread/WARN]: Can't keep up! Is the server overloaded? Running 2500ms or 50 ticks behind", EventCantKeepUp, "", "2500ms"},
+		{"[10:05:00] [Server thread/INFO]: [Alex: Set the time to 1000]", EventCommandFeedback, "Alex", "Set the time to 1000"},
+		{"[10:05:10] [Server thread/INFO]: Set the weather to clear", EventCommandFeedback, "", "Set the weather to clear"},
+		{"[10:06:00] [Server thread/INFO]: Steve left the game", EventPlayerLeft, "Steve", "Steve left the game"},
+		{"[10:07:00] [Server thread/INFO]: Stopping server", EventServerStopping, "", "Stopping server"},
+		// Paper and Fabric layouts
+		{"[10:01:00 INFO]: Alex joined the game", EventPlayerJoined, "Alex", "Alex joined the game"},
+		{"[10:01:00] [Server thread/INFO] (Minecraft) <Alex> hi", EventChatMessage, "Alex", "hi"},
+	}
+	for _, tt := range tests {
+		event, ok := parser.Parse(parseServerLog(tt.line, mcp.LoggingLevelInfo))
+		if !ok || event.Type != tt.typ || event.Player != tt.player || event.Text != tt.text {
+			t.Errorf("Parse(%q) = %+v, %v", tt.line, event, ok)
+		}
+	}
+
+	for _, line := range []string{
+		"[10:00:01] [Server thread/INFO]: Preparing level \"world\"",
+		"[10:08:00] [Server thread/INFO]: Villager class_1646['Villager'/42] died, message: 'Villager was slain by Zombie'",
+		"\tat java.lang.Thread.run(Thread.java:833)",
+	} {
+		if event, ok := parser.Parse(parseServerLog(line, mcp.LoggingLevelInfo)); ok {
+			t.Errorf("Parse(%q) = %+v, should be no event", line, event)
+		}
+	}
+
+	if event, _ := parser.Parse(parseServerLog("[10:05:00] [Server thread/INFO]: Unknown command. Type \"/help\" for help.", mcp.LoggingLevelInfo)); event.Type != EventCommandFeedback || event.Success {
+		t.Errorf("failed command = %+v", event)
+	}
+
+	// Messages of older versions
+	old := NewLogEventParser("1.11.2")
+	if event, ok := old.Parse(parseServerLog("[10:02:00] [Server thread/INFO]: Steve has just earned the achievement [Taking Inventory]", mcp.LoggingLevelInfo)); !ok || event.Type != EventAdvancement || event.Text != "Taking Inventory" {
+		t.Errorf("achievement = %+v, %v", event, ok)
+	}
+	if _, ok := parser.Parse(parseServerLog("[10:02:00] [Server thread/INFO]: Steve has just earned the achievement [Taking Inventory]", mcp.LoggingLevelInfo)); ok {
+		t.Errorf("achievements should not be recognized for 1.20.2")
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	var bus eventBus
+	all, cancelAll := bus.Subscribe()
+	chat, cancelChat := bus.Subscribe(EventChatMessage)
+	bus.Publish(LogEvent{Type: EventPlayerJoined, Player: "Steve"})
+	bus.Publish(LogEvent{Type: EventChatMessage, Player: "Steve", Text: "hi"})
+	if len(all) != 2 || len(chat) != 1 {
+		t.Fatalf("events: all %d, chat %d", len(all), len(chat))
+	}
+	if event := <-chat; event.Text != "hi" {
+		t.Errorf("chat event = %+v", event)
+	}
+
+	cancelChat()
+	cancelChat()
+	if _, ok := <-chat; ok {
+		t.Errorf("channel should be closed after cancel")
+	}
+	for i := 0; i < eventBufferSize*2; i++ {
+		bus.Publish(LogEvent{Type: EventChatMessage})
+	}
+	if len(all) != eventBufferSize {
+		t.Errorf("slow subscriber buffered %d events", len(all))
+	}
+	cancelAll()
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// BuiltinFontName is the name of the built-in 5x8 ASCII font.
+	BuiltinFontName = "builtin"
+	// EmbeddedCJKFontName is the name of the GNU Unifont subset built into the binary.
+	EmbeddedCJKFontName = "unifont-cjk"
+	// DefaultCJKFont is the GNU Unifont file (in the assets directory) used instead of the
+	// embedded subset for text that the built-in font cannot render, if it exists.
+	DefaultCJKFont = "fonts/unifont.hex"
+	// maxFontFileSize is the largest font file accepted (a full Unifont .hex is about 10 MiB).
+	maxFontFileSize = 64 * 1024 * 1024
+)
+
+// bitmapGlyph is a single glyph; bit (Width-1-col) of each row is the pixel at col.
+type bitmapGlyph struct {
+	Width int
+	Rows  []uint16
+}
+
+// Pixel reports whether the pixel at col, row is set.
+func (g bitmapGlyph) Pixel(col, row int) bool {
+	return g.Rows[row]&(1<<(g.Width-1-col)) != 0
+}
+
+// BitmapFont is a fixed height bitmap font.
+type BitmapFont struct {
+	Name   string
+	Height int
+	glyphs map[rune]bitmapGlyph
+}
+
+// Glyph returns the glyph of r.
+func (f *BitmapFont) Glyph(r rune) (bitmapGlyph, bool) {
+	g, ok := f.glyphs[r]
+	return g, ok
+}
+
+// embeddedCJKFontData is a GNU Unifont subset with ASCII, Latin-1, CJK punctuation, kana,
+// the CJK Unified Ideographs block and fullwidth forms, see fonts/README.md.
+//
+//go:embed fonts/unifont_cjk.hex.gz
+var embeddedCJKFontData []byte
+
+// embeddedCJKFont parses the embedded Unifont subset on first use.
+var embeddedCJKFont = sync.OnceValues(func() (*BitmapFont, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(embeddedCJKFontData))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	return parseHexFont(EmbeddedCJKFontName, data)
+})
+
+// builtinFontColumns is the classic 5x8 LCD font for ASCII 0x20-0x7E, stored column by
+// column with bit 0 at the top; bit 7 is the descender row.
+var builtinFontColumns = [95][5]byte{
+	{0x00, 0x00, 0x00, 0x00, 0x00}, {0x00, 0x00, 0x5F, 0x00, 0x00}, {0x00, 0x07, 0x00, 0x07, 0x00}, {0x14, 0x7F, 0x14, 0x7F, 0x14}, // space ! " #
+	{0x24, 0x2A, 0x7F, 0x2A, 0x12}, {0x23, 0x13, 0x08, 0x64, 0x62}, {0x36, 0x49, 0x56, 0x20, 0x50}, {0x00, 0x08, 0x07, 0x03, 0x00}, // $ % & '
+	{0x00, 0x1C, 0x22, 0x41, 0x00}, {0x00, 0x41, 0x22, 0x1C, 0x00}, {0x2A, 0x1C, 0x7F, 0x1C, 0x2A}, {0x08, 0x08, 0x3E, 0x08, 0x08}, // ( ) * +
+	{0x00, 0x80, 0x70, 0x30, 0x00}, {0x08, 0x08, 0x08, 0x08, 0x08}, {0x00, 0x00, 0x60, 0x60, 0x00}, {0x20, 0x10, 0x08, 0x04, 0x02}, // , - . /
+	{0x3E, 0x51, 0x49, 0x45, 0x3E}, {0x00, 0x42, 0x7F, 0x40, 0x00}, {0x72, 0x49, 0x49, 0x49, 0x46}, {0x21, 0x41, 0x49, 0x4D, 0x33}, // 0 1 2 3
+	{0x18, 0x14, 0x12, 0x7F, 0x10}, {0x27, 0x45, 0x45, 0x45, 0x39}, {0x3C, 0x4A, 0x49, 0x49, 0x31}, {0x41, 0x21, 0x11, 0x09, 0x07}, // 4 5 6 7
+	{0x36, 0x49, 0x49, 0x49, 0x36}, {0x46, 0x49, 0x49, 0x29, 0x1E}, {0x00, 0x00, 0x14, 0x00, 0x00}, {0x00, 0x40, 0x34, 0x00, 0x00}, // 8 9 : ;
+	{0x00, 0x08, 0x14, 0x22, 0x41}, {0x14, 0x14, 0x14, 0x14, 0x14}, {0x00, 0x41, 0x22, 0x14, 0x08}, {0x02, 0x01, 0x59, 0x09, 0x06}, // < = > ?
+	{0x3E, 0x41, 0x5D, 0x59, 0x4E}, {0x7C, 0x12, 0x11, 0x12, 0x7C}, {0x7F, 0x49, 0x49, 0x49, 0x36}, {0x3E, 0x41, 0x41, 0x41, 0x22}, // @ A B C
+	{0x7F, 0x41, 0x41, 0x41, 0x3E}, {0x7F, 0x49, 0x49, 0x49, 0x41}, {0x7F, 0x09, 0x09, 0x09, 0x01}, {0x3E, 0x41, 0x41, 0x51, 0x73}, // D E F G
+	{0x7F, 0x08, 0x08, 0x08, 0x7F}, {0x00, 0x41, 0x7F, 0x41, 0x00}, {0x20, 0x40, 0x41, 0x3F, 0x01}, {0x7F, 0x08, 0x14, 0x22, 0x41}, // H I J K
+	{0x7F, 0x40, 0x40, 0x40, 0x40}, {0x7F, 0x02, 0x1C, 0x02, 0x7F}, {0x7F, 0x04, 0x08, 0x10, 0x7F}, {0x3E, 0x41, 0x41, 0x41, 0x3E}, // L M N O
+	{0x7F, 0x09, 0x09, 0x09, 0x06}, {0x3E, 0x41, 0x51, 0x21, 0x5E}, {0x7F, 0x09, 0x19, 0x29, 0x46}, {0x26, 0x49, 0x49, 0x49, 0x32}, // P Q R S
+	{0x03, 0x01, 0x7F, 0x01, 0x03}, {0x3F, 0x40, 0x40, 0x40, 0x3F}, {0x1F, 0x20, 0x40, 0x20, 0x1F}, {0x3F, 0x40, 0x38, 0x40, 0x3F}, // T U V W
+	{0x63, 0x14, 0x08, 0x14, 0x63}, {0x03, 0x04, 0x78, 0x04, 0x03}, {0x61, 0x59, 0x49, 0x4D, 0x43}, {0x00, 0x7F, 0x41, 0x41, 0x41}, // X Y Z [
+	{0x02, 0x04, 0x08, 0x10, 0x20}, {0x00, 0x41, 0x41, 0x41, 0x7F}, {0x04, 0x02, 0x01, 0x02, 0x04}, {0x40, 0x40, 0x40, 0x40, 0x40}, // \ ] ^ _
+	{0x00, 0x03, 0x07, 0x08, 0x00}, {0x20, 0x54, 0x54, 0x78, 0x40}, {0x7F, 0x28, 0x44, 0x44, 0x38}, {0x38, 0x44, 0x44, 0x44, 0x28}, // ` a b c
+	{0x38, 0x44, 0x44, 0x28, 0x7F}, {0x38, 0x54, 0x54, 0x54, 0x18}, {0x00, 0x08, 0x7E, 0x09, 0x02}, {0x18, 0xA4, 0xA4, 0x9C, 0x78}, // d e f g
+	{0x7F, 0x08, 0x04, 0x04, 0x78}, {0x00, 0x44, 0x7D, 0x40, 0x00}, {0x20, 0x40, 0x40, 0x3D, 0x00}, {0x7F, 0x10, 0x28, 0x44, 0x00}, // h i j k
+	{0x00, 0x41, 0x7F, 0x40, 0x00}, {0x7C, 0x04, 0x78, 0x04, 0x78}, {0x7C, 0x08, 0x04, 0x04, 0x78}, {0x38, 0x44, 0x44, 0x44, 0x38}, // l m n o
+	{0xFC, 0x18, 0x24, 0x24, 0x18}, {0x18, 0x24, 0x24, 0x18, 0xFC}, {0x7C, 0x08, 0x04, 0x04, 0x08}, {0x48, 0x54, 0x54, 0x54, 0x24}, // p q r s
+	{0x04, 0x04, 0x3F, 0x44, 0x24}, {0x3C, 0x40, 0x40, 0x20, 0x7C}, {0x1C, 0x20, 0x40, 0x20, 0x1C}, {0x3C, 0x40, 0x30, 0x40, 0x3C}, // t u v w
+	{0x44, 0x28, 0x10, 0x28, 0x44}, {0x4C, 0x90, 0x90, 0x90, 0x7C}, {0x44, 0x64, 0x54, 0x4C, 0x44}, {0x00, 0x08, 0x36, 0x41, 0x00}, // x y z {
+	{0x00, 0x00, 0x77, 0x00, 0x00}, {0x00, 0x41, 0x36, 0x08, 0x00}, {0x02, 0x01, 0x02, 0x04, 0x02}, // | } ~
+}
+
+// builtinFont is the built-in ASCII font.
+var builtinFont = newBuiltinFont()
+
+func newBuiltinFont() *BitmapFont {
+	f := &BitmapFont{Name: BuiltinFontName, Height: 8, glyphs: make(map[rune]bitmapGlyph, len(builtinFontColumns))}
+	for i, cols := range builtinFontColumns {
+		g := bitmapGlyph{Width: 5, Rows: make([]uint16, 8)}
+		for c, bits := range cols {
+			for r := 0; r < 8; r++ {
+				if bits&(1<<r) != 0 {
+					g.Rows[r] |= 1 << (4 - c)
+				}
+			}
+		}
+		f.glyphs[rune(0x20+i)] = g
+	}
+	return f
+}
+
+// parseHexFont parses a font in the GNU Unifont .hex format ("4E2D:0100...", 8x16 or 16x16 glyphs).
+func parseHexFont(name string, data []byte) (*BitmapFont, error) {
+	f := &BitmapFont{Name: name, Height: 16, glyphs: make(map[rune]bitmapGlyph)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		code, bitmap, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: missing ':'", name, line)
+		}
+		r, err := strconv.ParseUint(code, 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid code point %q", name, line, code)
+		}
+		var g bitmapGlyph
+		switch len(bitmap) {
+		case 32:
+			g.Width = 8
+		case 64:
+			g.Width = 16
+		default:
+			return nil, fmt.Errorf("%s line %d: unsupported glyph size", name, line)
+		}
+		digits := g.Width / 4
+		g.Rows = make([]uint16, 16)
+		for row := range g.Rows {
+			v, err := strconv.ParseUint(bitmap[row*digits:(row+1)*digits], 16, 16)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: invalid bitmap", name, line)
+			}
+			g.Rows[row] = uint16(v)
+		}
+		f.glyphs[rune(r)] = g
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(f.glyphs) == 0 {
+		return nil, fmt.Errorf("%s contains no glyphs", name)
+	}
+	return f, nil
+}
+
+// loadFont returns the built-in font, the embedded Unifont subset or a .hex font from
+// the assets directory. Parsed fonts are cached, a full Unifont takes a moment to parse.
+func (ms *MinecraftServer) loadFont(name string) (*BitmapFont, error) {
+	if name == "" || name == BuiltinFontName {
+		return builtinFont, nil
+	}
+	if name == EmbeddedCJKFontName {
+		return embeddedCJKFont()
+	}
+	abs, err := ms.resolveAssetPath(name)
+	if err != nil {
+		return nil, err
+	}
+	ms.fontMu.Lock()
+	defer ms.fontMu.Unlock()
+	if f, ok := ms.fonts[abs]; ok {
+		return f, nil
+	}
+	data, err := ms.readAssetFile(abs, maxFontFileSize)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseHexFont(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if ms.fonts == nil {
+		ms.fonts = make(map[string]*BitmapFont)
+	}
+	ms.fonts[abs] = f
+	return f, nil
+}
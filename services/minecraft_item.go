@@ -0,0 +1,538 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var (
+	// resourceIDRegex matches a namespaced ID like "minecraft:diamond_sword".
+	resourceIDRegex = regexp.MustCompile(`^[a-z0-9_.-]+:[a-z0-9_./-]+$`)
+	// itemSlotRegex matches a slot of /item replace like "armor.head" or "hotbar.0".
+	itemSlotRegex  = regexp.MustCompile(`^[a-z]+(\.[a-z0-9_]+)?$`)
+	attributeRegex = regexp.MustCompile(`^[a-z_]+$`)
+
+	// itemSlots are the short names of the equipment slots of /item replace.
+	itemSlots = map[string]string{
+		"head": "armor.head", "chest": "armor.chest", "legs": "armor.legs", "feet": "armor.feet",
+		"body": "armor.body", "mainhand": "weapon.mainhand", "offhand": "weapon.offhand",
+	}
+	// modifierOperations are the names of the attribute modifier operations, by
+	// their number in the NBT of older versions.
+	modifierOperations = []string{"add_value", "add_multiplied_base", "add_multiplied_total"}
+	// modifierOperationAliases are the other names accepted for the operations.
+	modifierOperationAliases = map[string]int{
+		"add": 0, "add_number": 0, "add_value": 0,
+		"multiply_base": 1, "add_multiplied_base": 1,
+		"multiply": 2, "multiply_total": 2, "add_multiplied_total": 2,
+	}
+	// modifierSlots are the equipment slots of attribute modifiers, "any" for all of
+	// them. The last three need 1.20.5.
+	modifierSlots = []string{"mainhand", "offhand", "head", "chest", "legs", "feet", "any", "hand", "armor", "body"}
+	// playerAttributes had the "player." prefix from 1.20.5 until 1.21.2.
+	playerAttributes = []string{"block_break_speed", "block_interaction_range", "entity_interaction_range",
+		"mining_efficiency", "sneaking_speed", "submerged_mining_speed", "sweeping_damage_ratio"}
+)
+
+// ItemEnchantment is an enchantment of an item stack.
+type ItemEnchantment struct {
+	ID    string `json:"id"`
+	Level int    `json:"level"`
+}
+
+// ItemAttribute is an attribute modifier of an item stack.
+type ItemAttribute struct {
+	Attribute string  `json:"attribute"` // name without namespace and prefix, e.g. "attack_damage"
+	Amount    float64 `json:"amount"`
+	Operation int     `json:"operation"` // index in modifierOperations
+	Slot      string  `json:"slot"`      // equipment slot, "any" for all
+}
+
+// ItemStack is an item with a custom name, lore, enchantments and attribute modifiers.
+// Format writes it as data components since 1.20.5 and as NBT before.
+type ItemStack struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name,omitempty"` // text markup
+	Lore         []string          `json:"lore,omitempty"` // text markup, one line each
+	Enchantments []ItemEnchantment `json:"enchantments,omitempty"`
+	Attributes   []ItemAttribute   `json:"attributes,omitempty"`
+	Unbreakable  bool              `json:"unbreakable,omitempty"`
+	Data         string            `json:"data,omitempty"` // more components or NBT tags, written as is
+}
+
+// namespacedID adds the "minecraft:" namespace to an ID without one.
+func namespacedID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id != "" && !strings.Contains(id, ":") {
+		id = "minecraft:" + id
+	}
+	return id
+}
+
+// equipmentSlot returns the equipment slot an item is worn in, or "mainhand".
+func equipmentSlot(item string) string {
+	name := strings.TrimPrefix(namespacedID(item), "minecraft:")
+	switch {
+	case strings.HasSuffix(name, "_helmet") || strings.HasSuffix(name, "_head") ||
+		strings.HasSuffix(name, "_skull") || name == "carved_pumpkin":
+		return "head"
+	case strings.HasSuffix(name, "_chestplate") || name == "elytra":
+		return "chest"
+	case strings.HasSuffix(name, "_leggings"):
+		return "legs"
+	case strings.HasSuffix(name, "_boots"):
+		return "feet"
+	case name == "shield" || name == "totem_of_undying":
+		return "offhand"
+	case strings.HasSuffix(name, "_horse_armor") || name == "wolf_armor":
+		return "body"
+	}
+	return "mainhand"
+}
+
+// parseEnchantments parses a list like "sharpness=5;unbreaking=3". The level
+// defaults to 1.
+func parseEnchantments(s string) ([]ItemEnchantment, error) {
+	var enchantments []ItemEnchantment
+	for _, item := range strings.Split(s, ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		id, level, hasLevel := strings.Cut(item, "=")
+		e := ItemEnchantment{ID: namespacedID(id), Level: 1}
+		if hasLevel {
+			n, err := strconv.Atoi(strings.TrimSpace(level))
+			if err != nil {
+				return nil, fmt.Errorf("invalid enchantment level in %q", item)
+			}
+			e.Level = n
+		}
+		if !resourceIDRegex.MatchString(e.ID) {
+			return nil, fmt.Errorf("invalid enchantment %q", id)
+		}
+		if e.Level < 1 || e.Level > 255 {
+			return nil, fmt.Errorf("enchantment level of %s must be between 1 and 255", e.ID)
+		}
+		enchantments = append(enchantments, e)
+	}
+	return enchantments, nil
+}
+
+// attributeBase returns the attribute name without namespace and prefix in snake
+// case, so "generic.attackDamage" and "minecraft:generic.attack_damage" are both
+// "attack_damage".
+func attributeBase(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "minecraft:")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	var sb strings.Builder
+	for _, c := range name {
+		if c >= 'A' && c <= 'Z' {
+			sb.WriteByte('_')
+			c += 'a' - 'A'
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// parseItemAttributes parses a list of attribute modifiers like
+// "attack_damage=5;movement_speed=0.1,multiply_base,feet". The operation defaults
+// to add_value and the slot to the one the item is worn in.
+func parseItemAttributes(s, item string) ([]ItemAttribute, error) {
+	var attributes []ItemAttribute
+	for _, entry := range strings.Split(s, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ",")
+		name, amount, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid attribute modifier %q (expected attribute=amount)", entry)
+		}
+		a := ItemAttribute{Attribute: attributeBase(name), Slot: equipmentSlot(item)}
+		if !attributeRegex.MatchString(a.Attribute) {
+			return nil, fmt.Errorf("invalid attribute %q", name)
+		}
+		var err error
+		if a.Amount, err = strconv.ParseFloat(strings.TrimSpace(amount), 64); err != nil {
+			return nil, fmt.Errorf("invalid amount in attribute modifier %q", entry)
+		}
+		for _, field := range fields[1:] {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if op, ok := modifierOperationAliases[field]; ok {
+				a.Operation = op
+			} else if slices.Contains(modifierSlots, field) {
+				a.Slot = field
+			} else {
+				return nil, fmt.Errorf("unknown operation or slot %q in attribute modifier %q", field, entry)
+			}
+		}
+		attributes = append(attributes, a)
+	}
+	return attributes, nil
+}
+
+// attributeName returns the ID of an attribute in the configured game version.
+func attributeName(mc *MinecraftConfig, base string) string {
+	if mc.VersionAtLeast("1.21.2") {
+		return "minecraft:" + base
+	}
+	prefix := "generic."
+	switch {
+	case base == "spawn_reinforcements":
+		prefix = "zombie."
+	case base == "jump_strength" && !mc.VersionAtLeast("1.20.5"):
+		prefix = "horse."
+	case slices.Contains(playerAttributes, base) && mc.VersionAtLeast("1.20.5"):
+		prefix = "player."
+	}
+	if mc.VersionAtLeast("1.16") {
+		return "minecraft:" + prefix + base
+	}
+	// Attributes were in camel case before 1.16
+	parts := strings.Split(base, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return prefix + strings.Join(parts, "")
+}
+
+// modifierUUID returns a UUID for an attribute modifier that stays the same when
+// the item is given again, as four integers.
+func modifierUUID(item string, index int, a ItemAttribute) [4]int32 {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s/%d/%s/%s", item, index, a.Attribute, a.Slot)))
+	var uuid [4]int32
+	for i := range uuid {
+		uuid[i] = int32(binary.BigEndian.Uint32(sum[i*4:]))
+	}
+	return uuid
+}
+
+// snbtQuote quotes a string for SNBT with single quotes, which keeps the double
+// quotes of JSON readable.
+func snbtQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// snbtDouble formats a number as an SNBT double.
+func snbtDouble(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + "d"
+}
+
+// itemText returns the text component of a name or lore line. Item texts are
+// italic by default, so the text is only italic with <i>.
+func itemText(markup string, format textFormat) (string, error) {
+	components, err := ParseMarkup(markup, format)
+	if err != nil {
+		return "", err
+	}
+	return textJSON(map[string]interface{}{"text": "", "italic": false, "extra": components[1:]})
+}
+
+// Format returns the item argument of /give and /item, e.g.
+// minecraft:diamond_sword[enchantments={levels:{"minecraft:sharpness":5}}] since
+// 1.20.5 or minecraft:diamond_sword{Enchantments:[{id:"minecraft:sharpness",lvl:5s}]}
+// before.
+func (s ItemStack) Format(mc *MinecraftConfig) (string, error) {
+	id := strings.TrimSpace(s.ID)
+	// A line break would end the command and start another one
+	if strings.ContainsAny(id, "\r\n") || strings.ContainsAny(s.Data, "\r\n") {
+		return "", fmt.Errorf("item and data must not contain line breaks")
+	}
+	if i := strings.IndexAny(id, "[{"); i >= 0 {
+		if s.Name != "" || len(s.Lore) > 0 || len(s.Enchantments) > 0 || len(s.Attributes) > 0 || s.Unbreakable {
+			return "", fmt.Errorf("item %q already has components or NBT, put them in data instead", id)
+		}
+		prefix := namespacedID(id[:i])
+		if !resourceIDRegex.MatchString(prefix) {
+			return "", fmt.Errorf("invalid item ID %q", s.ID)
+		}
+		return prefix + id[i:], nil
+	}
+	id = namespacedID(id)
+	if !resourceIDRegex.MatchString(id) {
+		return "", fmt.Errorf("invalid item ID %q", s.ID)
+	}
+	if s.Name == "" && len(s.Lore) == 0 && len(s.Enchantments) == 0 && len(s.Attributes) == 0 && !s.Unbreakable && s.Data == "" {
+		return id, nil
+	}
+	if !mc.VersionAtLeast("1.13") {
+		return "", fmt.Errorf("item names, lore, enchantments and attributes need Minecraft 1.13 or newer")
+	}
+	if len(s.Lore) > 0 && !mc.VersionAtLeast("1.14") {
+		return "", fmt.Errorf("lore needs Minecraft 1.14 or newer")
+	}
+
+	format := newTextFormat(mc)
+	name := ""
+	if s.Name != "" {
+		var err error
+		if name, err = itemText(s.Name, format); err != nil {
+			return "", fmt.Errorf("name: %w", err)
+		}
+	}
+	lore := make([]string, len(s.Lore))
+	for i, line := range s.Lore {
+		var err error
+		if lore[i], err = itemText(line, format); err != nil {
+			return "", fmt.Errorf("lore line %d: %w", i+1, err)
+		}
+	}
+	for _, a := range s.Attributes {
+		if slices.Index(modifierSlots, a.Slot) > 6 && !mc.VersionAtLeast("1.20.5") {
+			return "", fmt.Errorf("attribute slot %s needs Minecraft 1.20.5 or newer", a.Slot)
+		}
+	}
+	if mc.VersionAtLeast("1.20.5") {
+		return id + "[" + strings.Join(s.components(mc, name, lore), ",") + "]", nil
+	}
+	return id + "{" + strings.Join(s.nbt(mc, name, lore), ",") + "}", nil
+}
+
+// components returns the data components of the item, for 1.20.5 and newer. Since
+// 1.21.5 texts are written as SNBT instead of JSON strings, and enchantments and
+// attribute modifiers are no longer wrapped.
+func (s ItemStack) components(mc *MinecraftConfig, name string, lore []string) []string {
+	inline := mc.VersionAtLeast("1.21.5")
+	text := func(json string) string {
+		if inline {
+			return json
+		}
+		return snbtQuote(json)
+	}
+	var parts []string
+	if name != "" {
+		parts = append(parts, "custom_name="+text(name))
+	}
+	if len(lore) > 0 {
+		lines := make([]string, len(lore))
+		for i, line := range lore {
+			lines[i] = text(line)
+		}
+		parts = append(parts, "lore=["+strings.Join(lines, ",")+"]")
+	}
+	if len(s.Enchantments) > 0 {
+		levels := make([]string, len(s.Enchantments))
+		for i, e := range s.Enchantments {
+			levels[i] = fmt.Sprintf("%q:%d", e.ID, e.Level)
+		}
+		value := "{" + strings.Join(levels, ",") + "}"
+		if !inline {
+			value = "{levels:" + value + "}"
+		}
+		parts = append(parts, s.enchantmentKey("stored_enchantments", "enchantments")+"="+value)
+	}
+	if len(s.Attributes) > 0 {
+		modifiers := make([]string, len(s.Attributes))
+		for i, a := range s.Attributes {
+			m := fmt.Sprintf("type:%q,amount:%s,operation:%q,slot:%q",
+				attributeName(mc, a.Attribute), snbtDouble(a.Amount), modifierOperations[a.Operation], a.Slot)
+			if mc.VersionAtLeast("1.21") {
+				m += fmt.Sprintf(",id:\"moling:%s_%d\"", a.Attribute, i)
+			} else {
+				u := modifierUUID(s.ID, i, a)
+				m += fmt.Sprintf(",uuid:[I;%d,%d,%d,%d],name:\"moling\"", u[0], u[1], u[2], u[3])
+			}
+			modifiers[i] = "{" + m + "}"
+		}
+		value := "[" + strings.Join(modifiers, ",") + "]"
+		if !inline {
+			value = "{modifiers:" + value + "}"
+		}
+		parts = append(parts, "attribute_modifiers="+value)
+	}
+	if s.Unbreakable {
+		parts = append(parts, "unbreakable={}")
+	}
+	if s.Data != "" {
+		parts = append(parts, s.Data)
+	}
+	return parts
+}
+
+// nbt returns the NBT tags of the item, for versions before 1.20.5.
+func (s ItemStack) nbt(mc *MinecraftConfig, name string, lore []string) []string {
+	var parts, display []string
+	if name != "" {
+		display = append(display, "Name:"+snbtQuote(name))
+	}
+	if len(lore) > 0 {
+		lines := make([]string, len(lore))
+		for i, line := range lore {
+			lines[i] = snbtQuote(line)
+		}
+		display = append(display, "Lore:["+strings.Join(lines, ",")+"]")
+	}
+	if len(display) > 0 {
+		parts = append(parts, "display:{"+strings.Join(display, ",")+"}")
+	}
+	if len(s.Enchantments) > 0 {
+		enchantments := make([]string, len(s.Enchantments))
+		for i, e := range s.Enchantments {
+			enchantments[i] = fmt.Sprintf("{id:%q,lvl:%ds}", e.ID, e.Level)
+		}
+		parts = append(parts, s.enchantmentKey("StoredEnchantments", "Enchantments")+":["+strings.Join(enchantments, ",")+"]")
+	}
+	if len(s.Attributes) > 0 {
+		modifiers := make([]string, len(s.Attributes))
+		for i, a := range s.Attributes {
+			m := fmt.Sprintf("AttributeName:%q,Name:\"moling\",Amount:%s,Operation:%d",
+				attributeName(mc, a.Attribute), snbtDouble(a.Amount), a.Operation)
+			u := modifierUUID(s.ID, i, a)
+			if mc.VersionAtLeast("1.16") {
+				m += fmt.Sprintf(",UUID:[I;%d,%d,%d,%d]", u[0], u[1], u[2], u[3])
+			} else {
+				most := int64(uint64(uint32(u[0]))<<32 | uint64(uint32(u[1])))
+				least := int64(uint64(uint32(u[2]))<<32 | uint64(uint32(u[3])))
+				m += fmt.Sprintf(",UUIDMost:%dL,UUIDLeast:%dL", most, least)
+			}
+			if a.Slot != "any" {
+				m += fmt.Sprintf(",Slot:%q", a.Slot)
+			}
+			modifiers[i] = "{" + m + "}"
+		}
+		parts = append(parts, "AttributeModifiers:["+strings.Join(modifiers, ",")+"]")
+	}
+	if s.Unbreakable {
+		parts = append(parts, "Unbreakable:1b")
+	}
+	if s.Data != "" {
+		parts = append(parts, s.Data)
+	}
+	return parts
+}
+
+// enchantmentKey returns book for enchanted books, which store their enchantments,
+// and item otherwise.
+func (s ItemStack) enchantmentKey(book, item string) string {
+	if namespacedID(s.ID) == "minecraft:enchanted_book" {
+		return book
+	}
+	return item
+}
+
+// itemStackFromArgs reads the item builder arguments of a tool.
+func itemStackFromArgs(args map[string]interface{}) (ItemStack, error) {
+	var s ItemStack
+	var err error
+	if s.ID, err = getStringArg(args, "item", true); err != nil {
+		return s, err
+	}
+	s.Name, _ = getStringArg(args, "name", false)
+	if lore, _ := getStringArg(args, "lore", false); lore != "" {
+		s.Lore = strings.Split(strings.TrimRight(lore, "\n"), "\n")
+	}
+	enchantments, _ := getStringArg(args, "enchantments", false)
+	if s.Enchantments, err = parseEnchantments(enchantments); err != nil {
+		return s, err
+	}
+	attributes, _ := getStringArg(args, "attributes", false)
+	if s.Attributes, err = parseItemAttributes(attributes, s.ID); err != nil {
+		return s, err
+	}
+	s.Unbreakable, _ = getBoolArg(args, "unbreakable", false)
+	s.Data, _ = getStringArg(args, "data", false)
+	s.Data = strings.TrimSpace(s.Data)
+	return s, nil
+}
+
+// itemStackOptions are the item builder arguments shared by minecraft_give and
+// minecraft_item_replace.
+func itemStackOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("item", mcp.Description("Item ID (e.g., minecraft:diamond_sword)"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Custom name in the text markup of minecraft_tellraw, e.g. <gold><b>Excalibur (optional)")),
+		mcp.WithString("lore", mcp.Description("Lore lines in text markup, separated by newlines (optional)")),
+		mcp.WithString("enchantments", mcp.Description("Enchantments as id=level separated by ';', e.g. sharpness=5;unbreaking=3 (optional)")),
+		mcp.WithString("attributes", mcp.Description("Attribute modifiers as attribute=amount[,operation][,slot] separated by ';', e.g. attack_damage=8;movement_speed=0.1,add_multiplied_base,feet. Operations: add_value (default), add_multiplied_base, add_multiplied_total; slots: mainhand, offhand, head, chest, legs, feet, and since 1.20.5 any, hand, armor, body (default: where the item is worn) (optional)")),
+		mcp.WithBoolean("unbreakable", mcp.Description("Make the item unbreakable (optional, default: false)")),
+		mcp.WithString("data", mcp.Description("More data components (1.20.5+, e.g. max_stack_size=1) or NBT tags (older versions) added as is (optional)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the command, without sending it (optional, default: false)")),
+	}
+}
+
+// registerItemTools registers the /item replace tool. minecraft_give shares its
+// item builder arguments.
+func (ms *MinecraftServer) registerItemTools() {
+	options := []mcp.ToolOption{
+		mcp.WithDescription("Put an item into a slot of entities or players, e.g. to equip mobs with armor and weapons. Uses /item replace since 1.17 and /replaceitem before"),
+		mcp.WithString("target", mcp.Description("Entity selector, player name or UUID (e.g., @e[type=zombie,distance=..10])"), mcp.Required()),
+		mcp.WithString("slot", mcp.Description("Slot like armor.head, weapon.mainhand, hotbar.0, inventory.5 or horse.saddle; head, chest, legs, feet, body, mainhand and offhand are short for the equipment slots (optional, default: where the item is worn)")),
+		mcp.WithNumber("count", mcp.Description("Number of items (optional, default: 1)")),
+	}
+	ms.AddTool(mcp.NewTool("minecraft_item_replace", append(options, itemStackOptions()...)...), ms.handleItemReplace)
+}
+
+// handleItemReplace implements the minecraft_item_replace tool.
+func (ms *MinecraftServer) handleItemReplace(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	target, err := getStringArg(args, "target", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if target, err = checkTarget(target); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	stack, err := itemStackFromArgs(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	item, err := stack.Format(ms.config)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	slot, _ := getStringArg(args, "slot", false)
+	if slot = strings.ToLower(strings.TrimSpace(slot)); slot == "" {
+		slot = equipmentSlot(stack.ID)
+	}
+	if s, ok := itemSlots[slot]; ok {
+		slot = s
+	}
+	if !itemSlotRegex.MatchString(slot) {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid slot %q", slot)), nil
+	}
+	count, err := getIntArg(args, "count", 1)
+	if err != nil || count < 1 || count > 99 {
+		return mcp.NewToolResultError("count must be between 1 and 99"), nil
+	}
+
+	command := fmt.Sprintf("/item replace entity %s %s with %s", target, slot, item)
+	if !ms.config.VersionAtLeast("1.17") {
+		command = fmt.Sprintf("/replaceitem entity %s %s %s", target, slot, item)
+	}
+	if count > 1 {
+		command += " " + strconv.Itoa(count)
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	return ms.sendCommands([]string{command}, dryRun)
+}
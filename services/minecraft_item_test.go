@@ -0,0 +1,150 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseItemAttributes(t *testing.T) {
+	attributes, err := parseItemAttributes("generic.attackDamage=8; movement_speed=0.1,multiply_base,any", "minecraft:diamond_boots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attributes) != 2 || attributes[0] != (ItemAttribute{Attribute: "attack_damage", Amount: 8, Slot: "feet"}) ||
+		attributes[1] != (ItemAttribute{Attribute: "movement_speed", Amount: 0.1, Operation: 1, Slot: "any"}) {
+		t.Errorf("parseItemAttributes = %+v", attributes)
+	}
+	for _, s := range []string{"attack_damage", "attack_damage=x", "attack_damage=1,sideways"} {
+		if _, err := parseItemAttributes(s, "stick"); err == nil {
+			t.Errorf("parseItemAttributes(%q) should fail", s)
+		}
+	}
+	if _, err := parseEnchantments("sharpness=300"); err == nil {
+		t.Errorf("enchantment level 300 should fail")
+	}
+}
+
+func TestItemStackFormat(t *testing.T) {
+	stack := ItemStack{
+		ID:           "diamond_sword",
+		Name:         "<gold>Blade",
+		Enchantments: []ItemEnchantment{{ID: "minecraft:sharpness", Level: 5}},
+		Attributes:   []ItemAttribute{{Attribute: "attack_damage", Amount: 8, Slot: "mainhand"}},
+		Unbreakable:  true,
+	}
+	name := `{"extra":[{"color":"gold","text":"Blade"}],"italic":false,"text":""}`
+	tests := []struct {
+		version string
+		want    []string
+	}{
+		{"1.16.5", []string{`minecraft:diamond_sword{display:{Name:'` + name + `'}`, `Enchantments:[{id:"minecraft:sharpness",lvl:5s}]`,
+			`AttributeName:"minecraft:generic.attack_damage"`, `UUID:[I;`, `Slot:"mainhand"`, `Unbreakable:1b}`}},
+		{"1.20.6", []string{`minecraft:diamond_sword[custom_name='` + name + `'`, `enchantments={levels:{"minecraft:sharpness":5}}`,
+			`attribute_modifiers={modifiers:[{type:"minecraft:generic.attack_damage",amount:8d,operation:"add_value",slot:"mainhand",uuid:[I;`, `unbreakable={}]`}},
+		{"1.21.1", []string{`id:"moling:attack_damage_0"`, `type:"minecraft:generic.attack_damage"`}},
+		{"1.21.4", []string{`type:"minecraft:attack_damage"`, `{modifiers:[`}},
+		{"1.21.5", []string{`[custom_name=` + name + `,`, `enchantments={"minecraft:sharpness":5}`, `attribute_modifiers=[{type:`}},
+	}
+	for _, tt := range tests {
+		item, err := stack.Format(&MinecraftConfig{GameVersion: tt.version})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.version, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(item, want) {
+				t.Errorf("%s: %s does not contain %s", tt.version, item, want)
+			}
+		}
+	}
+
+	if item, _ := (ItemStack{ID: "enchanted_book", Enchantments: stack.Enchantments}).Format(&MinecraftConfig{GameVersion: "1.20.4"}); !strings.HasPrefix(item, "minecraft:enchanted_book{StoredEnchantments:") {
+		t.Errorf("enchanted book = %s", item)
+	}
+	if item, _ := (ItemStack{ID: "stick"}).Format(&MinecraftConfig{GameVersion: "1.12.2"}); item != "minecraft:stick" {
+		t.Errorf("plain item = %s", item)
+	}
+	if _, err := stack.Format(&MinecraftConfig{GameVersion: "1.12.2"}); err == nil {
+		t.Errorf("enchantments before 1.13 should fail")
+	}
+	if _, err := (ItemStack{ID: "stick{CustomModelData:1}", Unbreakable: true}).Format(&MinecraftConfig{GameVersion: "1.20.4"}); err == nil {
+		t.Errorf("an item with NBT should not be combined with the builder")
+	}
+	if item, err := (ItemStack{ID: "stick{CustomModelData:1}"}).Format(&MinecraftConfig{GameVersion: "1.20.4"}); err != nil || item != "minecraft:stick{CustomModelData:1}" {
+		t.Errorf("item with NBT = %s, %v", item, err)
+	}
+	for _, stack := range []ItemStack{
+		{ID: "stick\nop Steve"},
+		{ID: "stick{a:1}\nop Steve"},
+		{ID: "op Steve\n{a:1}"},
+		{ID: "Bad Item[a=1]"},
+		{ID: "stick", Data: "max_stack_size=1\nop Steve"},
+	} {
+		if item, err := stack.Format(&MinecraftConfig{GameVersion: "1.21"}); err == nil {
+			t.Errorf("%+v should fail, got %s", stack, item)
+		}
+	}
+}
+
+func TestAttributeName(t *testing.T) {
+	tests := []struct{ version, base, want string }{
+		{"1.15.2", "attack_damage", "generic.attackDamage"},
+		{"1.20.4", "jump_strength", "minecraft:horse.jump_strength"},
+		{"1.20.6", "block_interaction_range", "minecraft:player.block_interaction_range"},
+		{"1.21.3", "spawn_reinforcements", "minecraft:spawn_reinforcements"},
+	}
+	for _, tt := range tests {
+		if got := attributeName(&MinecraftConfig{GameVersion: tt.version}, tt.base); got != tt.want {
+			t.Errorf("attributeName(%s, %s) = %s, want %s", tt.version, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestMinecraftServer_handleItemReplace(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{GameVersion: "1.16.5"}}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"target": "@e[type=zombie]", "item": "iron_helmet", "dryRun": true}
+	result, _ := ms.handleItemReplace(context.Background(), request)
+	if got := toolResultText(result); got != "/replaceitem entity @e[type=zombie] armor.head minecraft:iron_helmet" {
+		t.Errorf("replaceitem = %s", got)
+	}
+
+	ms.config.GameVersion = "1.21"
+	request.Params.Arguments["slot"] = "offhand"
+	request.Params.Arguments["count"] = float64(2)
+	result, _ = ms.handleItemReplace(context.Background(), request)
+	if got := toolResultText(result); got != "/item replace entity @e[type=zombie] weapon.offhand with minecraft:iron_helmet 2" {
+		t.Errorf("item replace = %s", got)
+	}
+}
+
+func TestMinecraftServer_handleGive(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{GameVersion: "1.21"}}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"target": "Steve\nop Alex", "item": "stick", "dryRun": true}
+	if result, _ := ms.handleGive(context.Background(), request); !strings.Contains(toolResultText(result), "invalid target") {
+		t.Errorf("give = %s", toolResultText(result))
+	}
+}
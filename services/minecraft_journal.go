@@ -0,0 +1,249 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// JournalDirName is the directory under BasePath/data where session journals are stored.
+	JournalDirName = "sessions"
+	// journalFileExt is the file extension of a session journal (one JSON entry per line).
+	journalFileExt = ".jsonl"
+	// localSessionID is used when a tool is called without an MCP client session (e.g. replay).
+	localSessionID = "local"
+)
+
+var journalNameRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// JournalEntry is a single recorded tool call of an MCP session.
+type JournalEntry struct {
+	Seq       int                    `json:"seq"`       // Sequence number inside the session, starting at 1
+	Time      time.Time              `json:"time"`      // Time the tool was called
+	Tool      string                 `json:"tool"`      // Name of the MCP tool
+	Arguments map[string]interface{} `json:"arguments"` // Arguments the tool was called with
+	Result    string                 `json:"result"`    // Text result returned to the client
+	IsError   bool                   `json:"is_error"`  // Whether the tool returned an error result
+}
+
+// SessionJournal records the tool calls of every MCP session to BasePath/data/sessions.
+type SessionJournal struct {
+	dir     string
+	prefix  string // start time of the service, keeps journals of different runs apart
+	mu      sync.Mutex
+	seqs    map[string]int
+	enabled bool
+}
+
+// NewSessionJournal creates a journal writing into dir. An empty dir disables recording.
+func NewSessionJournal(dir string) *SessionJournal {
+	return &SessionJournal{
+		dir:     dir,
+		prefix:  time.Now().Format("20060102-150405"),
+		seqs:    make(map[string]int),
+		enabled: dir != "",
+	}
+}
+
+// sessionName returns the journal name of the MCP session found in ctx.
+func (sj *SessionJournal) sessionName(ctx context.Context) string {
+	sessionID := localSessionID
+	if session := server.ClientSessionFromContext(ctx); session != nil && session.SessionID() != "" {
+		sessionID = session.SessionID()
+	}
+	return sj.prefix + "_" + journalNameRegex.ReplaceAllString(sessionID, "_")
+}
+
+// Record appends a tool call to the journal of the session in ctx.
+func (sj *SessionJournal) Record(ctx context.Context, tool string, args map[string]interface{}, result *mcp.CallToolResult) error {
+	if sj == nil || !sj.enabled {
+		return nil
+	}
+	name := sj.sessionName(ctx)
+
+	sj.mu.Lock()
+	defer sj.mu.Unlock()
+	if err := os.MkdirAll(sj.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create journal directory %s: %w", sj.dir, err)
+	}
+	sj.seqs[name]++
+	entry := JournalEntry{
+		Seq:       sj.seqs[name],
+		Time:      time.Now(),
+		Tool:      tool,
+		Arguments: args,
+	}
+	if result != nil {
+		entry.IsError = result.IsError
+		entry.Result = toolResultText(result)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(sj.dir, name+journalFileExt), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal file: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// ListJournals returns the names of the recorded sessions in dir, newest first.
+func ListJournals(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), journalFileExt) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(f.Name(), journalFileExt))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// LoadJournal reads all entries of the recorded session name in dir.
+func LoadJournal(dir, name string) ([]JournalEntry, error) {
+	f, err := os.Open(filepath.Join(dir, strings.TrimSuffix(name, journalFileExt)+journalFileExt))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// A decoder has no line length limit, entries may hold whole images (imageBase64)
+	var entries []JournalEntry
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var entry JournalEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid journal entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// toolResultText joins the text contents of a tool result.
+func toolResultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, c := range result.Content {
+		if tc, ok := c.(mcp.TextContent); ok {
+			texts = append(texts, tc.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// AddTool registers a tool like MLService.AddTool, but records every call of a
+// non read-only tool to the session journal so it can be replayed later.
+func (ms *MinecraftServer) AddTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if tool.Annotations.ReadOnlyHint {
+		ms.MLService.AddTool(tool, handler)
+		return
+	}
+	ms.MLService.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := handler(ctx, request)
+		if err == nil {
+			if jErr := ms.journal.Record(ctx, tool.Name, ms.journalArgs(request.Params.Arguments), result); jErr != nil {
+				ms.logger.Warn().Err(jErr).Str("tool", tool.Name).Msg("Failed to record tool call to session journal")
+			}
+		}
+		return result, err
+	})
+}
+
+// journalArgs returns a copy of args with waypoint references in coordinate arguments
+// resolved, so a replay does not depend on waypoints that may change later. Secret
+// server properties such as rcon.password are redacted, the journal is plain text.
+func (ms *MinecraftServer) journalArgs(args map[string]interface{}) map[string]interface{} {
+	var keys []string
+	seen := make(map[string]bool)
+	for k := range args {
+		m := coordKeyRegex.FindStringSubmatch(k)
+		if m == nil || seen[k[1:]] {
+			continue
+		}
+		seen[k[1:]] = true
+		keys = append(keys, "x"+k[1:], "y"+k[1:], "z"+k[1:])
+	}
+	recorded := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		recorded[k] = v
+	}
+	if set, ok := args["set"].(map[string]interface{}); ok {
+		redacted := make(map[string]interface{}, len(set))
+		for k, v := range set {
+			redacted[k] = v
+			if serverPropertySpecs[k].Secret {
+				redacted[k] = redactedProperty
+			}
+		}
+		recorded["set"] = redacted
+	}
+	resolved, err := ms.resolveCoordRefs(args, keys)
+	if err != nil {
+		return recorded
+	}
+	for k, v := range resolved {
+		recorded[k] = v
+	}
+	for k, sep := range pointKeys {
+		if s, ok := recorded[k].(string); ok && sep != "" {
+			recorded[k] = ms.resolvePointRefs(s, sep)
+		}
+	}
+	return recorded
+}
+
+// resolvePointRefs replaces the waypoint references in a list of points separated
+// by sep with absolute "x y z" points. Invalid references are kept, the tool reports
+// them.
+func (ms *MinecraftServer) resolvePointRefs(s, sep string) string {
+	items := strings.Split(s, sep)
+	for i, item := range items {
+		if item = strings.TrimSpace(item); !isWaypointRef(item) {
+			continue
+		}
+		if p, err := ms.waypoints.ResolveRef(item, 0); err == nil {
+			items[i] = fmt.Sprintf("%d %d %d", p[0], p[1], p[2])
+		}
+	}
+	return strings.Join(items, sep)
+}
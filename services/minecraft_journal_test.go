@@ -0,0 +1,130 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestSessionJournal_RecordAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	sj := NewSessionJournal(dir)
+	ctx := context.Background()
+	args := map[string]interface{}{"x": "10", "y": "64", "z": "-5", "block": "minecraft:stone"}
+	if err := sj.Record(ctx, "minecraft_setblock", args, mcp.NewToolResultText("ok")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := sj.Record(ctx, "minecraft_setblock", args, mcp.NewToolResultError("failed")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	names, err := ListJournals(dir)
+	if err != nil || len(names) != 1 {
+		t.Fatalf("expected 1 journal, got %v (err: %v)", names, err)
+	}
+	entries, err := LoadJournal(dir, names[0])
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Seq != 1 || entries[1].Seq != 2 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if !entries[1].IsError || entries[0].Result != "ok" {
+		t.Errorf("unexpected entry results: %+v", entries)
+	}
+	if got := FilterJournal(entries, ReplayOptions{SkipErrors: true}); len(got) != 1 {
+		t.Errorf("expected 1 entry after skipping errors, got %d", len(got))
+	}
+
+	admin := []JournalEntry{{Seq: 1, Tool: "minecraft_fill"}, {Seq: 2, Tool: "minecraft_backup_restore"}, {Seq: 3, Tool: "minecraft_op"}}
+	if got := FilterJournal(admin, ReplayOptions{}); len(got) != 1 || got[0].Tool != "minecraft_fill" {
+		t.Errorf("admin tools should not be replayed by default, got %+v", got)
+	}
+	if got := FilterJournal(admin, ReplayOptions{Tools: []string{"minecraft_op"}}); len(got) != 1 || got[0].Tool != "minecraft_op" {
+		t.Errorf("admin tools named in Tools should be replayed, got %+v", got)
+	}
+}
+
+func TestTranslateArguments(t *testing.T) {
+	args := map[string]interface{}{
+		"x1":          "10",
+		"y1":          "~2",
+		"z1":          "-5.5",
+		"x":           "^",
+		"block":       "minecraft:stone",
+		"destination": "1 2 3",
+		"points":      "0 64 0; 10,64,10;station",
+		"amount":      float64(3),
+	}
+	got := TranslateArguments(args, [3]int{100, 0, -50})
+	want := map[string]interface{}{
+		"x1":          "110",
+		"y1":          "~2",
+		"z1":          "-55.5",
+		"x":           "^",
+		"block":       "minecraft:stone",
+		"destination": "101 2 -47",
+		"points":      "100 64 -50;110 64 -40;station",
+		"amount":      float64(3),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, got[k])
+		}
+	}
+	if args["x1"] != "10" {
+		t.Errorf("TranslateArguments must not modify its input")
+	}
+}
+
+func TestMinecraftServer_journalArgs(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{}}
+	set := map[string]interface{}{"rcon.password": "hunter2", "difficulty": "hard"}
+	recorded := ms.journalArgs(map[string]interface{}{"set": set})
+	got := recorded["set"].(map[string]interface{})
+	if got["rcon.password"] != redactedProperty || got["difficulty"] != "hard" {
+		t.Errorf("journaled properties = %v", got)
+	}
+	if set["rcon.password"] != "hunter2" {
+		t.Errorf("journalArgs must not modify its input")
+	}
+}
+
+func TestLoadJournal_LargeEntry(t *testing.T) {
+	dir := t.TempDir()
+	sj := NewSessionJournal(dir)
+	image := strings.Repeat("A", 20*1024*1024)
+	if err := sj.Record(context.Background(), "minecraft_pixel_art", map[string]interface{}{"imageBase64": image}, mcp.NewToolResultText("ok")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	names, _ := ListJournals(dir)
+	entries, err := LoadJournal(dir, names[0])
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Arguments["imageBase64"] != image {
+		t.Errorf("large entry was not loaded")
+	}
+}
@@ -0,0 +1,336 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxLogicInputs is the largest number of variables of a boolean expression.
+const maxLogicInputs = 6
+
+// boolExpr is a node of a parsed boolean expression.
+type boolExpr struct {
+	Op    byte // 'v' variable, 'c' constant, '!', '&', '^' or '|'
+	Name  string
+	Value bool
+	Args  []*boolExpr
+}
+
+// Eval evaluates the expression with the given variable values.
+func (e *boolExpr) Eval(vars map[string]bool) bool {
+	switch e.Op {
+	case 'v':
+		return vars[e.Name]
+	case 'c':
+		return e.Value
+	case '!':
+		return !e.Args[0].Eval(vars)
+	case '&':
+		return e.Args[0].Eval(vars) && e.Args[1].Eval(vars)
+	case '^':
+		return e.Args[0].Eval(vars) != e.Args[1].Eval(vars)
+	}
+	return e.Args[0].Eval(vars) || e.Args[1].Eval(vars)
+}
+
+// boolParser is a recursive descent parser of boolean expressions.
+type boolParser struct {
+	tokens []string
+	pos    int
+	vars   []string
+}
+
+// tokenizeBoolExpr splits an expression into variables, constants and operators,
+// normalizing &&, ||, ~ and the words and, or, xor and not.
+func tokenizeBoolExpr(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||"):
+			tokens = append(tokens, s[i:i+1])
+			i += 2
+		case strings.ContainsRune("!&|^()", c):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '~':
+			tokens = append(tokens, "!")
+			i++
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			j := i
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			word := s[i:j]
+			switch strings.ToLower(word) {
+			case "and":
+				word = "&"
+			case "or":
+				word = "|"
+			case "xor":
+				word = "^"
+			case "not":
+				word = "!"
+			}
+			tokens = append(tokens, word)
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q in expression", c)
+		}
+	}
+	return tokens, nil
+}
+
+// parseBoolExpr parses an expression of variables, 0, 1, ! (not), & (and), ^ (xor),
+// | (or) and parentheses, in decreasing precedence. It also returns the variables in
+// order of appearance.
+func parseBoolExpr(s string) (*boolExpr, []string, error) {
+	tokens, err := tokenizeBoolExpr(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("empty expression")
+	}
+	p := &boolParser{tokens: tokens}
+	e, err := p.binary(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, nil, fmt.Errorf("unexpected %q in expression", p.tokens[p.pos])
+	}
+	return e, p.vars, nil
+}
+
+// boolOperators lists the binary operators by increasing precedence.
+var boolOperators = []string{"|", "^", "&"}
+
+func (p *boolParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *boolParser) binary(level int) (*boolExpr, error) {
+	if level == len(boolOperators) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == boolOperators[level] {
+		p.pos++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &boolExpr{Op: boolOperators[level][0], Args: []*boolExpr{left, right}}
+	}
+	return left, nil
+}
+
+func (p *boolParser) unary() (*boolExpr, error) {
+	t := p.peek()
+	p.pos++
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case t == "!":
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &boolExpr{Op: '!', Args: []*boolExpr{e}}, nil
+	case t == "(":
+		e, err := p.binary(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return e, nil
+	case t == "0" || t == "1":
+		return &boolExpr{Op: 'c', Value: t == "1"}, nil
+	case t[0] == '_' || unicode.IsLetter(rune(t[0])):
+		found := false
+		for _, v := range p.vars {
+			found = found || v == t
+		}
+		if !found {
+			p.vars = append(p.vars, t)
+		}
+		return &boolExpr{Op: 'v', Name: t}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", t)
+}
+
+// implicant is a product term: variables whose bit is set in Mask are free, the others
+// must match Value. Bit i stands for variable i.
+type implicant struct {
+	Value, Mask uint
+}
+
+// Covers reports whether the term is true for the minterm.
+func (m implicant) Covers(minterm uint) bool {
+	return minterm&^m.Mask == m.Value
+}
+
+// truthTable returns the minterms (variable assignments, bit i for variable i) for which
+// the expression is true.
+func truthTable(e *boolExpr, vars []string) []uint {
+	var minterms []uint
+	values := make(map[string]bool, len(vars))
+	for m := uint(0); m < 1<<len(vars); m++ {
+		for i, v := range vars {
+			values[v] = m>>i&1 == 1
+		}
+		if e.Eval(values) {
+			minterms = append(minterms, m)
+		}
+	}
+	return minterms
+}
+
+// minimizeSOP returns a short sum of products covering exactly the minterms, using the
+// prime implicants of Quine-McCluskey and a greedy cover after the essential ones.
+func minimizeSOP(minterms []uint) []implicant {
+	current := make(map[implicant]bool, len(minterms))
+	for _, m := range minterms {
+		current[implicant{Value: m}] = true
+	}
+	var primes []implicant
+	for len(current) > 0 {
+		list := make([]implicant, 0, len(current))
+		for m := range current {
+			list = append(list, m)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Mask != list[j].Mask {
+				return list[i].Mask < list[j].Mask
+			}
+			return list[i].Value < list[j].Value
+		})
+		next := make(map[implicant]bool)
+		combined := make(map[implicant]bool)
+		for i, a := range list {
+			for _, b := range list[i+1:] {
+				if d := a.Value ^ b.Value; a.Mask == b.Mask && bits.OnesCount(d) == 1 {
+					next[implicant{Value: a.Value &^ d, Mask: a.Mask | d}] = true
+					combined[a], combined[b] = true, true
+				}
+			}
+		}
+		for _, m := range list {
+			if !combined[m] {
+				primes = append(primes, m)
+			}
+		}
+		current = next
+	}
+	// Larger terms first, so ties in the cover pick the simpler term
+	sort.SliceStable(primes, func(i, j int) bool {
+		return bits.OnesCount(primes[i].Mask) > bits.OnesCount(primes[j].Mask)
+	})
+
+	uncovered := make(map[uint]bool, len(minterms))
+	for _, m := range minterms {
+		uncovered[m] = true
+	}
+	chosen := make(map[int]bool)
+	choose := func(i int) {
+		chosen[i] = true
+		for m := range uncovered {
+			if primes[i].Covers(m) {
+				delete(uncovered, m)
+			}
+		}
+	}
+	for _, m := range minterms {
+		only := -1
+		for i, p := range primes {
+			if p.Covers(m) {
+				if only >= 0 {
+					only = -1
+					break
+				}
+				only = i
+			}
+		}
+		if only >= 0 && !chosen[only] {
+			choose(only)
+		}
+	}
+	for len(uncovered) > 0 {
+		best, count := -1, 0
+		for i, p := range primes {
+			n := 0
+			for m := range uncovered {
+				if p.Covers(m) {
+					n++
+				}
+			}
+			if n > count {
+				best, count = i, n
+			}
+		}
+		choose(best)
+	}
+
+	terms := make([]implicant, 0, len(chosen))
+	for i, p := range primes {
+		if chosen[i] {
+			terms = append(terms, p)
+		}
+	}
+	return terms
+}
+
+// formatSOP writes a sum of products with the variable names, e.g. "a&!b | c".
+func formatSOP(terms []implicant, vars []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		var lits []string
+		for v, name := range vars {
+			if t.Mask>>v&1 == 1 {
+				continue
+			}
+			if t.Value>>v&1 == 0 {
+				name = "!" + name
+			}
+			lits = append(lits, name)
+		}
+		if len(lits) == 0 {
+			lits = []string{"1"}
+		}
+		parts[i] = strings.Join(lits, "&")
+	}
+	return strings.Join(parts, " | ")
+}
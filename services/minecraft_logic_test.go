@@ -0,0 +1,97 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"testing"
+)
+
+func TestParseBoolExpr(t *testing.T) {
+	for _, c := range []struct {
+		expr string
+		vars int
+		on   []map[string]bool
+		off  []map[string]bool
+	}{
+		{"a & b | !c", 3, []map[string]bool{{"a": true, "b": true, "c": true}, {}}, []map[string]bool{{"c": true}}},
+		{"a ^ b & c", 3, []map[string]bool{{"a": true}, {"b": true, "c": true}}, []map[string]bool{{"a": true, "b": true, "c": true}}},
+		{"not (x or y) and 1", 2, []map[string]bool{{}}, []map[string]bool{{"x": true}}},
+		{"~(a && b) || 0", 2, []map[string]bool{{"a": true}}, []map[string]bool{{"a": true, "b": true}}},
+	} {
+		e, vars, err := parseBoolExpr(c.expr)
+		if err != nil {
+			t.Errorf("parseBoolExpr(%q) failed: %v", c.expr, err)
+			continue
+		}
+		if len(vars) != c.vars {
+			t.Errorf("parseBoolExpr(%q) variables = %v", c.expr, vars)
+		}
+		for _, v := range c.on {
+			if !e.Eval(v) {
+				t.Errorf("%q should be true for %v", c.expr, v)
+			}
+		}
+		for _, v := range c.off {
+			if e.Eval(v) {
+				t.Errorf("%q should be false for %v", c.expr, v)
+			}
+		}
+	}
+	for _, bad := range []string{"", "a &", "(a | b", "a b", "a $ b", "2a"} {
+		if _, _, err := parseBoolExpr(bad); err == nil {
+			t.Errorf("parseBoolExpr(%q) should fail", bad)
+		}
+	}
+}
+
+func TestMinimizeSOP(t *testing.T) {
+	for _, c := range []struct {
+		expr  string
+		terms int
+	}{
+		{"a & b | a & !b", 1},
+		{"a ^ b", 2},
+		{"a ^ b ^ c", 4},
+		{"(a | b) & (a | c)", 2},
+		{"a&b&c | a&b&!c | a&!b&c | !a&b&c", 3},
+		{"!(a & b & c & d & e & f)", 6},
+	} {
+		e, vars, _ := parseBoolExpr(c.expr)
+		minterms := truthTable(e, vars)
+		terms := minimizeSOP(minterms)
+		if len(terms) != c.terms {
+			t.Errorf("%q: %d terms (%s), want %d", c.expr, len(terms), formatSOP(terms, vars), c.terms)
+		}
+		for m := uint(0); m < 1<<len(vars); m++ {
+			want := false
+			for _, mt := range minterms {
+				want = want || mt == m
+			}
+			got := false
+			for _, term := range terms {
+				got = got || term.Covers(m)
+			}
+			if got != want {
+				t.Errorf("%q: %s is %v for %b, want %v", c.expr, formatSOP(terms, vars), got, m, want)
+			}
+		}
+	}
+}
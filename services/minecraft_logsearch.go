@@ -0,0 +1,377 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// latestLogName is the log file of the running server, older ones are gzipped.
+	latestLogName = "latest.log"
+	// maxLogPageSize is the largest page of minecraft_search_logs.
+	maxLogPageSize = 200
+)
+
+// rotatedLogRegex matches the name of a rotated log file, e.g. "2025-04-01-2.log.gz".
+var rotatedLogRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(\d+)\.log(\.gz)?$`)
+
+// logTimeLayouts are the accepted formats of the from and to arguments.
+var logTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+// LogQuery selects lines of the server log history. Zero fields match everything.
+type LogQuery struct {
+	From, To time.Time
+	Regex    *regexp.Regexp
+	Player   *regexp.Regexp // the player name as a word
+	Level    mcp.LoggingLevel
+	Event    LogEventType // only lines that are events of this type
+	Offset   int
+	Limit    int
+}
+
+// LogEntry is a line of the server log history.
+type LogEntry struct {
+	Time time.Time
+	File string
+	Line ServerLogLine
+}
+
+// String formats the entry with its date and file.
+func (e LogEntry) String() string {
+	return fmt.Sprintf("%s [%s] %s", e.Time.Format("2006-01-02"), e.File, e.Line.Raw)
+}
+
+// logFile is a log file with the date its first line was written on.
+type logFile struct {
+	Path  string
+	Date  time.Time
+	Index int
+}
+
+// serverLogFiles returns the log files in dir, oldest first, the rotated ones by the
+// date and index in their name and latest.log last.
+func serverLogFiles(dir string) ([]logFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	var latest *logFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if entry.Name() == latestLogName {
+			latest = &logFile{Path: path}
+			continue
+		}
+		m := rotatedLogRegex.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		index, _ := strconv.Atoi(m[2])
+		files = append(files, logFile{Path: path, Date: date, Index: index})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].Date.Equal(files[j].Date) {
+			return files[i].Date.Before(files[j].Date)
+		}
+		return files[i].Index < files[j].Index
+	})
+	if latest != nil {
+		files = append(files, *latest)
+	}
+	return files, nil
+}
+
+// openLogFile opens a log file, decompressing gzipped ones.
+func openLogFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, f}, nil
+}
+
+// lineClock returns the seconds since midnight of a log line time like "12:34:56".
+func lineClock(s string) (time.Duration, bool) {
+	if len(s) < 8 {
+		return 0, false
+	}
+	t, err := time.Parse("15:04:05", s[:8])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, true
+}
+
+// scanLogFile calls fn for every line of a log file until it returns false. Log lines
+// only have the time of day, so the date starts at file.Date and moves to the next
+// day when the time goes backwards. Without a date (latest.log), the file is read
+// once to count the midnights and the date is counted back from its last change.
+// Lines without a time, such as stack traces, get the time and level of the line
+// before them.
+func scanLogFile(file logFile, fn func(LogEntry) bool) error {
+	if file.Date.IsZero() {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return err
+		}
+		days := 0
+		if err := readLogFile(file.Path, time.Time{}, func(e LogEntry) bool {
+			days = int(e.Time.Sub(time.Time{}) / (24 * time.Hour))
+			return true
+		}); err != nil {
+			return err
+		}
+		y, m, d := info.ModTime().Date()
+		file.Date = time.Date(y, m, d-days, 0, 0, 0, 0, time.Local)
+	}
+	return readLogFile(file.Path, file.Date, fn)
+}
+
+// readLogFile reads the lines of a log file starting on the given date.
+func readLogFile(path string, date time.Time, fn func(LogEntry) bool) error {
+	r, err := openLogFile(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	name := filepath.Base(path)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var last time.Duration
+	current := date
+	level := mcp.LoggingLevelInfo
+	for scanner.Scan() {
+		line := parseServerLog(scanner.Text(), level)
+		if clock, ok := lineClock(line.Time); ok {
+			if clock < last {
+				date = date.AddDate(0, 0, 1)
+			}
+			last = clock
+			current = date.Add(clock)
+			level = line.Level
+		}
+		if !fn(LogEntry{Time: current, File: name, Line: line}) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// SearchLogs returns the page of lines in the log files of dir selected by q, oldest
+// first, and the number of all selected lines.
+func SearchLogs(dir string, q LogQuery, parser *LogEventParser) ([]LogEntry, int, error) {
+	files, err := serverLogFiles(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	var page []LogEntry
+	total := 0
+	fromDay := time.Date(q.From.Year(), q.From.Month(), q.From.Day()-1, 0, 0, 0, 0, time.Local)
+	for i, file := range files {
+		// A file ends when the next one starts, skip the files ending before the range
+		if !q.From.IsZero() && i+1 < len(files) && !files[i+1].Date.IsZero() && files[i+1].Date.Before(fromDay) {
+			continue
+		}
+		if !q.To.IsZero() && !file.Date.IsZero() && file.Date.After(q.To) {
+			break
+		}
+		err := scanLogFile(file, func(e LogEntry) bool {
+			if !q.match(e, parser) {
+				return true
+			}
+			if total >= q.Offset && (q.Limit <= 0 || len(page) < q.Limit) {
+				page = append(page, e)
+			}
+			total++
+			return true
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return page, total, nil
+}
+
+// match reports whether the query selects an entry.
+func (q LogQuery) match(e LogEntry, parser *LogEventParser) bool {
+	if !q.From.IsZero() && e.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && e.Time.After(q.To) {
+		return false
+	}
+	if q.Level != "" && logSeverity(e.Line.Level) < logSeverity(q.Level) {
+		return false
+	}
+	if q.Regex != nil && !q.Regex.MatchString(e.Line.Raw) {
+		return false
+	}
+	if q.Player != nil && !q.Player.MatchString(e.Line.Message) {
+		return false
+	}
+	if q.Event != "" {
+		event, ok := parser.Parse(e.Line)
+		if !ok || event.Type != q.Event {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLogTime parses a from or to argument in local time. A time of day alone, like
+// "15:04", is today.
+func parseLogTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range logTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			y, m, d := time.Now().Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected e.g. \"2025-04-01 15:00\" or \"15:00\"", s)
+}
+
+// registerLogSearchTools registers the log history tool.
+func (ms *MinecraftServer) registerLogSearchTools() {
+	levels := make([]string, len(logLevels))
+	for i, l := range logLevels {
+		levels[i] = string(l)
+	}
+	ms.AddTool(mcp.NewTool(
+		"minecraft_search_logs",
+		mcp.WithDescription("Search the Minecraft server log history (logs/latest.log and the rotated logs/*.log.gz), e.g. to find out why the server lagged or what a player did"),
+		mcp.WithString("from", mcp.Description("Start of the time range in server local time (optional), e.g. '2025-04-01 14:30' or '14:30' for today")),
+		mcp.WithString("to", mcp.Description("End of the time range in server local time (optional)")),
+		mcp.WithString("regex", mcp.Description("Regular expression the line must match (optional), e.g. 'Can.t keep up|TNT'")),
+		mcp.WithString("player", mcp.Description("Only lines mentioning this player (optional)")),
+		mcp.WithString("level", mcp.Description("Minimum level of the lines (optional)"), mcp.Enum(levels...)),
+		mcp.WithString("event", mcp.Description("Only lines that are this game event (optional)"), mcp.Enum(logEventTypes...)),
+		mcp.WithNumber("page", mcp.Description("Page of the results, starting at 1 (optional, default: 1)")),
+		mcp.WithNumber("pageSize", mcp.Description(fmt.Sprintf("Lines per page (optional, default: 50, max: %d)", maxLogPageSize))),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleSearchLogs)
+}
+
+// handleSearchLogs implements the minecraft_search_logs tool.
+func (ms *MinecraftServer) handleSearchLogs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	var q LogQuery
+	for key, t := range map[string]*time.Time{"from": &q.From, "to": &q.To} {
+		if s, _ := getStringArg(args, key, false); s != "" {
+			var err error
+			if *t, err = parseLogTime(s); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("%s: %v", key, err)), nil
+			}
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return mcp.NewToolResultError("to is before from"), nil
+	}
+	if s, _ := getStringArg(args, "regex", false); s != "" {
+		var err error
+		if q.Regex, err = regexp.Compile(s); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid regex: %v", err)), nil
+		}
+	}
+	if player, _ := getStringArg(args, "player", false); player != "" {
+		q.Player = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(player) + `\b`)
+	}
+	if level, _ := getStringArg(args, "level", false); level != "" {
+		q.Level = mcp.LoggingLevel(strings.ToLower(level))
+		if logSeverity(q.Level) < 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid level %s", level)), nil
+		}
+	}
+	if event, _ := getStringArg(args, "event", false); event != "" {
+		if indexOf(logEventTypes, event) < 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown event %s, valid events are %s", event, strings.Join(logEventTypes, ", "))), nil
+		}
+		q.Event = LogEventType(event)
+	}
+	page, err := getIntArg(args, "page", 1)
+	if err != nil || page < 1 {
+		return mcp.NewToolResultError("page must be a positive number"), nil
+	}
+	q.Limit, err = getIntArg(args, "pageSize", 50)
+	if err != nil || q.Limit < 1 || q.Limit > maxLogPageSize {
+		return mcp.NewToolResultError(fmt.Sprintf("pageSize must be between 1 and %d", maxLogPageSize)), nil
+	}
+	q.Offset = (page - 1) * q.Limit
+
+	parser := ms.eventParser
+	if parser == nil {
+		parser = NewLogEventParser(ms.config.GameVersion)
+	}
+	entries, total, err := SearchLogs(filepath.Join(ms.config.ServerRootPath, "logs"), q, parser)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to search the logs: %v", err)), nil
+	}
+	if total == 0 {
+		return mcp.NewToolResultText("No matching log lines"), nil
+	}
+	if len(entries) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("page %d is past the last page, there are %d matching lines", page, total)), nil
+	}
+	var sb strings.Builder
+	pages := (total + q.Limit - 1) / q.Limit
+	fmt.Fprintf(&sb, "Lines %d-%d of %d (page %d of %d):\n", q.Offset+1, q.Offset+len(entries), total, page, pages)
+	for _, e := range entries {
+		sb.WriteString(e.String() + "\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
@@ -0,0 +1,129 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func writeTestLogs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "2025-04-01-1.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(strings.Join([]string{
+		"[14:59:00] [Server thread/INFO]: Steve joined the game",
+		"[15:02:11] [Server thread/WARN]: Can't keep up! Is the server overloaded? Running 5012ms or 100 ticks behind",
+		"[23:59:59] [Server thread/INFO]: Steve was blown up by Creeper",
+		"[00:00:05] [Server thread/ERROR]: Exception ticking world",
+		"\tat net.minecraft.server.MinecraftServer.run(MinecraftServer.java:123)",
+	}, "\n") + "\n"))
+	gz.Close()
+	f.Close()
+
+	latest := filepath.Join(dir, "latest.log")
+	if err := os.WriteFile(latest, []byte("[09:00:00] [Server thread/INFO]: Alex joined the game\n[09:05:00] [Server thread/INFO]: <Alex> who placed TNT here?\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2025, 4, 3, 9, 5, 0, 0, time.Local)
+	if err := os.Chtimes(latest, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "debug.txt"), []byte("not a log"), 0644)
+	return dir
+}
+
+func TestSearchLogs(t *testing.T) {
+	dir := writeTestLogs(t)
+	parser := NewLogEventParser("1.20.2")
+
+	entries, total, err := SearchLogs(dir, LogQuery{}, parser)
+	if err != nil || total != 7 || len(entries) != 7 {
+		t.Fatalf("SearchLogs = %d entries, %d total, %v", len(entries), total, err)
+	}
+	if e := entries[3]; e.Time.Format("2006-01-02 15:04:05") != "2025-04-02 00:00:05" {
+		t.Errorf("time after midnight = %s", e.Time)
+	}
+	if e := entries[4]; e.Line.Level != mcp.LoggingLevelError || e.Time != entries[3].Time {
+		t.Errorf("stack trace line = %+v", e)
+	}
+	if e := entries[6]; e.File != "latest.log" || e.Time.Format("2006-01-02 15:04") != "2025-04-03 09:05" {
+		t.Errorf("latest.log line = %s", e)
+	}
+
+	from, _ := parseLogTime("2025-04-01 15:00")
+	to, _ := parseLogTime("2025-04-01 16:00")
+	entries, total, _ = SearchLogs(dir, LogQuery{From: from, To: to}, parser)
+	if total != 1 || !strings.Contains(entries[0].Line.Message, "Can't keep up") {
+		t.Errorf("time range = %v", entries)
+	}
+
+	_, total, _ = SearchLogs(dir, LogQuery{Level: mcp.LoggingLevelWarning}, parser)
+	if total != 3 {
+		t.Errorf("warnings and errors = %d", total)
+	}
+	_, total, _ = SearchLogs(dir, LogQuery{Player: regexp.MustCompile(`(?i)\bsteve\b`)}, parser)
+	if total != 2 {
+		t.Errorf("lines of Steve = %d", total)
+	}
+	entries, _, _ = SearchLogs(dir, LogQuery{Event: EventDeath}, parser)
+	if len(entries) != 1 || entries[0].Line.Message != "Steve was blown up by Creeper" {
+		t.Errorf("deaths = %v", entries)
+	}
+	entries, total, _ = SearchLogs(dir, LogQuery{Regex: regexp.MustCompile("joined"), Offset: 1, Limit: 1}, parser)
+	if total != 2 || len(entries) != 1 || !strings.Contains(entries[0].Line.Raw, "Alex") {
+		t.Errorf("second page = %v of %d", entries, total)
+	}
+}
+
+func TestParseLogTime(t *testing.T) {
+	if tm, err := parseLogTime("2025-04-01 15:04"); err != nil || tm.Format(time.DateTime) != "2025-04-01 15:04:00" {
+		t.Errorf("parseLogTime = %s, %v", tm, err)
+	}
+	if tm, err := parseLogTime("15:30"); err != nil || tm.Format(time.DateOnly) != time.Now().Format(time.DateOnly) || tm.Hour() != 15 {
+		t.Errorf("time of day = %s, %v", tm, err)
+	}
+	if _, err := parseLogTime("yesterday"); err == nil {
+		t.Errorf("invalid time should fail")
+	}
+}
+
+func TestMinecraftServer_handleSearchLogsEvent(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: t.TempDir()}}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"event": "deaths"}
+	result, _ := ms.handleSearchLogs(context.Background(), request)
+	if text := toolResultText(result); !result.IsError || !strings.Contains(text, "death, advancement") {
+		t.Errorf("an unknown event should list the valid ones, got %s", text)
+	}
+}
@@ -0,0 +1,363 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// serverLogURI is the resource with the recent server output.
+	serverLogURI = "minecraft://server/log"
+	// logBufferSize is the number of recent server lines kept for the log resource.
+	logBufferSize = 500
+	// resourceUpdateInterval throttles the resources/updated notifications of a session.
+	resourceUpdateInterval = time.Second
+	// maxDroppedNotifications unsubscribes a session whose notifications keep failing.
+	maxDroppedNotifications = 100
+)
+
+// serverLogFormats match a line of the server console in the layouts of the common
+// server distributions. The groups are the time of day, the thread (optional), the
+// level and the message.
+var serverLogFormats = []*regexp.Regexp{
+	// Vanilla and Forge: "[12:34:56] [Server thread/INFO]: Steve joined the game", Forge
+	// adds the logger name as an extra bracket before the colon.
+	regexp.MustCompile(`^\[([0-9:.]+)\] \[([^\]]+)/([A-Z]+)\](?: \[[^\]]*\])?: ?(.*)$`),
+	// Fabric: "[12:34:56] [Server thread/INFO] (Minecraft) Steve joined the game"
+	regexp.MustCompile(`^\[([0-9:.]+)\] \[([^\]]+)/([A-Z]+)\] \([^)]*\) (.*)$`),
+	// Paper and Spigot: "[12:34:56 INFO]: Steve joined the game"
+	regexp.MustCompile(`^\[([0-9:.]+)()\s+([A-Z]+)\]: ?(.*)$`),
+}
+
+// logLevels are the MCP logging levels from the least to the most severe.
+var logLevels = []mcp.LoggingLevel{
+	mcp.LoggingLevelDebug, mcp.LoggingLevelInfo, mcp.LoggingLevelNotice, mcp.LoggingLevelWarning,
+	mcp.LoggingLevelError, mcp.LoggingLevelCritical, mcp.LoggingLevelAlert, mcp.LoggingLevelEmergency,
+}
+
+// logSeverity returns the position of level in logLevels, or -1.
+func logSeverity(level mcp.LoggingLevel) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// ServerLogLine is a line of the server output.
+type ServerLogLine struct {
+	Time    string           `json:"time,omitempty"` // time of day logged by the server
+	Thread  string           `json:"thread,omitempty"`
+	Level   mcp.LoggingLevel `json:"level"`
+	Message string           `json:"message"`
+	Raw     string           `json:"-"`
+}
+
+// parseServerLog parses a line of the server output. Lines that do not look like log
+// records, such as stack traces, get fallback as their level.
+func parseServerLog(line string, fallback mcp.LoggingLevel) ServerLogLine {
+	var m []string
+	for _, format := range serverLogFormats {
+		if m = format.FindStringSubmatch(line); m != nil {
+			break
+		}
+	}
+	if m == nil {
+		return ServerLogLine{Level: fallback, Message: line, Raw: line}
+	}
+	level := mcp.LoggingLevelInfo
+	switch m[3] {
+	case "TRACE", "DEBUG":
+		level = mcp.LoggingLevelDebug
+	case "WARN":
+		level = mcp.LoggingLevelWarning
+	case "ERROR":
+		level = mcp.LoggingLevelError
+	case "FATAL":
+		level = mcp.LoggingLevelCritical
+	}
+	return ServerLogLine{Time: m[1], Thread: m[2], Level: level, Message: m[4], Raw: line}
+}
+
+// LogFilter selects server lines by minimum level and regular expressions on the line.
+type LogFilter struct {
+	Level   mcp.LoggingLevel
+	Include *regexp.Regexp // only lines matching it, if set
+	Exclude *regexp.Regexp // no lines matching it, if set
+}
+
+// Match reports whether the filter selects l.
+func (f LogFilter) Match(l ServerLogLine) bool {
+	if logSeverity(l.Level) < logSeverity(f.Level) {
+		return false
+	}
+	if f.Include != nil && !f.Include.MatchString(l.Raw) {
+		return false
+	}
+	return f.Exclude == nil || !f.Exclude.MatchString(l.Raw)
+}
+
+// logSubscription is a session that receives the server output as notifications.
+type logSubscription struct {
+	session         server.ClientSession
+	filter          LogFilter
+	resourceUpdates bool // send resources/updated for the log resource instead of every line
+	lastUpdate      time.Time
+	dropped         int // consecutive notifications that could not be delivered
+}
+
+// notify sends a notification to the session without blocking.
+func (sub *logSubscription) notify(method string, params map[string]interface{}) bool {
+	if !sub.session.Initialized() {
+		return false
+	}
+	notification := mcp.JSONRPCNotification{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		Notification: mcp.Notification{
+			Method: method,
+			Params: mcp.NotificationParams{AdditionalFields: params},
+		},
+	}
+	select {
+	case sub.session.NotificationChannel() <- notification:
+		return true
+	default:
+		return false
+	}
+}
+
+// logStream keeps the recent server output and forwards new lines to the subscribed sessions.
+type logStream struct {
+	mu     sync.Mutex
+	recent []ServerLogLine
+	subs   map[string]*logSubscription // by session ID
+}
+
+// Publish records a line and notifies the sessions whose filter selects it. Sessions
+// that keep failing to receive notifications are unsubscribed.
+func (ls *logStream) Publish(l ServerLogLine) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.recent = append(ls.recent, l)
+	if len(ls.recent) > logBufferSize {
+		ls.recent = append(ls.recent[:0], ls.recent[len(ls.recent)-logBufferSize:]...)
+	}
+	now := time.Now()
+	for id, sub := range ls.subs {
+		if !sub.filter.Match(l) {
+			continue
+		}
+		var ok bool
+		if sub.resourceUpdates {
+			if now.Sub(sub.lastUpdate) < resourceUpdateInterval {
+				continue
+			}
+			if ok = sub.notify("notifications/resources/updated", map[string]interface{}{"uri": serverLogURI}); ok {
+				sub.lastUpdate = now
+			}
+		} else {
+			ok = sub.notify("notifications/message", map[string]interface{}{"level": l.Level, "logger": "minecraft", "data": l.Raw})
+		}
+		if ok {
+			sub.dropped = 0
+		} else if sub.dropped++; sub.dropped >= maxDroppedNotifications {
+			delete(ls.subs, id)
+		}
+	}
+}
+
+// Broadcast sends a logging notification to every subscribed session, regardless of its
+// filter, and returns the number of sessions that received it.
+func (ls *logStream) Broadcast(level mcp.LoggingLevel, logger string, data interface{}) int {
+	return ls.Notify("notifications/message", map[string]interface{}{"level": level, "logger": logger, "data": data})
+}
+
+// Notify sends a notification to every subscribed session and returns the number of
+// sessions that received it.
+func (ls *logStream) Notify(method string, params map[string]interface{}) int {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	sent := 0
+	for _, sub := range ls.subs {
+		if sub.notify(method, params) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Subscribe sets the filter of a session, replacing a previous subscription.
+func (ls *logStream) Subscribe(session server.ClientSession, filter LogFilter, resourceUpdates bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.subs == nil {
+		ls.subs = make(map[string]*logSubscription)
+	}
+	ls.subs[session.SessionID()] = &logSubscription{session: session, filter: filter, resourceUpdates: resourceUpdates}
+}
+
+// Unsubscribe stops the notifications of a session and reports whether it was subscribed.
+func (ls *logStream) Unsubscribe(sessionID string) bool {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	_, ok := ls.subs[sessionID]
+	delete(ls.subs, sessionID)
+	return ok
+}
+
+// Recent returns up to n of the latest lines selected by filter, oldest first.
+func (ls *logStream) Recent(filter LogFilter, n int) []ServerLogLine {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	var lines []ServerLogLine
+	for i := len(ls.recent) - 1; i >= 0 && len(lines) < n; i-- {
+		if filter.Match(ls.recent[i]) {
+			lines = append(lines, ls.recent[i])
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// getLogFilter reads the level, include and exclude arguments.
+func getLogFilter(args map[string]interface{}) (LogFilter, error) {
+	filter := LogFilter{Level: mcp.LoggingLevelInfo}
+	if level, _ := getStringArg(args, "level", false); level != "" {
+		filter.Level = mcp.LoggingLevel(strings.ToLower(level))
+		if logSeverity(filter.Level) < 0 {
+			return filter, fmt.Errorf("invalid level %s", level)
+		}
+	}
+	for key, re := range map[string]**regexp.Regexp{"include": &filter.Include, "exclude": &filter.Exclude} {
+		if expr, _ := getStringArg(args, key, false); expr != "" {
+			var err error
+			if *re, err = regexp.Compile(expr); err != nil {
+				return filter, fmt.Errorf("invalid %s pattern: %w", key, err)
+			}
+		}
+	}
+	return filter, nil
+}
+
+// registerLogTools registers the server log resource and tools.
+func (ms *MinecraftServer) registerLogTools() {
+	ms.AddResource(mcp.NewResource(
+		serverLogURI,
+		"Minecraft server log",
+		mcp.WithResourceDescription(fmt.Sprintf("The last %d lines of the Minecraft server output", logBufferSize)),
+		mcp.WithMIMEType("text/plain"),
+	), ms.handleLogResource)
+
+	levels := make([]string, len(logLevels))
+	for i, l := range logLevels {
+		levels[i] = string(l)
+	}
+	ms.AddTool(mcp.NewTool(
+		"minecraft_log_subscribe",
+		mcp.WithDescription("Receive the Minecraft server output (chat, joins, deaths, advancements, warnings, ...) of this session as logging notifications, so you can react to game events. Subscribing again replaces the filter."),
+		mcp.WithString("level", mcp.Description("Minimum level of the lines (optional, default: info)"), mcp.Enum(levels...)),
+		mcp.WithString("include", mcp.Description("Regular expression, only lines matching it are sent (optional), e.g. 'joined the game|left the game'")),
+		mcp.WithString("exclude", mcp.Description("Regular expression, lines matching it are not sent (optional)")),
+		mcp.WithBoolean("resourceUpdates", mcp.Description("Send a resources/updated notification for "+serverLogURI+" (at most once a second) instead of every line (optional, default: false)")),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleLogSubscribe)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_log_unsubscribe",
+		mcp.WithDescription("Stop the server output notifications of this session"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleLogUnsubscribe)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_log_recent",
+		mcp.WithDescription("Show the latest lines of the Minecraft server output"),
+		mcp.WithNumber("lines", mcp.Description(fmt.Sprintf("Number of lines (optional, default: 50, max: %d)", logBufferSize))),
+		mcp.WithString("level", mcp.Description("Minimum level of the lines (optional, default: info)"), mcp.Enum(levels...)),
+		mcp.WithString("include", mcp.Description("Regular expression, only lines matching it are shown (optional)")),
+		mcp.WithString("exclude", mcp.Description("Regular expression, lines matching it are not shown (optional)")),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleLogRecent)
+}
+
+// handleLogResource returns the recent server output.
+func (ms *MinecraftServer) handleLogResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	var sb strings.Builder
+	for _, l := range ms.logs.Recent(LogFilter{Level: mcp.LoggingLevelDebug}, logBufferSize) {
+		sb.WriteString(l.Raw + "\n")
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: serverLogURI, MIMEType: "text/plain", Text: sb.String()}}, nil
+}
+
+// handleLogSubscribe implements the minecraft_log_subscribe tool.
+func (ms *MinecraftServer) handleLogSubscribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return mcp.NewToolResultError("notifications need an MCP client session"), nil
+	}
+	filter, err := getLogFilter(request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	resourceUpdates, _ := getBoolArg(request.Params.Arguments, "resourceUpdates", false)
+	ms.logs.Subscribe(session, filter, resourceUpdates)
+	ms.logger.Info().Str("session", session.SessionID()).Str("level", string(filter.Level)).Msg("Session subscribed to the server log")
+	return mcp.NewToolResultText(fmt.Sprintf("Subscribed to the server output at level %s and above", filter.Level)), nil
+}
+
+// handleLogUnsubscribe implements the minecraft_log_unsubscribe tool.
+func (ms *MinecraftServer) handleLogUnsubscribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil || !ms.logs.Unsubscribe(session.SessionID()) {
+		return mcp.NewToolResultError("this session is not subscribed to the server output"), nil
+	}
+	return mcp.NewToolResultText("Unsubscribed from the server output"), nil
+}
+
+// handleLogRecent implements the minecraft_log_recent tool.
+func (ms *MinecraftServer) handleLogRecent(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filter, err := getLogFilter(request.Params.Arguments)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	n, err := getIntArg(request.Params.Arguments, "lines", 50)
+	if err != nil || n < 1 || n > logBufferSize {
+		return mcp.NewToolResultError(fmt.Sprintf("lines must be between 1 and %d", logBufferSize)), nil
+	}
+	lines := ms.logs.Recent(filter, n)
+	if len(lines) == 0 {
+		return mcp.NewToolResultText("No matching server output"), nil
+	}
+	var sb strings.Builder
+	for _, l := range lines {
+		sb.WriteString(l.Raw + "\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
@@ -0,0 +1,132 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type testSession struct {
+	id string
+	ch chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.ch
+}
+
+func TestParseServerLog(t *testing.T) {
+	l := parseServerLog("[12:34:56] [Server thread/INFO]: Steve joined the game", mcp.LoggingLevelInfo)
+	if l.Time != "12:34:56" || l.Thread != "Server thread" || l.Level != mcp.LoggingLevelInfo || l.Message != "Steve joined the game" {
+		t.Errorf("parseServerLog = %+v", l)
+	}
+	l = parseServerLog("[12:34:56] [Server thread/WARN] [minecraft/MinecraftServer]: Can't keep up!", mcp.LoggingLevelInfo)
+	if l.Level != mcp.LoggingLevelWarning || l.Message != "Can't keep up!" {
+		t.Errorf("parseServerLog with logger name = %+v", l)
+	}
+	if l = parseServerLog("\tat java.lang.Thread.run", mcp.LoggingLevelError); l.Level != mcp.LoggingLevelError {
+		t.Errorf("unparsed line level = %s", l.Level)
+	}
+}
+
+func TestLogStream(t *testing.T) {
+	var ls logStream
+	chat := &testSession{id: "chat", ch: make(chan mcp.JSONRPCNotification, 10)}
+	warn := &testSession{id: "warn", ch: make(chan mcp.JSONRPCNotification, 10)}
+	ls.Subscribe(chat, LogFilter{Level: mcp.LoggingLevelInfo, Include: regexp.MustCompile(`<\w+>`)}, false)
+	ls.Subscribe(warn, LogFilter{Level: mcp.LoggingLevelWarning}, false)
+
+	ls.Publish(parseServerLog("[10:00:00] [Server thread/INFO]: <Steve> hello", mcp.LoggingLevelInfo))
+	ls.Publish(parseServerLog("[10:00:01] [Server thread/WARN]: Can't keep up!", mcp.LoggingLevelInfo))
+	ls.Publish(parseServerLog("[10:00:02] [Server thread/INFO]: Steve left the game", mcp.LoggingLevelInfo))
+
+	if len(chat.ch) != 1 || len(warn.ch) != 1 {
+		t.Fatalf("notifications: chat %d, warn %d", len(chat.ch), len(warn.ch))
+	}
+	n := <-chat.ch
+	if n.Method != "notifications/message" || n.Params.AdditionalFields["data"] != "[10:00:00] [Server thread/INFO]: <Steve> hello" {
+		t.Errorf("chat notification = %+v", n)
+	}
+	if n = <-warn.ch; n.Params.AdditionalFields["level"] != mcp.LoggingLevelWarning {
+		t.Errorf("warn notification = %+v", n)
+	}
+
+	if lines := ls.Recent(LogFilter{Level: mcp.LoggingLevelInfo, Exclude: regexp.MustCompile("Steve")}, 10); len(lines) != 1 || lines[0].Message != "Can't keep up!" {
+		t.Errorf("Recent = %+v", lines)
+	}
+	if lines := ls.Recent(LogFilter{Level: mcp.LoggingLevelDebug}, 2); len(lines) != 2 || lines[1].Message != "Steve left the game" {
+		t.Errorf("Recent(2) = %+v", lines)
+	}
+
+	// Resource updates are throttled
+	ls.Subscribe(chat, LogFilter{Level: mcp.LoggingLevelDebug}, true)
+	ls.Publish(parseServerLog("a", mcp.LoggingLevelInfo))
+	ls.Publish(parseServerLog("b", mcp.LoggingLevelInfo))
+	if len(chat.ch) != 1 {
+		t.Fatalf("resource updates: %d", len(chat.ch))
+	}
+	if n = <-chat.ch; n.Method != "notifications/resources/updated" || n.Params.AdditionalFields["uri"] != serverLogURI {
+		t.Errorf("resource update = %+v", n)
+	}
+
+	if !ls.Unsubscribe("warn") || ls.Unsubscribe("warn") {
+		t.Errorf("Unsubscribe should only succeed once")
+	}
+
+	// Broadcasts reach every subscriber and report how many got them
+	if n := ls.Broadcast(mcp.LoggingLevelNotice, "minecraft.chat", "hi"); n != 1 || len(chat.ch) != 1 {
+		t.Errorf("Broadcast = %d, %d queued", n, len(chat.ch))
+	}
+	<-chat.ch
+	if n := ls.Notify("notifications/prompts/list_changed", nil); n != 1 {
+		t.Errorf("Notify = %d", n)
+	} else if got := <-chat.ch; got.Method != "notifications/prompts/list_changed" {
+		t.Errorf("notification = %+v", got)
+	}
+	ls.Unsubscribe("chat")
+	if n := ls.Broadcast(mcp.LoggingLevelNotice, "minecraft.chat", "hi"); n != 0 {
+		t.Errorf("Broadcast without subscribers = %d", n)
+	}
+}
+
+func TestMinecraftServer_handleLogSubscribe(t *testing.T) {
+	ms := &MinecraftServer{}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"level": "warning"}
+	if result, _ := ms.handleLogSubscribe(context.Background(), request); !result.IsError {
+		t.Errorf("subscribing without a session should fail")
+	}
+
+	session := &testSession{id: "s1", ch: make(chan mcp.JSONRPCNotification, 1)}
+	ctx := server.NewMCPServer("test", "1.0").WithContext(context.Background(), session)
+	request.Params.Arguments["include"] = "("
+	if result, _ := ms.handleLogSubscribe(ctx, request); !result.IsError {
+		t.Errorf("invalid include pattern should fail")
+	}
+}
@@ -0,0 +1,833 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxModelFileSize is the largest model (or model buffer) file accepted.
+	maxModelFileSize = 64 * 1024 * 1024
+)
+
+// meshVertex is a vertex of a triangle with its texture coordinate and color (0-255 each).
+type meshVertex struct {
+	Pos   Vec3
+	UV    [2]float64
+	Color [3]float64
+}
+
+// meshTriangle is a single triangle of a mesh. Its color is sampled from Texture
+// when set, otherwise the vertex colors are used if HasColor is true.
+type meshTriangle struct {
+	V        [3]meshVertex
+	Texture  image.Image
+	HasColor bool
+}
+
+// Mesh is a triangle soup loaded from a model file.
+type Mesh struct {
+	Triangles []meshTriangle
+	// UpAxis is the axis the format conventionally points up ("y" or "z")
+	UpAxis string
+}
+
+// ColorAt returns the color of the triangle at the barycentric coordinates a, b, c.
+func (t *meshTriangle) ColorAt(a, b, c float64) [3]float64 {
+	if t.Texture != nil {
+		u := a*t.V[0].UV[0] + b*t.V[1].UV[0] + c*t.V[2].UV[0]
+		v := a*t.V[0].UV[1] + b*t.V[1].UV[1] + c*t.V[2].UV[1]
+		return sampleTexture(t.Texture, u, v)
+	}
+	var col [3]float64
+	for i := range col {
+		col[i] = a*t.V[0].Color[i] + b*t.V[1].Color[i] + c*t.V[2].Color[i]
+	}
+	return col
+}
+
+// sampleTexture returns the texel at u, v (repeating, v pointing up).
+func sampleTexture(img image.Image, u, v float64) [3]float64 {
+	b := img.Bounds()
+	u -= math.Floor(u)
+	v -= math.Floor(v)
+	x := b.Min.X + min(int(u*float64(b.Dx())), b.Dx()-1)
+	y := b.Min.Y + min(int((1-v)*float64(b.Dy())), b.Dy()-1)
+	r, g, bl, a := img.At(x, y).RGBA()
+	if a == 0 {
+		return [3]float64{}
+	}
+	return [3]float64{float64(r) / float64(a) * 255, float64(g) / float64(a) * 255, float64(bl) / float64(a) * 255}
+}
+
+// loadModel loads an OBJ, STL, glTF or GLB file from the assets directory.
+func (ms *MinecraftServer) loadModel(path string) (*Mesh, error) {
+	data, err := ms.readAssetFile(path, maxModelFileSize)
+	if err != nil {
+		return nil, err
+	}
+	var mesh *Mesh
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".obj":
+		mesh, err = ms.parseOBJ(data, filepath.Dir(path))
+	case ".stl":
+		mesh, err = parseSTL(data)
+	case ".gltf", ".glb":
+		mesh, err = ms.parseGLTF(data, filepath.Dir(path))
+	default:
+		return nil, fmt.Errorf("unsupported model format %q (expected .obj, .stl, .gltf or .glb)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model %s: %w", path, err)
+	}
+	if len(mesh.Triangles) == 0 {
+		return nil, fmt.Errorf("model %s contains no triangles", path)
+	}
+	return mesh, nil
+}
+
+// loadModelTexture loads a texture image referenced by a model, relative to the model directory.
+func (ms *MinecraftServer) loadModelTexture(dir, name string) (image.Image, error) {
+	data, err := ms.readAssetFile(filepath.Join(dir, filepath.FromSlash(name)), maxImageFileSize)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := decodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("texture %s: %w", name, err)
+	}
+	return img, nil
+}
+
+// objMaterial is the part of a MTL material that is used for coloring.
+type objMaterial struct {
+	Color    [3]float64
+	HasColor bool
+	Texture  image.Image
+}
+
+// parseFloats parses all fields as floats.
+func parseFloats(fields []string) ([]float64, error) {
+	out := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid number %q", f)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// newLineScanner returns a scanner for text model files, which may have long lines.
+func newLineScanner(data []byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
+
+// parseOBJ parses a Wavefront OBJ file. Polygons are triangulated as fans; colors come from
+// the diffuse texture (map_Kd) or color (Kd) of the MTL material, or from vertex colors.
+func (ms *MinecraftServer) parseOBJ(data []byte, dir string) (*Mesh, error) {
+	var (
+		positions []Vec3
+		colors    [][3]float64
+		uvs       [][2]float64
+		vertColor bool
+		materials = make(map[string]*objMaterial)
+		current   *objMaterial
+		mesh      = &Mesh{UpAxis: "y"}
+	)
+
+	// index resolves a 1-based (or negative, relative) OBJ index
+	index := func(s string, n int) (int, error) {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid index %q", s)
+		}
+		if i < 0 {
+			i += n
+		} else {
+			i--
+		}
+		if i < 0 || i >= n {
+			return 0, fmt.Errorf("index %s out of range", s)
+		}
+		return i, nil
+	}
+
+	scanner := newLineScanner(data)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			v, err := parseFloats(fields[1:])
+			if err != nil || len(v) < 3 {
+				return nil, fmt.Errorf("line %d: invalid vertex", line)
+			}
+			positions = append(positions, Vec3{v[0], v[1], v[2]})
+			var c [3]float64
+			if len(v) >= 6 {
+				c = [3]float64{v[3] * 255, v[4] * 255, v[5] * 255}
+				vertColor = true
+			}
+			colors = append(colors, c)
+		case "vt":
+			v, err := parseFloats(fields[1:])
+			if err != nil || len(v) < 1 {
+				return nil, fmt.Errorf("line %d: invalid texture coordinate", line)
+			}
+			uv := [2]float64{v[0], 0}
+			if len(v) > 1 {
+				uv[1] = v[1]
+			}
+			uvs = append(uvs, uv)
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("line %d: a face needs at least 3 vertices", line)
+			}
+			verts := make([]meshVertex, 0, len(fields)-1)
+			hasUV := true
+			for _, f := range fields[1:] {
+				parts := strings.Split(f, "/")
+				pi, err := index(parts[0], len(positions))
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				mv := meshVertex{Pos: positions[pi], Color: colors[pi]}
+				if len(parts) > 1 && parts[1] != "" {
+					ti, err := index(parts[1], len(uvs))
+					if err != nil {
+						return nil, fmt.Errorf("line %d: %w", line, err)
+					}
+					mv.UV = uvs[ti]
+				} else {
+					hasUV = false
+				}
+				verts = append(verts, mv)
+			}
+			for i := 1; i+1 < len(verts); i++ {
+				t := meshTriangle{V: [3]meshVertex{verts[0], verts[i], verts[i+1]}}
+				switch {
+				case current != nil && current.Texture != nil && hasUV:
+					t.Texture = current.Texture
+					t.HasColor = true
+				case vertColor:
+					t.HasColor = true
+				case current != nil && current.HasColor:
+					for j := range t.V {
+						t.V[j].Color = current.Color
+					}
+					t.HasColor = true
+				}
+				mesh.Triangles = append(mesh.Triangles, t)
+			}
+		case "mtllib":
+			name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "mtllib"))
+			if err := ms.parseMTL(dir, name, materials); err != nil {
+				// A missing material library only loses the colors
+				ms.logger.Warn().Err(err).Str("mtllib", name).Msg("Failed to load OBJ material library")
+			}
+		case "usemtl":
+			current = nil
+			if len(fields) > 1 {
+				current = materials[fields[1]]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mesh, nil
+}
+
+// parseMTL loads the materials of a MTL file into materials.
+func (ms *MinecraftServer) parseMTL(dir, name string, materials map[string]*objMaterial) error {
+	data, err := ms.readAssetFile(filepath.Join(dir, filepath.FromSlash(name)), maxModelFileSize)
+	if err != nil {
+		return err
+	}
+	var current *objMaterial
+	scanner := newLineScanner(data)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "newmtl":
+			current = &objMaterial{}
+			materials[fields[1]] = current
+		case "Kd":
+			if current == nil {
+				continue
+			}
+			if v, err := parseFloats(fields[1:]); err == nil && len(v) >= 3 {
+				current.Color = [3]float64{v[0] * 255, v[1] * 255, v[2] * 255}
+				current.HasColor = true
+			}
+		case "map_Kd":
+			if current == nil {
+				continue
+			}
+			// Options like "-s 1 1 1" precede the file name
+			texture := fields[len(fields)-1]
+			img, err := ms.loadModelTexture(dir, texture)
+			if err != nil {
+				ms.logger.Warn().Err(err).Str("texture", texture).Msg("Failed to load OBJ texture")
+				continue
+			}
+			current.Texture = img
+		}
+	}
+	return scanner.Err()
+}
+
+// parseSTL parses a binary or ASCII STL file. STL has no colors and is conventionally Z-up.
+func parseSTL(data []byte) (*Mesh, error) {
+	mesh := &Mesh{UpAxis: "z"}
+	// Binary: 80 byte header, triangle count, 50 bytes per triangle. Some binary files
+	// start with "solid" too, so the size is checked first.
+	if len(data) >= 84 {
+		n := int(binary.LittleEndian.Uint32(data[80:84]))
+		if len(data) == 84+50*n {
+			for i := 0; i < n; i++ {
+				rec := data[84+50*i:]
+				var t meshTriangle
+				for v := 0; v < 3; v++ {
+					for a := 0; a < 3; a++ {
+						bits := binary.LittleEndian.Uint32(rec[12+v*12+a*4:])
+						t.V[v].Pos[a] = float64(math.Float32frombits(bits))
+					}
+					if !t.V[v].Pos.IsFinite() {
+						return nil, fmt.Errorf("triangle %d has a non-finite vertex", i)
+					}
+				}
+				mesh.Triangles = append(mesh.Triangles, t)
+			}
+			return mesh, nil
+		}
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("solid")) {
+		return nil, fmt.Errorf("not a valid STL file")
+	}
+
+	var verts []Vec3
+	scanner := newLineScanner(data)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "vertex":
+			v, err := parseFloats(fields[1:])
+			if err != nil || len(v) != 3 {
+				return nil, fmt.Errorf("invalid STL vertex: %s", scanner.Text())
+			}
+			verts = append(verts, Vec3{v[0], v[1], v[2]})
+		case "endloop":
+			for i := 1; i+1 < len(verts); i++ {
+				var t meshTriangle
+				t.V[0].Pos, t.V[1].Pos, t.V[2].Pos = verts[0], verts[i], verts[i+1]
+				mesh.Triangles = append(mesh.Triangles, t)
+			}
+			verts = verts[:0]
+		}
+	}
+	return mesh, scanner.Err()
+}
+
+// gltfDocument is the subset of a glTF 2.0 document needed to extract triangles.
+type gltfDocument struct {
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes []struct {
+		Mesh        *int      `json:"mesh"`
+		Children    []int     `json:"children"`
+		Matrix      []float64 `json:"matrix"`
+		Translation []float64 `json:"translation"`
+		Rotation    []float64 `json:"rotation"`
+		Scale       []float64 `json:"scale"`
+	} `json:"nodes"`
+	Meshes []struct {
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Material   *int           `json:"material"`
+			Mode       *int           `json:"mode"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Materials []struct {
+		PbrMetallicRoughness struct {
+			BaseColorFactor  []float64 `json:"baseColorFactor"`
+			BaseColorTexture *struct {
+				Index    int `json:"index"`
+				TexCoord int `json:"texCoord"`
+			} `json:"baseColorTexture"`
+		} `json:"pbrMetallicRoughness"`
+	} `json:"materials"`
+	Textures []struct {
+		Source *int `json:"source"`
+	} `json:"textures"`
+	Images []struct {
+		URI        string `json:"uri"`
+		BufferView *int   `json:"bufferView"`
+	} `json:"images"`
+	Accessors []struct {
+		BufferView    *int   `json:"bufferView"`
+		ByteOffset    int    `json:"byteOffset"`
+		ComponentType int    `json:"componentType"`
+		Count         int    `json:"count"`
+		Type          string `json:"type"`
+		Normalized    bool   `json:"normalized"`
+	} `json:"accessors"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+	Buffers []struct {
+		URI string `json:"uri"`
+	} `json:"buffers"`
+}
+
+// gltfMatrix is a column-major 4x4 matrix.
+type gltfMatrix [16]float64
+
+var gltfIdentity = gltfMatrix{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+
+func (m gltfMatrix) mul(o gltfMatrix) gltfMatrix {
+	var r gltfMatrix
+	for c := 0; c < 4; c++ {
+		for row := 0; row < 4; row++ {
+			for k := 0; k < 4; k++ {
+				r[c*4+row] += m[k*4+row] * o[c*4+k]
+			}
+		}
+	}
+	return r
+}
+
+func (m gltfMatrix) apply(v Vec3) Vec3 {
+	return Vec3{
+		m[0]*v[0] + m[4]*v[1] + m[8]*v[2] + m[12],
+		m[1]*v[0] + m[5]*v[1] + m[9]*v[2] + m[13],
+		m[2]*v[0] + m[6]*v[1] + m[10]*v[2] + m[14],
+	}
+}
+
+// gltfNodeMatrix returns the local transform of a node (matrix or translation * rotation * scale).
+func gltfNodeMatrix(matrix, t, r, s []float64) gltfMatrix {
+	if len(matrix) == 16 {
+		var m gltfMatrix
+		copy(m[:], matrix)
+		return m
+	}
+	m := gltfIdentity
+	if len(t) == 3 {
+		tm := gltfIdentity
+		tm[12], tm[13], tm[14] = t[0], t[1], t[2]
+		m = m.mul(tm)
+	}
+	if len(r) == 4 {
+		x, y, z, w := r[0], r[1], r[2], r[3]
+		rm := gltfMatrix{
+			1 - 2*(y*y+z*z), 2 * (x*y + z*w), 2 * (x*z - y*w), 0,
+			2 * (x*y - z*w), 1 - 2*(x*x+z*z), 2 * (y*z + x*w), 0,
+			2 * (x*z + y*w), 2 * (y*z - x*w), 1 - 2*(x*x+y*y), 0,
+			0, 0, 0, 1,
+		}
+		m = m.mul(rm)
+	}
+	if len(s) == 3 {
+		sm := gltfIdentity
+		sm[0], sm[5], sm[10] = s[0], s[1], s[2]
+		m = m.mul(sm)
+	}
+	return m
+}
+
+// gltfTexture returns the image of texture index, decoded from a data URI, a buffer
+// view of a GLB file or a file next to the model.
+func (ms *MinecraftServer) gltfTexture(doc *gltfDocument, buffers [][]byte, dir string, index int) (image.Image, error) {
+	if index < 0 || index >= len(doc.Textures) || doc.Textures[index].Source == nil {
+		return nil, fmt.Errorf("invalid texture %d", index)
+	}
+	source := *doc.Textures[index].Source
+	if source < 0 || source >= len(doc.Images) {
+		return nil, fmt.Errorf("texture %d: invalid image %d", index, source)
+	}
+	img := doc.Images[source]
+	var data []byte
+	switch {
+	case img.BufferView != nil:
+		bv := *img.BufferView
+		if bv < 0 || bv >= len(doc.BufferViews) {
+			return nil, fmt.Errorf("image %d: invalid buffer view", source)
+		}
+		view := doc.BufferViews[bv]
+		if view.Buffer < 0 || view.Buffer >= len(buffers) {
+			return nil, fmt.Errorf("image %d: invalid buffer", source)
+		}
+		buf := buffers[view.Buffer]
+		if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset > len(buf) || view.ByteLength > len(buf)-view.ByteOffset {
+			return nil, fmt.Errorf("image %d: buffer view out of range", source)
+		}
+		data = buf[view.ByteOffset : view.ByteOffset+view.ByteLength]
+	case strings.HasPrefix(img.URI, "data:"):
+		idx := strings.Index(img.URI, ";base64,")
+		if idx < 0 {
+			return nil, fmt.Errorf("image %d: unsupported data URI", source)
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(img.URI[idx+len(";base64,"):]); err != nil {
+			return nil, fmt.Errorf("image %d: %w", source, err)
+		}
+	case img.URI != "":
+		return ms.loadModelTexture(dir, img.URI)
+	default:
+		return nil, fmt.Errorf("image %d has no data", source)
+	}
+	decoded, _, err := decodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", source, err)
+	}
+	return decoded, nil
+}
+
+// parseGLTF parses a glTF 2.0 (.gltf with embedded or external buffers, or binary .glb)
+// model. Colors are sampled from the base color texture of the material, otherwise
+// they come from the COLOR_0 attribute and the base color factor.
+func (ms *MinecraftServer) parseGLTF(data []byte, dir string) (*Mesh, error) {
+	jsonChunk, binChunk := data, []byte(nil)
+	if len(data) >= 12 && string(data[:4]) == "glTF" {
+		if binary.LittleEndian.Uint32(data[4:8]) != 2 {
+			return nil, fmt.Errorf("unsupported GLB version %d", binary.LittleEndian.Uint32(data[4:8]))
+		}
+		jsonChunk = nil
+		for off := 12; off+8 <= len(data); {
+			length := int(binary.LittleEndian.Uint32(data[off:]))
+			kind := binary.LittleEndian.Uint32(data[off+4:])
+			if length < 0 || off+8+length > len(data) {
+				return nil, fmt.Errorf("truncated GLB chunk")
+			}
+			chunk := data[off+8 : off+8+length]
+			switch kind {
+			case 0x4E4F534A: // JSON
+				jsonChunk = chunk
+			case 0x004E4942: // BIN
+				binChunk = chunk
+			}
+			off += 8 + length
+		}
+		if jsonChunk == nil {
+			return nil, fmt.Errorf("GLB file has no JSON chunk")
+		}
+	}
+
+	var doc gltfDocument
+	if err := json.Unmarshal(jsonChunk, &doc); err != nil {
+		return nil, fmt.Errorf("invalid glTF JSON: %w", err)
+	}
+
+	buffers := make([][]byte, len(doc.Buffers))
+	for i, b := range doc.Buffers {
+		switch {
+		case b.URI == "":
+			if binChunk == nil {
+				return nil, fmt.Errorf("buffer %d has no data", i)
+			}
+			buffers[i] = binChunk
+		case strings.HasPrefix(b.URI, "data:"):
+			idx := strings.Index(b.URI, ";base64,")
+			if idx < 0 {
+				return nil, fmt.Errorf("buffer %d: unsupported data URI", i)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(b.URI[idx+len(";base64,"):])
+			if err != nil {
+				return nil, fmt.Errorf("buffer %d: %w", i, err)
+			}
+			buffers[i] = decoded
+		default:
+			raw, err := ms.readAssetFile(filepath.Join(dir, filepath.FromSlash(b.URI)), maxModelFileSize)
+			if err != nil {
+				return nil, fmt.Errorf("buffer %d: %w", i, err)
+			}
+			buffers[i] = raw
+		}
+	}
+
+	mesh := &Mesh{UpAxis: "y"}
+	textures := make(map[int]image.Image)
+	addMesh := func(mi int, m gltfMatrix) error {
+		if mi < 0 || mi >= len(doc.Meshes) {
+			return fmt.Errorf("invalid mesh index %d", mi)
+		}
+		for _, prim := range doc.Meshes[mi].Primitives {
+			if prim.Mode != nil && *prim.Mode != 4 {
+				// Only triangle lists are supported, points and lines have no surface
+				continue
+			}
+			posIdx, ok := prim.Attributes["POSITION"]
+			if !ok {
+				continue
+			}
+			positions, err := readGLTFAccessor(&doc, buffers, posIdx)
+			if err != nil {
+				return err
+			}
+			factor, hasColor := [4]float64{1, 1, 1, 1}, false
+			var texture image.Image
+			var uvs [][]float64
+			if prim.Material != nil && *prim.Material >= 0 && *prim.Material < len(doc.Materials) {
+				pbr := doc.Materials[*prim.Material].PbrMetallicRoughness
+				if f := pbr.BaseColorFactor; len(f) == 4 {
+					copy(factor[:], f)
+					hasColor = true
+				}
+				if tex := pbr.BaseColorTexture; tex != nil {
+					if ti, ok := prim.Attributes[fmt.Sprintf("TEXCOORD_%d", tex.TexCoord)]; ok {
+						if texture = textures[tex.Index]; texture == nil {
+							if texture, err = ms.gltfTexture(&doc, buffers, dir, tex.Index); err != nil {
+								return err
+							}
+							textures[tex.Index] = texture
+						}
+						if uvs, err = readGLTFAccessor(&doc, buffers, ti); err != nil {
+							return err
+						}
+						hasColor = true
+					}
+				}
+			}
+			var colors [][]float64
+			if ci, ok := prim.Attributes["COLOR_0"]; ok {
+				if colors, err = readGLTFAccessor(&doc, buffers, ci); err != nil {
+					return err
+				}
+				hasColor = true
+			}
+			var indices []int
+			if prim.Indices != nil {
+				raw, err := readGLTFAccessor(&doc, buffers, *prim.Indices)
+				if err != nil {
+					return err
+				}
+				indices = make([]int, len(raw))
+				for i, v := range raw {
+					indices[i] = int(v[0])
+				}
+			} else {
+				indices = make([]int, len(positions))
+				for i := range indices {
+					indices[i] = i
+				}
+			}
+			for i := 0; i+2 < len(indices); i += 3 {
+				t := meshTriangle{HasColor: hasColor}
+				for v := 0; v < 3; v++ {
+					vi := indices[i+v]
+					if vi < 0 || vi >= len(positions) || len(positions[vi]) < 3 {
+						return fmt.Errorf("vertex index %d out of range", vi)
+					}
+					p := positions[vi]
+					t.V[v].Pos = m.apply(Vec3{p[0], p[1], p[2]})
+					if !t.V[v].Pos.IsFinite() {
+						return fmt.Errorf("vertex %d is not finite after the node transform", vi)
+					}
+					if texture != nil && vi < len(uvs) && len(uvs[vi]) >= 2 {
+						// glTF puts the texture origin at the top left, sampleTexture at the bottom left
+						t.Texture = texture
+						t.V[v].UV = [2]float64{uvs[vi][0], 1 - uvs[vi][1]}
+					}
+					t.V[v].Color = [3]float64{factor[0] * 255, factor[1] * 255, factor[2] * 255}
+					if vi < len(colors) && len(colors[vi]) >= 3 {
+						for c := 0; c < 3; c++ {
+							t.V[v].Color[c] *= colors[vi][c]
+						}
+					}
+				}
+				mesh.Triangles = append(mesh.Triangles, t)
+			}
+		}
+		return nil
+	}
+
+	var walk func(ni int, parent gltfMatrix, depth int) error
+	walk = func(ni int, parent gltfMatrix, depth int) error {
+		if ni < 0 || ni >= len(doc.Nodes) || depth > 64 {
+			return fmt.Errorf("invalid node hierarchy at node %d", ni)
+		}
+		n := doc.Nodes[ni]
+		m := parent.mul(gltfNodeMatrix(n.Matrix, n.Translation, n.Rotation, n.Scale))
+		if n.Mesh != nil {
+			if err := addMesh(*n.Mesh, m); err != nil {
+				return err
+			}
+		}
+		for _, c := range n.Children {
+			if err := walk(c, m, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(doc.Scenes) == 0 {
+		// No scene: use all meshes untransformed
+		for i := range doc.Meshes {
+			if err := addMesh(i, gltfIdentity); err != nil {
+				return nil, err
+			}
+		}
+		return mesh, nil
+	}
+	scene := 0
+	if doc.Scene != nil {
+		scene = *doc.Scene
+	}
+	if scene < 0 || scene >= len(doc.Scenes) {
+		return nil, fmt.Errorf("invalid scene %d", scene)
+	}
+	for _, ni := range doc.Scenes[scene].Nodes {
+		if err := walk(ni, gltfIdentity, 0); err != nil {
+			return nil, err
+		}
+	}
+	return mesh, nil
+}
+
+// gltfTypeSize is the number of components of each accessor type.
+var gltfTypeSize = map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4}
+
+// readGLTFAccessor returns the elements of an accessor as floats. Normalized integer
+// components are mapped to 0-1.
+func readGLTFAccessor(doc *gltfDocument, buffers [][]byte, index int) ([][]float64, error) {
+	if index < 0 || index >= len(doc.Accessors) {
+		return nil, fmt.Errorf("invalid accessor %d", index)
+	}
+	acc := doc.Accessors[index]
+	if acc.BufferView == nil {
+		return nil, fmt.Errorf("accessor %d: sparse accessors are not supported", index)
+	}
+	if *acc.BufferView < 0 || *acc.BufferView >= len(doc.BufferViews) {
+		return nil, fmt.Errorf("accessor %d: invalid buffer view", index)
+	}
+	view := doc.BufferViews[*acc.BufferView]
+	if view.Buffer < 0 || view.Buffer >= len(buffers) {
+		return nil, fmt.Errorf("accessor %d: invalid buffer", index)
+	}
+	comps, ok := gltfTypeSize[acc.Type]
+	if !ok {
+		return nil, fmt.Errorf("accessor %d: unsupported type %s", index, acc.Type)
+	}
+	var compSize int
+	var maxValue float64
+	switch acc.ComponentType {
+	case 5120, 5121: // byte, unsigned byte
+		compSize, maxValue = 1, 255
+	case 5122, 5123: // short, unsigned short
+		compSize, maxValue = 2, 65535
+	case 5125: // unsigned int
+		compSize, maxValue = 4, math.MaxUint32
+	case 5126: // float
+		compSize = 4
+	default:
+		return nil, fmt.Errorf("accessor %d: unsupported component type %d", index, acc.ComponentType)
+	}
+	if acc.ComponentType == 5120 {
+		maxValue = 127
+	} else if acc.ComponentType == 5122 {
+		maxValue = 32767
+	}
+
+	elemSize := comps * compSize
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = elemSize
+	}
+	if stride < elemSize {
+		return nil, fmt.Errorf("accessor %d: byte stride %d is smaller than the element size %d", index, stride, elemSize)
+	}
+	buf := buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset > len(buf) || view.ByteLength > len(buf)-view.ByteOffset {
+		return nil, fmt.Errorf("accessor %d: buffer view out of range", index)
+	}
+	buf = buf[view.ByteOffset : view.ByteOffset+view.ByteLength]
+	if acc.ByteOffset < 0 || acc.Count < 0 {
+		return nil, fmt.Errorf("accessor %d: negative byte offset or count", index)
+	}
+	// The last element ends at ByteOffset+(Count-1)*stride+elemSize, compared without
+	// overflowing on huge counts
+	if acc.Count > 0 && (acc.ByteOffset > len(buf)-elemSize || acc.Count-1 > (len(buf)-elemSize-acc.ByteOffset)/stride) {
+		return nil, fmt.Errorf("accessor %d: data out of range", index)
+	}
+
+	out := make([][]float64, acc.Count)
+	for i := range out {
+		el := make([]float64, comps)
+		for c := 0; c < comps; c++ {
+			b := buf[acc.ByteOffset+i*stride+c*compSize:]
+			var v float64
+			switch acc.ComponentType {
+			case 5120:
+				v = float64(int8(b[0]))
+			case 5121:
+				v = float64(b[0])
+			case 5122:
+				v = float64(int16(binary.LittleEndian.Uint16(b)))
+			case 5123:
+				v = float64(binary.LittleEndian.Uint16(b))
+			case 5125:
+				v = float64(binary.LittleEndian.Uint32(b))
+			case 5126:
+				v = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					return nil, fmt.Errorf("accessor %d: element %d is not finite", index, i)
+				}
+			}
+			if acc.Normalized && acc.ComponentType != 5126 {
+				v = math.Max(v/maxValue, -1)
+			}
+			el[c] = v
+		}
+		out[i] = el
+	}
+	return out, nil
+}
@@ -0,0 +1,629 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxLSystemLength is the longest expanded L-system string.
+	maxLSystemLength = 200000
+	// maxLSystemIterations is the largest number of rewriting steps.
+	maxLSystemIterations = 8
+	// maxGeneratedBlocks is the largest number of blocks a single generator may produce.
+	maxGeneratedBlocks = 100000
+	// maxFlowerFieldSize is the largest side of a flower field.
+	maxFlowerFieldSize = 128
+)
+
+// LSystem is a bracketed 3D L-system interpreted by a turtle that starts at the base of
+// the tree pointing up. Symbols:
+//
+//	F draw a log segment, f move without drawing, L leaf cluster
+//	+ - turn left/right, & ^ pitch down/up, \ / roll left/right, | turn around
+//	[ ] push/pop the turtle state, ! thinner branches, ' shorter segments
+type LSystem struct {
+	Axiom      string
+	Rules      map[byte]string
+	Iterations int
+	Angle      float64 // degrees
+	Step       float64 // blocks per F
+	TrunkWidth float64 // radius of the trunk in blocks
+	LeafRadius float64
+}
+
+// treePreset is a named tree type.
+type treePreset struct {
+	System LSystem
+	Log    string
+	Leaves string
+}
+
+// treePresets are the built-in tree types.
+var treePresets = map[string]treePreset{
+	"oak": {
+		System: LSystem{Axiom: "FFFFA", Rules: map[byte]string{'A': "[&FFLA]/////[&FFLA]///////[&FFLA]"},
+			Iterations: 3, Angle: 30, Step: 1, TrunkWidth: 0.5, LeafRadius: 2},
+		Log: "minecraft:oak_log", Leaves: "minecraft:oak_leaves",
+	},
+	"birch": {
+		System: LSystem{Axiom: "FFFFFA", Rules: map[byte]string{'A': "F[&L]//[&L]//[&L]FL"},
+			Iterations: 1, Angle: 20, Step: 1, TrunkWidth: 0.4, LeafRadius: 2},
+		Log: "minecraft:birch_log", Leaves: "minecraft:birch_leaves",
+	},
+	"spruce": {
+		System: LSystem{Axiom: "FFB", Rules: map[byte]string{'B': "F[&&&&FL]///[&&&&FL]///[&&&&FL]///[&&&&FL]'B"},
+			Iterations: 7, Angle: 22.5, Step: 1.5, TrunkWidth: 0.4, LeafRadius: 1.6},
+		Log: "minecraft:spruce_log", Leaves: "minecraft:spruce_leaves",
+	},
+	"jungle": {
+		System: LSystem{Axiom: "FFFFFFFFFFA", Rules: map[byte]string{'A': "[&&FFL!A]////[&&FFL!A]////[&&FFL!A]FL"},
+			Iterations: 2, Angle: 25, Step: 1, TrunkWidth: 1.2, LeafRadius: 3},
+		Log: "minecraft:jungle_log", Leaves: "minecraft:jungle_leaves",
+	},
+}
+
+// parseLSystemRules parses rules like "A=F[+A][-A];F=FF" (separated by ';' or new lines).
+func parseLSystemRules(s string) (map[byte]string, error) {
+	rules := make(map[byte]string)
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		k = strings.TrimSpace(k)
+		if !ok || len(k) != 1 {
+			return nil, fmt.Errorf("invalid rule %q, expected X=replacement", part)
+		}
+		rules[k[0]] = strings.ReplaceAll(strings.TrimSpace(v), " ", "")
+	}
+	return rules, nil
+}
+
+// Expand applies the rules Iterations times to the axiom.
+func (l *LSystem) Expand() (string, error) {
+	s := l.Axiom
+	for i := 0; i < l.Iterations; i++ {
+		var sb strings.Builder
+		for j := 0; j < len(s); j++ {
+			if r, ok := l.Rules[s[j]]; ok {
+				sb.WriteString(r)
+			} else {
+				sb.WriteByte(s[j])
+			}
+			if sb.Len() > maxLSystemLength {
+				return "", fmt.Errorf("L-system grows longer than %d symbols after %d iterations", maxLSystemLength, i+1)
+			}
+		}
+		s = sb.String()
+	}
+	return s, nil
+}
+
+// turtleState is the position and orientation (heading, left, up) of the turtle.
+type turtleState struct {
+	pos, h, l, u Vec3
+	width, step  float64
+}
+
+// rotate turns the vectors a and b by angle degrees in their plane.
+func rotate(a, b Vec3, angle float64) (Vec3, Vec3) {
+	s, c := math.Sincos(angle * math.Pi / 180)
+	var na, nb Vec3
+	for i := range a {
+		na[i] = a[i]*c + b[i]*s
+		nb[i] = -a[i]*s + b[i]*c
+	}
+	return na, nb
+}
+
+// Grow interprets the expanded L-system. The base of the trunk is at 0, 0, 0. Rotation
+// angles are jittered by rng, so different seeds give different trees of the same type.
+func (l *LSystem) Grow(rng *rand.Rand, log, leaves string) (BlockGrid, error) {
+	symbols, err := l.Expand()
+	if err != nil {
+		return nil, err
+	}
+	grid := make(BlockGrid)
+	t := turtleState{pos: Vec3{0.5, 0, 0.5}, h: Vec3{0, 1, 0}, l: Vec3{-1, 0, 0}, u: Vec3{0, 0, 1}, width: l.TrunkWidth, step: l.Step}
+	var stack []turtleState
+	jitter := func() float64 { return l.Angle * (1 + (rng.Float64()-0.5)*0.4) }
+
+	for i := 0; i < len(symbols); i++ {
+		switch symbols[i] {
+		case 'F', 'f':
+			end := Vec3{t.pos[0] + t.h[0]*t.step, t.pos[1] + t.h[1]*t.step, t.pos[2] + t.h[2]*t.step}
+			if symbols[i] == 'F' {
+				placeSegment(grid, t.pos, end, t.width, logState(log, t.h))
+			}
+			t.pos = end
+		case 'L':
+			placeLeaves(grid, rng, t.pos, l.LeafRadius, leavesState(leaves))
+		case '+':
+			t.h, t.l = rotate(t.h, t.l, jitter())
+		case '-':
+			t.h, t.l = rotate(t.h, t.l, -jitter())
+		case '&':
+			t.h, t.u = rotate(t.h, t.u, -jitter())
+		case '^':
+			t.h, t.u = rotate(t.h, t.u, jitter())
+		case '\\':
+			t.l, t.u = rotate(t.l, t.u, jitter())
+		case '/':
+			t.l, t.u = rotate(t.l, t.u, -jitter())
+		case '|':
+			t.h, t.l = rotate(t.h, t.l, 180)
+		case '!':
+			t.width *= 0.7
+		case '\'':
+			t.step *= 0.8
+		case '[':
+			stack = append(stack, t)
+		case ']':
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("unbalanced ']' in L-system")
+			}
+			t, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		}
+		if len(grid) > maxGeneratedBlocks {
+			return nil, fmt.Errorf("tree has more than %d blocks", maxGeneratedBlocks)
+		}
+	}
+	return grid, nil
+}
+
+// placeSegment rasterizes a log segment of the given radius from a to b.
+func placeSegment(grid BlockGrid, a, b Vec3, width float64, block string) {
+	length := math.Sqrt((b[0]-a[0])*(b[0]-a[0]) + (b[1]-a[1])*(b[1]-a[1]) + (b[2]-a[2])*(b[2]-a[2]))
+	steps := max(1, int(math.Ceil(length*4)))
+	r := int(math.Ceil(width))
+	for s := 0; s <= steps; s++ {
+		f := float64(s) / float64(steps)
+		p := Vec3{a[0] + (b[0]-a[0])*f, a[1] + (b[1]-a[1])*f, a[2] + (b[2]-a[2])*f}
+		c := p.BlockPos()
+		if width < 0.75 {
+			grid.Set(c[0], c[1], c[2], block)
+			continue
+		}
+		for dy := -r; dy <= r; dy++ {
+			for dz := -r; dz <= r; dz++ {
+				for dx := -r; dx <= r; dx++ {
+					bx, by, bz := c[0]+dx, c[1]+dy, c[2]+dz
+					ex, ey, ez := float64(bx)+0.5-p[0], float64(by)+0.5-p[1], float64(bz)+0.5-p[2]
+					if ex*ex+ey*ey+ez*ez <= width*width {
+						grid.Set(bx, by, bz, block)
+					}
+				}
+			}
+		}
+	}
+}
+
+// placeLeaves adds a roughly spherical leaf cluster without replacing logs.
+func placeLeaves(grid BlockGrid, rng *rand.Rand, center Vec3, radius float64, block string) {
+	c := center.BlockPos()
+	r := int(math.Ceil(radius))
+	for dy := -r; dy <= r; dy++ {
+		for dz := -r; dz <= r; dz++ {
+			for dx := -r; dx <= r; dx++ {
+				d := math.Sqrt(float64(dx*dx + dy*dy + dz*dz))
+				if d > radius+0.3 || (d > radius-0.7 && rng.Float64() < 0.4) {
+					continue
+				}
+				grid.SetIfEmpty(c[0]+dx, c[1]+dy, c[2]+dz, block)
+			}
+		}
+	}
+}
+
+// logState orients log-like blocks along the dominant axis of the heading.
+func logState(block string, h Vec3) string {
+	if strings.Contains(block, "[") {
+		return block
+	}
+	oriented := false
+	for _, suffix := range []string{"_log", "_wood", "_stem", "_hyphae"} {
+		oriented = oriented || strings.HasSuffix(block, suffix)
+	}
+	if !oriented {
+		return block
+	}
+	axis := "y"
+	if ax, az := math.Abs(h[0]), math.Abs(h[2]); ax > math.Abs(h[1]) && ax >= az {
+		axis = "x"
+	} else if az > math.Abs(h[1]) && az > ax {
+		axis = "z"
+	}
+	return block + "[axis=" + axis + "]"
+}
+
+// leavesState makes leaves persistent, so they do not decay away from generated logs.
+func leavesState(block string) string {
+	if strings.HasSuffix(block, "_leaves") && !strings.Contains(block, "[") {
+		return block + "[persistent=true]"
+	}
+	return block
+}
+
+// boulderGrid generates a lumpy ellipsoid of the given radius, a third of it below y 0.
+func boulderGrid(rng *rand.Rand, radius float64, blocks []string) BlockGrid {
+	rx := radius * (0.8 + rng.Float64()*0.4)
+	ry := radius * (0.6 + rng.Float64()*0.3)
+	rz := radius * (0.8 + rng.Float64()*0.4)
+	cy := ry * 0.4
+	// A few random waves deform the surface
+	type wave struct {
+		dir           Vec3
+		amp, k, phase float64
+	}
+	waves := make([]wave, 3)
+	for i := range waves {
+		d := Vec3{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+		n := math.Sqrt(d[0]*d[0]+d[1]*d[1]+d[2]*d[2]) + 1e-9
+		waves[i] = wave{Vec3{d[0] / n, d[1] / n, d[2] / n}, 0.08 + rng.Float64()*0.1, 2 + rng.Float64()*3, rng.Float64() * 2 * math.Pi}
+	}
+
+	grid := make(BlockGrid)
+	r := int(math.Ceil(radius*1.4)) + 1
+	for y := -r; y <= r; y++ {
+		for z := -r; z <= r; z++ {
+			for x := -r; x <= r; x++ {
+				dx, dy, dz := (float64(x)+0.5)/rx, (float64(y)+0.5-cy)/ry, (float64(z)+0.5)/rz
+				d := math.Sqrt(dx*dx + dy*dy + dz*dz)
+				limit := 1.0
+				if d > 0 {
+					for _, w := range waves {
+						limit += w.amp * math.Sin(w.k*(dx*w.dir[0]+dy*w.dir[1]+dz*w.dir[2])/d+w.phase)
+					}
+				}
+				if d <= limit {
+					grid.Set(x, y, z, blocks[rng.Intn(len(blocks))])
+				}
+			}
+		}
+	}
+	return grid
+}
+
+// flowerField scatters flowers over a width x depth area in patches of the same kind.
+// It returns the flower for every planted column.
+func flowerField(rng *rand.Rand, width, depth int, density float64, flowers []string) map[[2]int]string {
+	type patch struct {
+		x, z   float64
+		flower string
+	}
+	patches := make([]patch, max(1, width*depth/64))
+	for i := range patches {
+		patches[i] = patch{rng.Float64() * float64(width), rng.Float64() * float64(depth), flowers[rng.Intn(len(flowers))]}
+	}
+	field := make(map[[2]int]string)
+	for z := 0; z < depth; z++ {
+		for x := 0; x < width; x++ {
+			if rng.Float64() >= density {
+				continue
+			}
+			best, bestDist := 0, math.MaxFloat64
+			for i, p := range patches {
+				if d := (p.x-float64(x))*(p.x-float64(x)) + (p.z-float64(z))*(p.z-float64(z)); d < bestDist {
+					best, bestDist = i, d
+				}
+			}
+			field[[2]int{x, z}] = patches[best].flower
+		}
+	}
+	return field
+}
+
+// isSoilBlock reports whether plants can grow on the block.
+func isSoilBlock(state string) bool {
+	switch blockName(state) {
+	case "minecraft:grass_block", "minecraft:dirt", "minecraft:coarse_dirt", "minecraft:podzol",
+		"minecraft:rooted_dirt", "minecraft:moss_block", "minecraft:mycelium", "minecraft:farmland":
+		return true
+	}
+	return false
+}
+
+// defaultFlowers are planted when no flowers are given.
+var defaultFlowers = []string{
+	"minecraft:dandelion", "minecraft:poppy", "minecraft:azure_bluet", "minecraft:oxeye_daisy",
+	"minecraft:cornflower", "minecraft:red_tulip", "minecraft:white_tulip", "minecraft:allium",
+}
+
+// getBlockListArg returns a comma separated list of block IDs, or def when empty.
+func getBlockListArg(args map[string]interface{}, key string, def []string) ([]string, error) {
+	s, err := getStringArg(args, key, false)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(s) == "" {
+		return def, nil
+	}
+	var blocks []string
+	for _, b := range strings.Split(s, ",") {
+		b = strings.TrimSpace(b)
+		if err := validateBlockID(b); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// getSeedArg returns the seed argument, or a random seed that is reported back so the
+// result can be reproduced.
+func getSeedArg(args map[string]interface{}) (int64, error) {
+	if _, ok := args["seed"]; !ok {
+		return time.Now().UnixNano() % 1000000000, nil
+	}
+	seed, err := getIntArg(args, "seed", 0)
+	return int64(seed), err
+}
+
+// registerNatureTools adds the tree, boulder and flower generators.
+func (ms *MinecraftServer) registerNatureTools() {
+	names := make([]string, 0, len(treePresets))
+	for n := range treePresets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_generate_tree",
+		mcp.WithDescription("Grow a procedural tree from an L-system grammar. Built-in types: "+strings.Join(names, ", ")+", or 'custom' with your own axiom and rules. The same seed always gives the same tree. Without y the tree is planted on the ground read from the world save."),
+		mcp.WithString("x", mcp.Description("X coordinate of the trunk base"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate of the trunk base (optional, default: on top of the ground)")),
+		mcp.WithString("z", mcp.Description("Z coordinate of the trunk base"), mcp.Required()),
+		mcp.WithString("type", mcp.Description("Tree type: "+strings.Join(names, ", ")+" or custom (optional, default: oak)")),
+		mcp.WithString("axiom", mcp.Description("Custom L-system axiom, e.g. FFFA (symbols: F log, f move, L leaves, + - & ^ \\ / | turn, [ ] branch, ! thinner, ' shorter)")),
+		mcp.WithString("rules", mcp.Description("Custom L-system rules separated by ';', e.g. A=[&FLA]////[&FLA]")),
+		mcp.WithNumber("iterations", mcp.Description(fmt.Sprintf("Rewriting steps (optional, max: %d)", maxLSystemIterations))),
+		mcp.WithNumber("angle", mcp.Description("Turning angle in degrees (optional)")),
+		mcp.WithNumber("length", mcp.Description("Blocks per F segment (optional)")),
+		mcp.WithNumber("trunkWidth", mcp.Description("Trunk radius in blocks (optional)")),
+		mcp.WithNumber("leafRadius", mcp.Description("Radius of leaf clusters in blocks (optional)")),
+		mcp.WithString("log", mcp.Description("Log block (optional, default depends on the type)")),
+		mcp.WithString("leaves", mcp.Description("Leaves block (optional, default depends on the type)")),
+		mcp.WithNumber("seed", mcp.Description("Random seed for reproducible results (optional)")),
+		mcp.WithString("dimension", mcp.Description("Dimension used to read the ground (optional, default: overworld)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleGenerateTree)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_generate_boulder",
+		mcp.WithDescription("Generate a natural looking boulder partly sunk into the ground. Without y it is placed on the ground read from the world save."),
+		mcp.WithString("x", mcp.Description("X coordinate of the center"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate of the base (optional, default: on top of the ground)")),
+		mcp.WithString("z", mcp.Description("Z coordinate of the center"), mcp.Required()),
+		mcp.WithNumber("radius", mcp.Description("Radius in blocks, 1 to 8 (optional, default: 3)")),
+		mcp.WithString("blocks", mcp.Description("Comma separated blocks mixed randomly (optional, default: stone, cobblestone, andesite, mossy cobblestone)")),
+		mcp.WithNumber("seed", mcp.Description("Random seed for reproducible results (optional)")),
+		mcp.WithString("dimension", mcp.Description("Dimension used to read the ground (optional, default: overworld)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleGenerateBoulder)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_generate_flowers",
+		mcp.WithDescription("Scatter patches of flowers over an area. Without y the flowers follow the terrain read from the world save and are only planted on grass, dirt and similar blocks."),
+		mcp.WithString("x1", mcp.Description("X coordinate of the first corner"), mcp.Required()),
+		mcp.WithString("z1", mcp.Description("Z coordinate of the first corner"), mcp.Required()),
+		mcp.WithString("x2", mcp.Description("X coordinate of the opposite corner"), mcp.Required()),
+		mcp.WithString("z2", mcp.Description("Z coordinate of the opposite corner"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate to plant at (optional, default: on top of the ground of every column)")),
+		mcp.WithNumber("density", mcp.Description("Fraction of columns with a flower, 0 to 1 (optional, default: 0.3)")),
+		mcp.WithString("flowers", mcp.Description("Comma separated flower blocks (optional, default: a mix of common flowers)")),
+		mcp.WithNumber("seed", mcp.Description("Random seed for reproducible results (optional)")),
+		mcp.WithString("dimension", mcp.Description("Dimension used to read the ground (optional, default: overworld)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleGenerateFlowers)
+}
+
+// handleGenerateTree implements the minecraft_generate_tree tool.
+func (ms *MinecraftServer) handleGenerateTree(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	kind, _ := getStringArg(args, "type", false)
+	if kind == "" {
+		kind = "oak"
+	}
+	preset, ok := treePresets[kind]
+	if !ok && kind != "custom" {
+		return mcp.NewToolResultError(fmt.Sprintf("unknown tree type: %s", kind)), nil
+	}
+	if kind == "custom" {
+		preset = treePreset{System: LSystem{Iterations: 3, Angle: 25, Step: 1, TrunkWidth: 0.5, LeafRadius: 2},
+			Log: "minecraft:oak_log", Leaves: "minecraft:oak_leaves"}
+		axiom, err := getStringArg(args, "axiom", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		preset.System.Axiom = axiom
+		rules, _ := getStringArg(args, "rules", false)
+		if preset.System.Rules, err = parseLSystemRules(rules); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	sys := preset.System
+	var err error
+	if sys.Iterations, err = getIntArg(args, "iterations", sys.Iterations); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if sys.Iterations < 0 || sys.Iterations > maxLSystemIterations {
+		return mcp.NewToolResultError(fmt.Sprintf("iterations must be between 0 and %d", maxLSystemIterations)), nil
+	}
+	for key, dst := range map[string]*float64{"angle": &sys.Angle, "length": &sys.Step, "trunkWidth": &sys.TrunkWidth, "leafRadius": &sys.LeafRadius} {
+		if *dst, err = getFloatArg(args, key, *dst); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	if sys.Step <= 0 || sys.Step > 8 || sys.TrunkWidth < 0 || sys.TrunkWidth > 4 || sys.LeafRadius < 0 || sys.LeafRadius > 8 {
+		return mcp.NewToolResultError("length must be in (0, 8], trunkWidth in [0, 4] and leafRadius in [0, 8]"), nil
+	}
+	log, _ := getStringArg(args, "log", false)
+	if log == "" {
+		log = preset.Log
+	}
+	leaves, _ := getStringArg(args, "leaves", false)
+	if leaves == "" {
+		leaves = preset.Leaves
+	}
+	for _, b := range []string{log, leaves} {
+		if err := validateBlockID(b); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	seed, err := getSeedArg(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	dimension, _ := getStringArg(args, "dimension", false)
+
+	origin, err := ms.groundPosition(args, "x", "y", "z", &terrain{ms: ms, dimension: dimension})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	grid, err := sys.Grow(rand.New(rand.NewSource(seed)), log, leaves)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// Trees only grow into air, they never replace existing blocks
+	commands, err := grid.CommandsWithMode(origin, ms.config.MaxFillVolume, "keep")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.runBuild(ctx, fmt.Sprintf("%s tree at %s (seed %d, %d blocks)", kind, origin, seed, len(grid)), commands, dryRun)
+}
+
+// handleGenerateBoulder implements the minecraft_generate_boulder tool.
+func (ms *MinecraftServer) handleGenerateBoulder(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	radius, err := getFloatArg(args, "radius", 3)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if radius < 1 || radius > 8 {
+		return mcp.NewToolResultError("radius must be between 1 and 8"), nil
+	}
+	blocks, err := getBlockListArg(args, "blocks", []string{"minecraft:stone", "minecraft:cobblestone", "minecraft:andesite", "minecraft:mossy_cobblestone"})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	seed, err := getSeedArg(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	dimension, _ := getStringArg(args, "dimension", false)
+
+	origin, err := ms.groundPosition(args, "x", "y", "z", &terrain{ms: ms, dimension: dimension})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	grid := boulderGrid(rand.New(rand.NewSource(seed)), radius, blocks)
+	commands, err := grid.Commands(origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.runBuild(ctx, fmt.Sprintf("boulder at %s (seed %d, %d blocks)", origin, seed, len(grid)), commands, dryRun)
+}
+
+// handleGenerateFlowers implements the minecraft_generate_flowers tool.
+func (ms *MinecraftServer) handleGenerateFlowers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	coords, err := ms.getCoordArgs(args, "x1", "z1", "x2", "z2")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var corners [4]Coord
+	for i, c := range coords {
+		corners[i], _ = ParseCoord(c)
+	}
+	if corners[0].Kind != corners[2].Kind || corners[1].Kind != corners[3].Kind || corners[0].Kind == CoordLocal || corners[1].Kind == CoordLocal {
+		return mcp.NewToolResultError("both corners must use the same notation (absolute or ~) per axis"), nil
+	}
+	x0, z0 := math.Floor(math.Min(corners[0].Value, corners[2].Value)), math.Floor(math.Min(corners[1].Value, corners[3].Value))
+	width := int(math.Abs(math.Floor(corners[0].Value)-math.Floor(corners[2].Value))) + 1
+	depth := int(math.Abs(math.Floor(corners[1].Value)-math.Floor(corners[3].Value))) + 1
+	if width > maxFlowerFieldSize || depth > maxFlowerFieldSize {
+		return mcp.NewToolResultError(fmt.Sprintf("the area is %dx%d, the limit is %d per side", width, depth, maxFlowerFieldSize)), nil
+	}
+
+	density, err := getFloatArg(args, "density", 0.3)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if density <= 0 || density > 1 {
+		return mcp.NewToolResultError("density must be between 0 and 1"), nil
+	}
+	flowers, err := getBlockListArg(args, "flowers", defaultFlowers)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	seed, err := getSeedArg(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	dimension, _ := getStringArg(args, "dimension", false)
+
+	field := flowerField(rand.New(rand.NewSource(seed)), width, depth, density, flowers)
+	grid := make(BlockGrid, len(field))
+	var origin Position
+	if y, _ := getStringArg(args, "y", false); y != "" {
+		yc, err := ParseCoord(y)
+		if err != nil || yc.Kind == CoordLocal {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid coordinate format for y: %s", y)), nil
+		}
+		origin = Position{{corners[0].Kind, x0}, yc, {corners[1].Kind, z0}}
+		for col, flower := range field {
+			grid.Set(col[0], 0, col[1], flower)
+		}
+	} else {
+		if corners[0].Kind != CoordAbsolute || corners[1].Kind != CoordAbsolute {
+			return mcp.NewToolResultError("following the terrain needs absolute coordinates, or pass y"), nil
+		}
+		t := &terrain{ms: ms, dimension: dimension}
+		if _, err := t.World(); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("cannot read the world save (pass y instead): %v", err)), nil
+		}
+		origin = Position{{CoordAbsolute, x0}, {CoordAbsolute, 0}, {CoordAbsolute, z0}}
+		for col, flower := range field {
+			ground, block, err := t.Ground(int(x0)+col[0], int(z0)+col[1])
+			if err != nil || !isSoilBlock(block) {
+				continue
+			}
+			grid.Set(col[0], ground+1, col[1], flower)
+		}
+	}
+	commands, err := grid.CommandsWithMode(origin, ms.config.MaxFillVolume, "keep")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.runBuild(ctx, fmt.Sprintf("flower field %dx%d (seed %d, %d flowers)", width, depth, seed, len(grid)), commands, dryRun)
+}
@@ -0,0 +1,107 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLSystem(t *testing.T) {
+	rules, err := parseLSystemRules("A=F[+A]; F = FF")
+	if err != nil {
+		t.Fatalf("parseLSystemRules failed: %v", err)
+	}
+	l := LSystem{Axiom: "A", Rules: rules, Iterations: 2}
+	if s, err := l.Expand(); err != nil || s != "FF[+F[+A]]" {
+		t.Errorf("Expand = %q, %v", s, err)
+	}
+	if _, err := parseLSystemRules("AB=F"); err == nil {
+		t.Errorf("multi-symbol predecessor should fail")
+	}
+
+	// A straight trunk of three logs with a leaf cluster on top
+	l = LSystem{Axiom: "FFFL", Step: 1, LeafRadius: 1}
+	grid, err := l.Grow(rand.New(rand.NewSource(1)), "minecraft:oak_log", "minecraft:oak_leaves")
+	if err != nil {
+		t.Fatalf("Grow failed: %v", err)
+	}
+	for y := 0; y < 3; y++ {
+		if b := grid[[3]int{0, y, 0}]; b != "minecraft:oak_log[axis=y]" {
+			t.Errorf("trunk at y %d = %q", y, b)
+		}
+	}
+	if b := grid[[3]int{0, 4, 0}]; b != "minecraft:oak_leaves[persistent=true]" {
+		t.Errorf("leaves = %q", b)
+	}
+
+	l = LSystem{Axiom: "F]"}
+	if _, err := l.Grow(rand.New(rand.NewSource(1)), "minecraft:oak_log", "minecraft:oak_leaves"); err == nil {
+		t.Errorf("unbalanced brackets should fail")
+	}
+}
+
+func TestGeneratorsAreReproducible(t *testing.T) {
+	oak := treePresets["oak"].System
+	a, err := oak.Grow(rand.New(rand.NewSource(42)), "minecraft:oak_log", "minecraft:oak_leaves")
+	if err != nil {
+		t.Fatalf("Grow failed: %v", err)
+	}
+	b, _ := oak.Grow(rand.New(rand.NewSource(42)), "minecraft:oak_log", "minecraft:oak_leaves")
+	c, _ := oak.Grow(rand.New(rand.NewSource(43)), "minecraft:oak_log", "minecraft:oak_leaves")
+	if !reflect.DeepEqual(a, b) || reflect.DeepEqual(a, c) {
+		t.Errorf("trees should only depend on the seed")
+	}
+
+	stones := []string{"minecraft:stone"}
+	if !reflect.DeepEqual(boulderGrid(rand.New(rand.NewSource(7)), 3, stones), boulderGrid(rand.New(rand.NewSource(7)), 3, stones)) {
+		t.Errorf("boulders should only depend on the seed")
+	}
+
+	field := flowerField(rand.New(rand.NewSource(7)), 20, 10, 0.5, defaultFlowers)
+	if len(field) < 50 || len(field) > 150 {
+		t.Errorf("unexpected number of flowers: %d", len(field))
+	}
+	if !reflect.DeepEqual(field, flowerField(rand.New(rand.NewSource(7)), 20, 10, 0.5, defaultFlowers)) {
+		t.Errorf("flower fields should only depend on the seed")
+	}
+}
+
+func TestMinecraftServer_groundPosition(t *testing.T) {
+	root := t.TempDir()
+	writeTestRegion(t, filepath.Join(root, "world"))
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root}}
+	tr := &terrain{ms: ms}
+
+	p, err := ms.groundPosition(map[string]interface{}{"x": "1", "z": "2"}, "x", "y", "z", tr)
+	if err != nil || p.String() != "1 -57 2" {
+		t.Errorf("groundPosition = %s, %v", p, err)
+	}
+	p, err = ms.groundPosition(map[string]interface{}{"x": "1", "y": "~", "z": "2"}, "x", "y", "z", tr)
+	if err != nil || p.String() != "1 ~ 2" {
+		t.Errorf("explicit y = %s, %v", p, err)
+	}
+	if _, err := ms.groundPosition(map[string]interface{}{"x": "~1", "z": "2"}, "x", "y", "z", tr); err == nil {
+		t.Errorf("relative x without y should fail")
+	}
+}
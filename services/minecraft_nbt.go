@@ -0,0 +1,286 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// NBT tag types.
+const (
+	nbtEnd byte = iota
+	nbtByte
+	nbtShort
+	nbtInt
+	nbtLong
+	nbtFloat
+	nbtDouble
+	nbtByteArray
+	nbtString
+	nbtList
+	nbtCompound
+	nbtIntArray
+	nbtLongArray
+)
+
+const (
+	// maxNBTDepth limits the nesting of compounds and lists.
+	maxNBTDepth = 512
+	// maxNBTArrayLen limits the length of a single array or list.
+	maxNBTArrayLen = 16 * 1024 * 1024
+)
+
+var ErrInvalidNBT = errors.New("invalid NBT data")
+
+// NBTCompound is a decoded compound tag. Values are int8, int16, int32, int64, float32,
+// float64, []byte, string, []interface{}, NBTCompound, []int32 or []int64.
+type NBTCompound map[string]interface{}
+
+// Compound returns the compound child key.
+func (c NBTCompound) Compound(key string) (NBTCompound, bool) {
+	v, ok := c[key].(NBTCompound)
+	return v, ok
+}
+
+// List returns the list child key.
+func (c NBTCompound) List(key string) []interface{} {
+	v, _ := c[key].([]interface{})
+	return v
+}
+
+// String returns the string child key.
+func (c NBTCompound) String(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+// Int returns the integer child key of any integer tag type.
+func (c NBTCompound) Int(key string) (int64, bool) {
+	switch v := c[key].(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// LongArray returns the long array child key.
+func (c NBTCompound) LongArray(key string) []int64 {
+	v, _ := c[key].([]int64)
+	return v
+}
+
+// ReadNBT decodes an uncompressed NBT stream with a named root compound.
+func ReadNBT(r io.Reader) (NBTCompound, error) {
+	d := &nbtDecoder{r: bufio.NewReader(r)}
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	if tag != nbtCompound {
+		return nil, fmt.Errorf("%w: root tag is %d, not a compound", ErrInvalidNBT, tag)
+	}
+	if _, err := d.string(); err != nil {
+		return nil, err
+	}
+	v, err := d.payload(nbtCompound, 0)
+	if err != nil {
+		return nil, err
+	}
+	return v.(NBTCompound), nil
+}
+
+type nbtDecoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func (d *nbtDecoder) read(n int) ([]byte, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:n]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNBT, err)
+	}
+	return d.buf[:n], nil
+}
+
+func (d *nbtDecoder) byte() (byte, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *nbtDecoder) int16() (int16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (d *nbtDecoder) int32() (int32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (d *nbtDecoder) int64() (int64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+func (d *nbtDecoder) length() (int, error) {
+	n, err := d.int32()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > maxNBTArrayLen {
+		return 0, fmt.Errorf("%w: invalid length %d", ErrInvalidNBT, n)
+	}
+	return int(n), nil
+}
+
+func (d *nbtDecoder) string() (string, error) {
+	n, err := d.int16()
+	if err != nil {
+		return "", err
+	}
+	// Lengths are unsigned 16 bit
+	b := make([]byte, uint16(n))
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidNBT, err)
+	}
+	// Modified UTF-8 only differs for NUL and supplementary characters, which block names do not use
+	return string(b), nil
+}
+
+func (d *nbtDecoder) payload(tag byte, depth int) (interface{}, error) {
+	if depth > maxNBTDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", ErrInvalidNBT)
+	}
+	switch tag {
+	case nbtByte:
+		b, err := d.byte()
+		return int8(b), err
+	case nbtShort:
+		return d.int16()
+	case nbtInt:
+		return d.int32()
+	case nbtLong:
+		return d.int64()
+	case nbtFloat:
+		v, err := d.int32()
+		return math.Float32frombits(uint32(v)), err
+	case nbtDouble:
+		v, err := d.int64()
+		return math.Float64frombits(uint64(v)), err
+	case nbtByteArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(d.r, b); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidNBT, err)
+		}
+		return b, nil
+	case nbtString:
+		return d.string()
+	case nbtList:
+		elem, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			v, err := d.payload(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case nbtCompound:
+		c := make(NBTCompound)
+		for {
+			t, err := d.byte()
+			if err != nil {
+				return nil, err
+			}
+			if t == nbtEnd {
+				return c, nil
+			}
+			name, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			if c[name], err = d.payload(t, depth+1); err != nil {
+				return nil, err
+			}
+		}
+	case nbtIntArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int32, 0, min(n, 4096))
+		for i := 0; i < n; i++ {
+			v, err := d.int32()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case nbtLongArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int64, 0, min(n, 4096))
+		for i := 0; i < n; i++ {
+			v, err := d.int64()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("%w: unknown tag type %d", ErrInvalidNBT, tag)
+}
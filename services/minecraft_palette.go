@@ -0,0 +1,266 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// BlockColor is a block together with its average (texture or map) color.
+type BlockColor struct {
+	Block   string
+	R, G, B uint8
+}
+
+// blockPalettes are the block colors an image or model color can be matched against.
+// Wool, concrete and terracotta use the average color of the block texture, "map"
+// uses the map color of each block (flat shade), which is what map art is judged by.
+var blockPalettes = map[string][]BlockColor{
+	"wool": {
+		{"minecraft:white_wool", 233, 236, 236},
+		{"minecraft:orange_wool", 240, 118, 19},
+		{"minecraft:magenta_wool", 189, 68, 179},
+		{"minecraft:light_blue_wool", 58, 175, 217},
+		{"minecraft:yellow_wool", 248, 197, 39},
+		{"minecraft:lime_wool", 112, 185, 25},
+		{"minecraft:pink_wool", 237, 141, 172},
+		{"minecraft:gray_wool", 62, 68, 71},
+		{"minecraft:light_gray_wool", 142, 142, 134},
+		{"minecraft:cyan_wool", 21, 137, 145},
+		{"minecraft:purple_wool", 121, 42, 172},
+		{"minecraft:blue_wool", 53, 57, 157},
+		{"minecraft:brown_wool", 114, 71, 40},
+		{"minecraft:green_wool", 84, 109, 27},
+		{"minecraft:red_wool", 160, 39, 34},
+		{"minecraft:black_wool", 20, 21, 25},
+	},
+	"concrete": {
+		{"minecraft:white_concrete", 207, 213, 214},
+		{"minecraft:orange_concrete", 224, 97, 0},
+		{"minecraft:magenta_concrete", 169, 48, 159},
+		{"minecraft:light_blue_concrete", 35, 137, 198},
+		{"minecraft:yellow_concrete", 240, 175, 21},
+		{"minecraft:lime_concrete", 94, 168, 24},
+		{"minecraft:pink_concrete", 213, 101, 142},
+		{"minecraft:gray_concrete", 54, 57, 61},
+		{"minecraft:light_gray_concrete", 125, 125, 115},
+		{"minecraft:cyan_concrete", 21, 119, 136},
+		{"minecraft:purple_concrete", 100, 31, 156},
+		{"minecraft:blue_concrete", 44, 46, 143},
+		{"minecraft:brown_concrete", 96, 59, 31},
+		{"minecraft:green_concrete", 73, 91, 36},
+		{"minecraft:red_concrete", 142, 32, 32},
+		{"minecraft:black_concrete", 8, 10, 15},
+	},
+	"terracotta": {
+		{"minecraft:terracotta", 152, 94, 67},
+		{"minecraft:white_terracotta", 209, 178, 161},
+		{"minecraft:orange_terracotta", 161, 83, 37},
+		{"minecraft:magenta_terracotta", 149, 88, 108},
+		{"minecraft:light_blue_terracotta", 113, 108, 137},
+		{"minecraft:yellow_terracotta", 186, 133, 35},
+		{"minecraft:lime_terracotta", 103, 117, 52},
+		{"minecraft:pink_terracotta", 161, 78, 78},
+		{"minecraft:gray_terracotta", 57, 42, 35},
+		{"minecraft:light_gray_terracotta", 135, 106, 97},
+		{"minecraft:cyan_terracotta", 86, 91, 91},
+		{"minecraft:purple_terracotta", 118, 70, 86},
+		{"minecraft:blue_terracotta", 74, 59, 91},
+		{"minecraft:brown_terracotta", 77, 51, 35},
+		{"minecraft:green_terracotta", 76, 83, 42},
+		{"minecraft:red_terracotta", 143, 61, 46},
+		{"minecraft:black_terracotta", 37, 22, 16},
+	},
+	"map": mapColorPalette(220, []BlockColor{
+		{"minecraft:grass_block", 127, 178, 56},
+		{"minecraft:birch_planks", 247, 233, 163},
+		{"minecraft:redstone_block", 255, 0, 0},
+		{"minecraft:packed_ice", 160, 160, 255},
+		{"minecraft:iron_block", 167, 167, 167},
+		{"minecraft:oak_leaves", 0, 124, 0},
+		{"minecraft:snow_block", 255, 255, 255},
+		{"minecraft:clay", 164, 168, 184},
+		{"minecraft:dirt", 151, 109, 77},
+		{"minecraft:stone", 112, 112, 112},
+		{"minecraft:oak_planks", 143, 119, 72},
+		{"minecraft:diorite", 255, 252, 245},
+		{"minecraft:orange_wool", 216, 127, 51},
+		{"minecraft:magenta_wool", 178, 76, 216},
+		{"minecraft:light_blue_wool", 102, 153, 216},
+		{"minecraft:yellow_wool", 229, 229, 51},
+		{"minecraft:lime_wool", 127, 204, 25},
+		{"minecraft:pink_wool", 242, 127, 165},
+		{"minecraft:gray_wool", 76, 76, 76},
+		{"minecraft:light_gray_wool", 153, 153, 153},
+		{"minecraft:cyan_wool", 76, 127, 153},
+		{"minecraft:purple_wool", 127, 63, 178},
+		{"minecraft:blue_wool", 51, 76, 178},
+		{"minecraft:brown_wool", 102, 76, 51},
+		{"minecraft:green_wool", 102, 127, 51},
+		{"minecraft:red_wool", 153, 51, 51},
+		{"minecraft:black_wool", 25, 25, 25},
+		{"minecraft:gold_block", 250, 238, 77},
+		{"minecraft:diamond_block", 92, 219, 213},
+		{"minecraft:lapis_block", 74, 128, 255},
+		{"minecraft:emerald_block", 0, 217, 58},
+		{"minecraft:spruce_planks", 129, 86, 49},
+		{"minecraft:netherrack", 112, 2, 0},
+		{"minecraft:white_terracotta", 209, 177, 161},
+		{"minecraft:orange_terracotta", 159, 82, 36},
+		{"minecraft:magenta_terracotta", 149, 87, 108},
+		{"minecraft:light_blue_terracotta", 112, 108, 138},
+		{"minecraft:yellow_terracotta", 186, 133, 36},
+		{"minecraft:lime_terracotta", 103, 117, 53},
+		{"minecraft:pink_terracotta", 160, 77, 78},
+		{"minecraft:gray_terracotta", 57, 41, 35},
+		{"minecraft:light_gray_terracotta", 135, 107, 98},
+		{"minecraft:cyan_terracotta", 87, 92, 92},
+		{"minecraft:purple_terracotta", 122, 73, 88},
+		{"minecraft:blue_terracotta", 76, 62, 92},
+		{"minecraft:brown_terracotta", 76, 50, 35},
+		{"minecraft:green_terracotta", 76, 82, 42},
+		{"minecraft:red_terracotta", 142, 60, 46},
+		{"minecraft:black_terracotta", 37, 22, 16},
+		{"minecraft:crimson_nylium", 189, 48, 49},
+		{"minecraft:crimson_planks", 148, 63, 97},
+		{"minecraft:crimson_hyphae", 92, 25, 29},
+		{"minecraft:warped_nylium", 22, 126, 134},
+		{"minecraft:warped_planks", 58, 142, 140},
+		{"minecraft:warped_hyphae", 86, 44, 62},
+		{"minecraft:warped_wart_block", 20, 180, 133},
+		{"minecraft:deepslate", 100, 100, 100},
+		{"minecraft:raw_iron_block", 216, 175, 147},
+	}),
+}
+
+// mapColorPalette applies a map shade (180, 220, 255 or 135) to base map colors.
+func mapColorPalette(shade int, base []BlockColor) []BlockColor {
+	shaded := make([]BlockColor, len(base))
+	for i, c := range base {
+		shaded[i] = BlockColor{
+			Block: c.Block,
+			R:     uint8(int(c.R) * shade / 255),
+			G:     uint8(int(c.G) * shade / 255),
+			B:     uint8(int(c.B) * shade / 255),
+		}
+	}
+	return shaded
+}
+
+// PaletteNames returns the names of the built-in palettes.
+func PaletteNames() []string {
+	names := make([]string, 0, len(blockPalettes))
+	for n := range blockPalettes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// labColor is a color in the CIE L*a*b* color space.
+type labColor [3]float64
+
+// Palette matches colors to the nearest block of one or more built-in palettes.
+type Palette struct {
+	colors []BlockColor
+	labs   []labColor
+	cache  map[[3]uint8]int
+}
+
+// NewPalette combines the comma separated built-in palettes in names (e.g. "wool,concrete").
+func NewPalette(names string) (*Palette, error) {
+	p := &Palette{cache: make(map[[3]uint8]int)}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		colors, ok := blockPalettes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown palette %q, available: %s", name, strings.Join(PaletteNames(), ", "))
+		}
+		for _, c := range colors {
+			if seen[c.Block] {
+				continue
+			}
+			seen[c.Block] = true
+			p.colors = append(p.colors, c)
+			p.labs = append(p.labs, rgbToLab(float64(c.R), float64(c.G), float64(c.B)))
+		}
+	}
+	return p, nil
+}
+
+// Nearest returns the index of the palette color closest to r, g, b (0-255 each).
+func (p *Palette) Nearest(r, g, b float64) int {
+	key := [3]uint8{clampByte(r), clampByte(g), clampByte(b)}
+	if i, ok := p.cache[key]; ok {
+		return i
+	}
+	lab := rgbToLab(float64(key[0]), float64(key[1]), float64(key[2]))
+	best, bestDist := 0, math.MaxFloat64
+	for i, l := range p.labs {
+		d := (lab[0]-l[0])*(lab[0]-l[0]) + (lab[1]-l[1])*(lab[1]-l[1]) + (lab[2]-l[2])*(lab[2]-l[2])
+		if d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	p.cache[key] = best
+	return best
+}
+
+// Color returns the palette entry at index i.
+func (p *Palette) Color(i int) BlockColor {
+	return p.colors[i]
+}
+
+// clampByte rounds v into the 0-255 range.
+func clampByte(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// rgbToLab converts an sRGB color (0-255 each) to CIE L*a*b* (D65).
+func rgbToLab(r, g, b float64) labColor {
+	lin := func(c float64) float64 {
+		c /= 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	rl, gl, bl := lin(r), lin(g), lin(b)
+	x := (rl*0.4124 + gl*0.3576 + bl*0.1805) / 0.95047
+	y := rl*0.2126 + gl*0.7152 + bl*0.0722
+	z := (rl*0.0193 + gl*0.1192 + bl*0.9505) / 1.08883
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Cbrt(t)
+		}
+		return 7.787*t + 16.0/116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return labColor{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
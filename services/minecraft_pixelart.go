@@ -0,0 +1,380 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultPixelArtSize is the longest side an image is scaled to when no size is given.
+	defaultPixelArtSize = 128
+	// maxPixelArtSize is the largest width or height of a pixel art build.
+	maxPixelArtSize = 512
+	// maxImageFileSize is the largest image file accepted.
+	maxImageFileSize = 32 * 1024 * 1024
+	// maxImagePixels is the largest width x height of an image that is decoded.
+	maxImagePixels = 16 * 1024 * 1024
+)
+
+// rgbaPixel is a pixel with non-premultiplied color channels in the 0-255 range.
+type rgbaPixel struct {
+	R, G, B, A float64
+}
+
+// resolveAssetPath returns the absolute path of an asset file, which must be inside AssetsPath.
+func (ms *MinecraftServer) resolveAssetPath(path string) (string, error) {
+	root, err := filepath.Abs(ms.config.AssetsPath)
+	if err != nil {
+		return "", err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", fmt.Errorf("assets directory %s is not accessible: %w", ms.config.AssetsPath, err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	abs, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("file %s is not accessible: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("access denied: %s is outside of the assets directory %s", path, root)
+	}
+	return abs, nil
+}
+
+// readAssetFile reads a file inside the assets directory that is at most limit bytes large.
+func (ms *MinecraftServer) readAssetFile(path string, limit int64) ([]byte, error) {
+	abs, err := ms.resolveAssetPath(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > limit {
+		return nil, fmt.Errorf("file %s is too large (%d bytes)", path, info.Size())
+	}
+	return os.ReadFile(abs)
+}
+
+// loadImageArg decodes the image given as "path" (inside the assets directory) or as "imageBase64".
+func (ms *MinecraftServer) loadImageArg(args map[string]interface{}) (image.Image, error) {
+	path, _ := getStringArg(args, "path", false)
+	encoded, _ := getStringArg(args, "imageBase64", false)
+
+	var data []byte
+	switch {
+	case path != "":
+		var err error
+		if data, err = ms.readAssetFile(path, maxImageFileSize); err != nil {
+			return nil, err
+		}
+	case encoded != "":
+		// Accept data URLs like "data:image/png;base64,...."
+		if i := strings.Index(encoded, ";base64,"); i >= 0 {
+			encoded = encoded[i+len(";base64,"):]
+		}
+		encoded = strings.TrimSpace(encoded)
+		if base64.StdEncoding.DecodedLen(len(encoded)) > maxImageFileSize {
+			return nil, fmt.Errorf("image is larger than the limit of %d bytes", maxImageFileSize)
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("invalid base64 image: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("either path or imageBase64 is required")
+	}
+
+	img, format, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	ms.logger.Debug().Str("format", format).Int("width", img.Bounds().Dx()).Int("height", img.Bounds().Dy()).Msg("Decoded image")
+	return img, nil
+}
+
+// decodeImage decodes an image after checking its header, so a small file declaring
+// huge dimensions is rejected before the pixels are allocated.
+func decodeImage(data []byte) (image.Image, string, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, "", fmt.Errorf("image size %dx%d exceeds the limit of %d pixels", cfg.Width, cfg.Height, maxImagePixels)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// pixelArtSize returns the target size of the build, keeping the aspect ratio if only one side is given.
+func pixelArtSize(srcW, srcH, width, height int) (int, int, error) {
+	switch {
+	case width <= 0 && height <= 0:
+		width, height = srcW, srcH
+		if longest := max(srcW, srcH); longest > defaultPixelArtSize {
+			width = max(1, srcW*defaultPixelArtSize/longest)
+			height = max(1, srcH*defaultPixelArtSize/longest)
+		}
+	case width <= 0:
+		width = max(1, srcW*height/srcH)
+	case height <= 0:
+		height = max(1, srcH*width/srcW)
+	}
+	if width > maxPixelArtSize || height > maxPixelArtSize {
+		return 0, 0, fmt.Errorf("pixel art size %dx%d exceeds the limit of %dx%d", width, height, maxPixelArtSize, maxPixelArtSize)
+	}
+	return width, height, nil
+}
+
+// resampleImage scales img to width x height by averaging the covered source pixels.
+func resampleImage(img image.Image, width, height int) [][]rgbaPixel {
+	b := img.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	out := make([][]rgbaPixel, height)
+	for y := 0; y < height; y++ {
+		out[y] = make([]rgbaPixel, width)
+		sy0, sy1 := y*srcH/height, max((y+1)*srcH/height, y*srcH/height+1)
+		for x := 0; x < width; x++ {
+			sx0, sx1 := x*srcW/width, max((x+1)*srcW/width, x*srcW/width+1)
+			var r, g, bl, a, n float64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					// RGBA returns alpha-premultiplied 16 bit values
+					pr, pg, pb, pa := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r += float64(pr)
+					g += float64(pg)
+					bl += float64(pb)
+					a += float64(pa)
+					n++
+				}
+			}
+			p := rgbaPixel{A: a / n / 257}
+			if a > 0 {
+				p.R, p.G, p.B = r/a*255, g/a*255, bl/a*255
+			}
+			out[y][x] = p
+		}
+	}
+	return out
+}
+
+// quantizeImage maps every pixel to a palette index, -1 for transparent pixels.
+// With dither the quantization error is spread with Floyd-Steinberg diffusion.
+func quantizeImage(pixels [][]rgbaPixel, palette *Palette, dither bool) [][]int {
+	height := len(pixels)
+	out := make([][]int, height)
+	if height == 0 {
+		return out
+	}
+	width := len(pixels[0])
+	// Working copy, so the error diffusion does not modify the input
+	work := make([][]rgbaPixel, height)
+	for y := range pixels {
+		work[y] = append([]rgbaPixel(nil), pixels[y]...)
+	}
+	spread := func(x, y int, er, eg, eb, f float64) {
+		if x < 0 || x >= width || y >= height || work[y][x].A < 128 {
+			return
+		}
+		work[y][x].R += er * f
+		work[y][x].G += eg * f
+		work[y][x].B += eb * f
+	}
+	for y := 0; y < height; y++ {
+		out[y] = make([]int, width)
+		for x := 0; x < width; x++ {
+			p := work[y][x]
+			if p.A < 128 {
+				out[y][x] = -1
+				continue
+			}
+			i := palette.Nearest(p.R, p.G, p.B)
+			out[y][x] = i
+			if !dither {
+				continue
+			}
+			c := palette.Color(i)
+			er, eg, eb := p.R-float64(c.R), p.G-float64(c.G), p.B-float64(c.B)
+			spread(x+1, y, er, eg, eb, 7.0/16)
+			spread(x-1, y+1, er, eg, eb, 3.0/16)
+			spread(x, y+1, er, eg, eb, 5.0/16)
+			spread(x+1, y+1, er, eg, eb, 1.0/16)
+		}
+	}
+	return out
+}
+
+// surfacePos maps the pixel at col, row (row 0 at the top) of an image with the given
+// height to a block position relative to the origin. Horizontal images lie flat with the
+// top row at the north (-Z) edge. Vertical images stand with the bottom row at the origin,
+// facing the given direction; the image reads left to right for a viewer standing in
+// front of it. layer moves the position behind a vertical image or above a horizontal one.
+func surfacePos(orientation, facing string, col, row, height, layer int) ([3]int, error) {
+	switch orientation {
+	case "horizontal":
+		return [3]int{col, layer, row}, nil
+	case "vertical":
+		up := height - 1 - row
+		switch facing {
+		case "south":
+			return [3]int{col, up, -layer}, nil
+		case "north":
+			return [3]int{-col, up, layer}, nil
+		case "east":
+			return [3]int{-layer, up, -col}, nil
+		case "west":
+			return [3]int{layer, up, col}, nil
+		}
+		return [3]int{}, fmt.Errorf("invalid facing: %s (expected north, south, east or west)", facing)
+	}
+	return [3]int{}, fmt.Errorf("invalid orientation: %s (expected vertical or horizontal)", orientation)
+}
+
+// pixelArtGrid lays out the quantized image as blocks relative to the origin, see surfacePos.
+func pixelArtGrid(indices [][]int, palette *Palette, orientation, facing, background string) (BlockGrid, error) {
+	height := len(indices)
+	grid := make(BlockGrid)
+	for y, row := range indices {
+		for x, i := range row {
+			block := background
+			if i >= 0 {
+				block = palette.Color(i).Block
+			}
+			if block == "" {
+				continue
+			}
+			p, err := surfacePos(orientation, facing, x, y, height, 0)
+			if err != nil {
+				return nil, err
+			}
+			grid[p] = block
+		}
+	}
+	return grid, nil
+}
+
+// registerPixelArtTools adds the image-to-blocks tool.
+func (ms *MinecraftServer) registerPixelArtTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_pixel_art",
+		mcp.WithDescription("Build an image (PNG, JPEG or GIF) as pixel art. The image is scaled, matched against a block color palette and placed with merged /fill commands."),
+		mcp.WithString("path", mcp.Description("Image file path, relative to (or inside) the configured assets directory")),
+		mcp.WithString("imageBase64", mcp.Description("Base64 encoded image data, used when path is empty")),
+		mcp.WithString("x", mcp.Description("X coordinate of the origin (bottom-left corner for vertical, north-west corner for horizontal)"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate of the origin"), mcp.Required()),
+		mcp.WithString("z", mcp.Description("Z coordinate of the origin"), mcp.Required()),
+		mcp.WithString("palette", mcp.Description("Block palette(s), comma separated: wool, concrete, terracotta, map (optional, default: concrete)")),
+		mcp.WithString("orientation", mcp.Description("vertical (wall) or horizontal (floor) (optional, default: vertical)")),
+		mcp.WithString("facing", mcp.Description("Direction a vertical image faces: north, south, east or west (optional, default: south)")),
+		mcp.WithNumber("width", mcp.Description("Width in blocks (optional, keeps the aspect ratio if height is omitted)")),
+		mcp.WithNumber("height", mcp.Description("Height in blocks (optional, keeps the aspect ratio if width is omitted)")),
+		mcp.WithBoolean("dither", mcp.Description("Use Floyd-Steinberg dithering (optional, default: false)")),
+		mcp.WithString("transparentBlock", mcp.Description("Block for transparent pixels (optional, default: leave them untouched)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handlePixelArt)
+}
+
+// handlePixelArt implements the minecraft_pixel_art tool.
+func (ms *MinecraftServer) handlePixelArt(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	origin, err := ms.getPositionArg(args, "x", "y", "z")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	img, err := ms.loadImageArg(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	paletteName, _ := getStringArg(args, "palette", false)
+	if paletteName == "" {
+		paletteName = "concrete"
+	}
+	palette, err := NewPalette(paletteName)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	orientation, _ := getStringArg(args, "orientation", false)
+	if orientation == "" {
+		orientation = "vertical"
+	}
+	facing, _ := getStringArg(args, "facing", false)
+	if facing == "" {
+		facing = "south"
+	}
+	background, _ := getStringArg(args, "transparentBlock", false)
+	if background != "" {
+		if err := validateBlockID(background); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	dither, err := getBoolArg(args, "dither", false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, err := getBoolArg(args, "dryRun", false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	width, err := getIntArg(args, "width", 0)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	height, err := getIntArg(args, "height", 0)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	width, height, err = pixelArtSize(img.Bounds().Dx(), img.Bounds().Dy(), width, height)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	indices := quantizeImage(resampleImage(img, width, height), palette, dither)
+	grid, err := pixelArtGrid(indices, palette, orientation, facing, background)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	commands, err := grid.Commands(origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	name := fmt.Sprintf("pixel art %dx%d (%d blocks)", width, height, len(grid))
+	return ms.runBuild(ctx, name, commands, dryRun)
+}
@@ -0,0 +1,497 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// commandQuietPeriod ends the response of a multi-line command.
+	commandQuietPeriod = 300 * time.Millisecond
+
+	WhitelistFileName     = "whitelist.json"
+	OpsFileName           = "ops.json"
+	BannedPlayersFileName = "banned-players.json"
+	BannedIPsFileName     = "banned-ips.json"
+)
+
+var (
+	playerNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+	// listRegex matches the first line of /list, "There are 2 of a max of 20 players
+	// online: Steve, Alex" since 1.13 and "There are 2/20 players online:" before.
+	listRegex = regexp.MustCompile(`^There are (\d+)(?: of a max(?: of)? |/)(\d+) players online:\s*(.*)$`)
+	// listUUIDRegex matches a player of "/list uuids", "Steve (069a79f4-44e9-4726-a5be-fca90e38aaf5)".
+	listUUIDRegex      = regexp.MustCompile(`^(\S+) \(([0-9a-fA-F-]{36})\)$`)
+	whitelistListRegex = regexp.MustCompile(`^There are (\d+) whitelisted players?(?:\(s\))?:\s*(.*)$`)
+	banRegex           = regexp.MustCompile(`^(\S+) was banned by (.+?): (.*)$`)
+	gameModes          = []string{"survival", "creative", "adventure", "spectator"}
+)
+
+// PlayerListEntry is an entry of whitelist.json, ops.json, banned-players.json or banned-ips.json.
+type PlayerListEntry struct {
+	UUID                string `json:"uuid,omitempty"`
+	Name                string `json:"name,omitempty"`
+	IP                  string `json:"ip,omitempty"`
+	Level               int    `json:"level,omitempty"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit,omitempty"`
+	Created             string `json:"created,omitempty"`
+	Source              string `json:"source,omitempty"`
+	Expires             string `json:"expires,omitempty"`
+	Reason              string `json:"reason,omitempty"`
+}
+
+// readPlayerList reads a player list file of the server root. A missing file is an empty list.
+func readPlayerList(root, name string) ([]PlayerListEntry, error) {
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []PlayerListEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return entries, nil
+}
+
+// findPlayerEntry returns the entry with the given player name or IP address.
+func findPlayerEntry(entries []PlayerListEntry, nameOrIP string) (PlayerListEntry, bool) {
+	for _, e := range entries {
+		if strings.EqualFold(e.Name, nameOrIP) || (e.IP != "" && e.IP == nameOrIP) {
+			return e, true
+		}
+	}
+	return PlayerListEntry{}, false
+}
+
+// OnlinePlayer is a player in the response of /list.
+type OnlinePlayer struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"`
+}
+
+// OnlinePlayers is the parsed response of /list.
+type OnlinePlayers struct {
+	Online  int            `json:"online"`
+	Max     int            `json:"max"`
+	Players []OnlinePlayer `json:"players"`
+}
+
+// splitNames splits a comma separated list of names.
+func splitNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseListOutput parses the response of /list or /list uuids. Before 1.13 the
+// names follow on the next line.
+func parseListOutput(lines []string) (OnlinePlayers, bool) {
+	for i, line := range lines {
+		m := listRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		list := OnlinePlayers{Players: []OnlinePlayer{}}
+		list.Online, _ = strconv.Atoi(m[1])
+		list.Max, _ = strconv.Atoi(m[2])
+		names := m[3]
+		if names == "" && list.Online > 0 && i+1 < len(lines) {
+			names = lines[i+1]
+		}
+		for _, name := range splitNames(names) {
+			if u := listUUIDRegex.FindStringSubmatch(name); u != nil {
+				list.Players = append(list.Players, OnlinePlayer{Name: u[1], UUID: u[2]})
+			} else {
+				list.Players = append(list.Players, OnlinePlayer{Name: name})
+			}
+		}
+		return list, true
+	}
+	return OnlinePlayers{}, false
+}
+
+// parseWhitelistOutput returns the names in the response of /whitelist list.
+func parseWhitelistOutput(lines []string) ([]string, bool) {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "There are no whitelisted players") {
+			return []string{}, true
+		}
+		if m := whitelistListRegex.FindStringSubmatch(line); m != nil {
+			return splitNames(m[2]), true
+		}
+	}
+	return nil, false
+}
+
+// parseBanlistOutput returns the bans in the response of /banlist.
+func parseBanlistOutput(lines []string) []PlayerListEntry {
+	bans := []PlayerListEntry{}
+	for _, line := range lines {
+		if m := banRegex.FindStringSubmatch(line); m != nil {
+			bans = append(bans, PlayerListEntry{Name: m[1], Source: m[2], Reason: m[3]})
+		}
+	}
+	return bans
+}
+
+// listDifference returns the names of a that are not in b.
+func listDifference(a, b []string) []string {
+	var diff []string
+	for _, name := range a {
+		found := false
+		for _, other := range b {
+			if strings.EqualFold(name, other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, name)
+		}
+	}
+	return diff
+}
+
+// entryNames returns the player names, or IP addresses, of the entries.
+func entryNames(entries []PlayerListEntry) []string {
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name
+		if names[i] == "" {
+			names[i] = e.IP
+		}
+	}
+	return names
+}
+
+// playerListResult is the result of the list actions: the entries of the file and
+// the differences to what the running server reported, e.g. after the file was
+// edited by hand without reloading it.
+type playerListResult struct {
+	File          string            `json:"file"`
+	Entries       []PlayerListEntry `json:"entries"`
+	OnlyInServer  []string          `json:"onlyInServer,omitempty"`
+	OnlyInFile    []string          `json:"onlyInFile,omitempty"`
+	ServerChecked bool              `json:"serverChecked"`
+}
+
+// jsonResult returns v as indented JSON text.
+func jsonResult(v interface{}) (*mcp.CallToolResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// playerListCommand runs a command that changes a player list and checks the file
+// the server saves the list to. present tells whether nameOrIP should be in the file
+// afterwards.
+func (ms *MinecraftServer) playerListCommand(command, file, nameOrIP string, present bool) (*mcp.CallToolResult, error) {
+	result, err := ms.WriteCommand(command)
+	if err != nil || result.IsError {
+		return result, err
+	}
+	entries, err := readPlayerList(ms.config.ServerRootPath, file)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("%s\nWarning: cannot check %s: %v", toolResultText(result), file, err)), nil
+	}
+	entry, found := findPlayerEntry(entries, nameOrIP)
+	switch {
+	case found == present && found && entry.UUID != "":
+		return mcp.NewToolResultText(fmt.Sprintf("%s\n%s lists %s (%s)", toolResultText(result), file, entry.Name, entry.UUID)), nil
+	case found == present && found:
+		return mcp.NewToolResultText(fmt.Sprintf("%s\n%s lists %s", toolResultText(result), file, nameOrIP)), nil
+	case found == present:
+		return mcp.NewToolResultText(fmt.Sprintf("%s\n%s no longer lists %s", toolResultText(result), file, nameOrIP)), nil
+	case present:
+		return mcp.NewToolResultText(fmt.Sprintf("%s\nWarning: %s does not list %s", toolResultText(result), file, nameOrIP)), nil
+	default:
+		return mcp.NewToolResultText(fmt.Sprintf("%s\nWarning: %s still lists %s", toolResultText(result), file, nameOrIP)), nil
+	}
+}
+
+// listPlayerFile returns the entries of a player list file, cross-checked against the
+// names the running server reports by command (nil: not checked).
+func (ms *MinecraftServer) listPlayerFile(file string, serverNames []string) (*mcp.CallToolResult, error) {
+	entries, err := readPlayerList(ms.config.ServerRootPath, file)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result := playerListResult{File: file, Entries: entries, ServerChecked: serverNames != nil}
+	if result.Entries == nil {
+		result.Entries = []PlayerListEntry{}
+	}
+	if serverNames != nil {
+		result.OnlyInServer = listDifference(serverNames, entryNames(entries))
+		result.OnlyInFile = listDifference(entryNames(entries), serverNames)
+	}
+	return jsonResult(result)
+}
+
+// getPlayerName reads a player name argument.
+func getPlayerName(args map[string]interface{}, key string) (string, error) {
+	name, err := getStringArg(args, key, true)
+	if err != nil {
+		return "", err
+	}
+	if !playerNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid player name %q", name)
+	}
+	return name, nil
+}
+
+// withReason appends the optional reason argument to a command.
+func withReason(command string, args map[string]interface{}) string {
+	reason, _ := getStringArg(args, "reason", false)
+	// A line break would end the command and start another one
+	reason = strings.Join(strings.Fields(reason), " ")
+	if reason != "" {
+		return command + " " + reason
+	}
+	return command
+}
+
+// registerPlayerTools registers the player administration tools.
+func (ms *MinecraftServer) registerPlayerTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_list",
+		mcp.WithDescription("List the online players with their UUIDs as JSON"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleList)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_whitelist",
+		mcp.WithDescription("Manage the whitelist: add or remove a player, list the whitelisted players (from "+WhitelistFileName+", checked against the server), turn it on or off, or reload it after editing the file"),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("add", "remove", "list", "on", "off", "reload")),
+		mcp.WithString("player", mcp.Description("Player name (for add and remove)")),
+	), ms.handleWhitelist)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_op",
+		mcp.WithDescription("Make a player a server operator, remove the operator status, or list the operators from "+OpsFileName),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("op", "deop", "list")),
+		mcp.WithString("player", mcp.Description("Player name (for op and deop)")),
+	), ms.handleOp)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_ban",
+		mcp.WithDescription("Ban or pardon a player or an IP address, or list the bans (from "+BannedPlayersFileName+" and "+BannedIPsFileName+", checked against the server)"),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("ban", "ban-ip", "pardon", "pardon-ip", "list")),
+		mcp.WithString("target", mcp.Description("Player name, or IP address (or online player name) for ban-ip and pardon-ip")),
+		mcp.WithString("reason", mcp.Description("Reason shown to the player (optional, for ban and ban-ip)")),
+	), ms.handleBan)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_kick",
+		mcp.WithDescription("Disconnect a player from the server"),
+		mcp.WithString("player", mcp.Description("Player name or selector"), mcp.Required()),
+		mcp.WithString("reason", mcp.Description("Reason shown to the player (optional)")),
+	), ms.handleKick)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_gamemode",
+		mcp.WithDescription("Set the game mode of players"),
+		mcp.WithString("mode", mcp.Description("Game mode"), mcp.Required(), mcp.Enum(gameModes...)),
+		mcp.WithString("target", mcp.Description("Player name or selector (e.g., @a, PlayerName)"), mcp.Required()),
+	), ms.handleGamemode)
+}
+
+// handleList implements the minecraft_list tool.
+func (ms *MinecraftServer) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	lines, err := ms.commandLines("/list uuids")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	list, ok := parseListOutput(lines)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unexpected response to /list: %s", strings.Join(lines, "\n"))), nil
+	}
+	return jsonResult(list)
+}
+
+// handleWhitelist implements the minecraft_whitelist tool.
+func (ms *MinecraftServer) handleWhitelist(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	switch action {
+	case "add", "remove":
+		player, err := getPlayerName(args, "player")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return ms.playerListCommand(fmt.Sprintf("/whitelist %s %s", action, player), WhitelistFileName, player, action == "add")
+	case "list":
+		var names []string
+		if ms.isServerRunning() {
+			lines, err := ms.commandLines("/whitelist list")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if names, _ = parseWhitelistOutput(lines); names == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("unexpected response to /whitelist list: %s", strings.Join(lines, "\n"))), nil
+			}
+		}
+		return ms.listPlayerFile(WhitelistFileName, names)
+	case "on", "off", "reload":
+		return ms.WriteCommand("/whitelist " + action)
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// handleOp implements the minecraft_op tool.
+func (ms *MinecraftServer) handleOp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	switch action {
+	case "op", "deop":
+		player, err := getPlayerName(args, "player")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return ms.playerListCommand(fmt.Sprintf("/%s %s", action, player), OpsFileName, player, action == "op")
+	case "list":
+		// There is no command listing the operators
+		return ms.listPlayerFile(OpsFileName, nil)
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// handleBan implements the minecraft_ban tool.
+func (ms *MinecraftServer) handleBan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if action == "list" {
+		var players, ips []string
+		if ms.isServerRunning() {
+			for _, list := range []struct {
+				kind  string
+				names *[]string
+			}{{"players", &players}, {"ips", &ips}} {
+				lines, err := ms.commandLines("/banlist " + list.kind)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				*list.names = entryNames(parseBanlistOutput(lines))
+			}
+		}
+		playerBans, err := ms.listPlayerFile(BannedPlayersFileName, players)
+		if err != nil || playerBans.IsError {
+			return playerBans, err
+		}
+		ipBans, err := ms.listPlayerFile(BannedIPsFileName, ips)
+		if err != nil || ipBans.IsError {
+			return ipBans, err
+		}
+		return mcp.NewToolResultText(toolResultText(playerBans) + "\n" + toolResultText(ipBans)), nil
+	}
+
+	target, err := getStringArg(args, "target", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	switch action {
+	case "ban", "pardon":
+		if !playerNameRegex.MatchString(target) {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid player name %q", target)), nil
+		}
+		command := "/" + action + " " + target
+		if action == "ban" {
+			command = withReason(command, args)
+		}
+		return ms.playerListCommand(command, BannedPlayersFileName, target, action == "ban")
+	case "ban-ip", "pardon-ip":
+		if strings.ContainsAny(target, " \t") {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid IP address or player name %q", target)), nil
+		}
+		command := "/" + action + " " + target
+		if action == "ban-ip" {
+			command = withReason(command, args)
+		}
+		if !playerNameRegex.MatchString(target) {
+			return ms.playerListCommand(command, BannedIPsFileName, target, action == "ban-ip")
+		}
+		// Banning the IP address of an online player, the file lists the address only
+		return ms.WriteCommand(command)
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// handleKick implements the minecraft_kick tool.
+func (ms *MinecraftServer) handleKick(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	player, err := getStringArg(request.Params.Arguments, "player", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if player, err = checkTarget(player); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.WriteCommand(withReason("/kick "+player, request.Params.Arguments))
+}
+
+// handleGamemode implements the minecraft_gamemode tool.
+func (ms *MinecraftServer) handleGamemode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	mode, err := getStringArg(request.Params.Arguments, "mode", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	valid := false
+	for _, m := range gameModes {
+		valid = valid || m == mode
+	}
+	if !valid {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid game mode %q, expected one of %s", mode, strings.Join(gameModes, ", "))), nil
+	}
+	target, err := getStringArg(request.Params.Arguments, "target", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if target, err = checkTarget(target); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.WriteCommand(fmt.Sprintf("/gamemode %s %s", mode, target))
+}
@@ -0,0 +1,113 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseListOutput(t *testing.T) {
+	list, ok := parseListOutput([]string{"There are 2 of a max of 20 players online: Steve (069a79f4-44e9-4726-a5be-fca90e38aaf5), Alex (ec561538-f3fd-461d-aff5-086b22154bce)"})
+	want := OnlinePlayers{Online: 2, Max: 20, Players: []OnlinePlayer{
+		{Name: "Steve", UUID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"},
+		{Name: "Alex", UUID: "ec561538-f3fd-461d-aff5-086b22154bce"},
+	}}
+	if !ok || !reflect.DeepEqual(list, want) {
+		t.Errorf("parseListOutput = %+v, %v", list, ok)
+	}
+	list, ok = parseListOutput([]string{"There are 0 of a max of 20 players online: "})
+	if !ok || list.Online != 0 || len(list.Players) != 0 {
+		t.Errorf("empty list = %+v, %v", list, ok)
+	}
+	// Before 1.13
+	list, ok = parseListOutput([]string{"There are 1/20 players online:", "Steve"})
+	if !ok || len(list.Players) != 1 || list.Players[0].Name != "Steve" {
+		t.Errorf("old list = %+v, %v", list, ok)
+	}
+	if _, ok = parseListOutput([]string{"Unknown command"}); ok {
+		t.Errorf("parseListOutput should fail on other responses")
+	}
+}
+
+func TestParseWhitelistAndBanlist(t *testing.T) {
+	if names, ok := parseWhitelistOutput([]string{"There are 2 whitelisted player(s): Steve, Alex"}); !ok || !reflect.DeepEqual(names, []string{"Steve", "Alex"}) {
+		t.Errorf("whitelist = %v, %v", names, ok)
+	}
+	if names, ok := parseWhitelistOutput([]string{"There are no whitelisted players"}); !ok || len(names) != 0 {
+		t.Errorf("empty whitelist = %v, %v", names, ok)
+	}
+	bans := parseBanlistOutput([]string{"There are 2 ban(s):", "Griefer was banned by Server: Banned by an operator.", "1.2.3.4 was banned by Steve: spam"})
+	if len(bans) != 2 || bans[0].Name != "Griefer" || bans[1].Source != "Steve" || bans[1].Reason != "spam" {
+		t.Errorf("banlist = %+v", bans)
+	}
+}
+
+func TestMinecraftServer_listPlayerFile(t *testing.T) {
+	root := t.TempDir()
+	data, _ := json.Marshal([]PlayerListEntry{
+		{UUID: "069a79f4-44e9-4726-a5be-fca90e38aaf5", Name: "Steve"},
+		{UUID: "ec561538-f3fd-461d-aff5-086b22154bce", Name: "Alex"},
+	})
+	if err := os.WriteFile(filepath.Join(root, WhitelistFileName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root}}
+
+	result, _ := ms.listPlayerFile(WhitelistFileName, []string{"steve", "Notch"})
+	var list playerListResult
+	if err := json.Unmarshal([]byte(toolResultText(result)), &list); err != nil {
+		t.Fatalf("result is not JSON: %v", err)
+	}
+	if len(list.Entries) != 2 || !reflect.DeepEqual(list.OnlyInServer, []string{"Notch"}) || !reflect.DeepEqual(list.OnlyInFile, []string{"Alex"}) {
+		t.Errorf("listPlayerFile = %+v", list)
+	}
+
+	result, _ = ms.listPlayerFile(OpsFileName, nil)
+	if err := json.Unmarshal([]byte(toolResultText(result)), &list); err != nil || len(list.Entries) != 0 || list.ServerChecked {
+		t.Errorf("missing ops.json = %s, %v", toolResultText(result), err)
+	}
+}
+
+func TestMinecraftServer_handleKickAndGamemode(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{}}
+	for _, target := range []string{"Steve\n/op Alex", "@a]", "Steve Alex"} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"player": target, "target": target, "mode": "creative"}
+		if result, _ := ms.handleKick(context.Background(), request); !strings.Contains(toolResultText(result), "invalid target") {
+			t.Errorf("kick %q = %s", target, toolResultText(result))
+		}
+		if result, _ := ms.handleGamemode(context.Background(), request); !strings.Contains(toolResultText(result), "invalid target") {
+			t.Errorf("gamemode %q = %s", target, toolResultText(result))
+		}
+	}
+	args := map[string]interface{}{"reason": " griefing\n/op Steve "}
+	if got := withReason("/kick Alex", args); got != "/kick Alex griefing /op Steve" {
+		t.Errorf("withReason = %q", got)
+	}
+}
@@ -0,0 +1,598 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serverPropertiesURI is the resource with the typed content of server.properties.
+const serverPropertiesURI = "minecraft://server.properties"
+
+// redactedProperty replaces the value of secret properties in results.
+const redactedProperty = "********"
+
+// propertyLine is one logical line of a .properties file. Comments and blank lines
+// have no key and are written back unchanged, like unmodified entries.
+type propertyLine struct {
+	Key   string
+	Value string
+	Raw   string // original text, empty once the value was changed
+}
+
+// ServerProperties is a server.properties file that keeps comments and ordering.
+type ServerProperties struct {
+	lines []propertyLine
+}
+
+// ParseServerProperties parses the content of a Java .properties file.
+func ParseServerProperties(data []byte) (*ServerProperties, error) {
+	p := &ServerProperties{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var raw []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		raw = append(raw, line)
+		// A line ending in an odd number of backslashes continues on the next line
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		if trimmed != "" && trimmed[0] != '#' && trimmed[0] != '!' && continuesLine(line) {
+			continue
+		}
+		p.lines = append(p.lines, parsePropertyLine(raw))
+		raw = nil
+	}
+	if raw != nil {
+		p.lines = append(p.lines, parsePropertyLine(raw))
+	}
+	return p, scanner.Err()
+}
+
+// ReadServerProperties reads a server.properties file.
+func ReadServerProperties(path string) (*ServerProperties, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseServerProperties(data)
+}
+
+// continuesLine reports whether the line ends in an unescaped backslash.
+func continuesLine(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
+
+// parsePropertyLine parses the physical lines of one logical line.
+func parsePropertyLine(raw []string) propertyLine {
+	var logical strings.Builder
+	for i, line := range raw {
+		if i > 0 {
+			line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		}
+		if i < len(raw)-1 {
+			line = line[:len(line)-1]
+		}
+		logical.WriteString(line)
+	}
+	pl := propertyLine{Raw: strings.Join(raw, "\n")}
+	s := strings.TrimLeftFunc(logical.String(), unicode.IsSpace)
+	if s == "" || s[0] == '#' || s[0] == '!' {
+		return pl
+	}
+
+	// The key ends at the first unescaped '=', ':' or whitespace
+	end := len(s)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '=' || s[i] == ':' || s[i] == ' ' || s[i] == '\t' || s[i] == '\f' {
+			end = i
+			break
+		}
+	}
+	pl.Key = unescapeProperty(s[:end])
+	rest := strings.TrimLeft(s[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	pl.Value = unescapeProperty(rest)
+	return pl
+}
+
+// unescapeProperty resolves the escapes of a .properties key or value.
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					sb.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			sb.WriteByte('u')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// escapeProperty escapes a key or value the way java.util.Properties stores it.
+func escapeProperty(s string, key bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == '=' || r == ':' || r == '#' || r == '!':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r == ' ' && (key || i == 0):
+			sb.WriteString(`\ `)
+		case r < 0x20 || r > 0x7e:
+			for _, u := range utf16Units(r) {
+				sb.WriteString(fmt.Sprintf(`\u%04X`, u))
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// utf16Units returns the UTF-16 code units of r.
+func utf16Units(r rune) []rune {
+	if r < 0x10000 {
+		return []rune{r}
+	}
+	r -= 0x10000
+	return []rune{0xd800 + (r>>10)&0x3ff, 0xdc00 + r&0x3ff}
+}
+
+// Get returns the value of key.
+func (p *ServerProperties) Get(key string) (string, bool) {
+	for _, l := range p.lines {
+		if l.Key == key {
+			return l.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes the value of key in place, or appends it when it is not in the file.
+func (p *ServerProperties) Set(key, value string) {
+	for i, l := range p.lines {
+		if l.Key == key {
+			if l.Value != value {
+				p.lines[i] = propertyLine{Key: key, Value: value}
+			}
+			return
+		}
+	}
+	p.lines = append(p.lines, propertyLine{Key: key, Value: value})
+}
+
+// Keys returns the keys in file order.
+func (p *ServerProperties) Keys() []string {
+	var keys []string
+	for _, l := range p.lines {
+		if l.Key != "" {
+			keys = append(keys, l.Key)
+		}
+	}
+	return keys
+}
+
+// Bytes returns the file content. Unchanged lines are kept byte for byte.
+func (p *ServerProperties) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, l := range p.lines {
+		if l.Key == "" || l.Raw != "" {
+			buf.WriteString(l.Raw)
+		} else {
+			buf.WriteString(escapeProperty(l.Key, true) + "=" + escapeProperty(l.Value, false))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Write saves the file to path.
+func (p *ServerProperties) Write(path string) error {
+	return os.WriteFile(path, p.Bytes(), 0o644)
+}
+
+// Typed returns the properties with booleans and integers of known keys converted.
+// Secrets such as rcon.password are redacted.
+func (p *ServerProperties) Typed() map[string]interface{} {
+	typed := make(map[string]interface{})
+	for _, key := range p.Keys() {
+		v, _ := p.Get(key)
+		typed[key] = v
+		spec, ok := serverPropertySpecs[key]
+		if !ok {
+			continue
+		}
+		if spec.Secret && v != "" {
+			typed[key] = redactedProperty
+		}
+		switch spec.Type {
+		case "bool":
+			if b, err := strconv.ParseBool(v); err == nil {
+				typed[key] = b
+			}
+		case "int":
+			if n, err := strconv.Atoi(v); err == nil {
+				typed[key] = n
+			}
+		}
+	}
+	return typed
+}
+
+// propertySpec describes a known server.properties key.
+type propertySpec struct {
+	Type   string   // "bool", "int", "enum" or "string"
+	Values []string // allowed values of an enum
+	Min    int      // range of an int
+	Max    int
+	Secret bool // the value is never shown
+	// Live returns the command applying the value to a running server, properties
+	// without it only take effect after a restart.
+	Live func(value string) string
+}
+
+// Validate checks value and returns it in canonical form.
+func (s propertySpec) Validate(key, value string) (string, error) {
+	switch s.Type {
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be true or false, got %q", key, value)
+		}
+		return strconv.FormatBool(b), nil
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be an integer, got %q", key, value)
+		}
+		if n < s.Min || n > s.Max {
+			return "", fmt.Errorf("%s must be between %d and %d, got %d", key, s.Min, s.Max, n)
+		}
+		return strconv.Itoa(n), nil
+	case "enum":
+		v := strings.ToLower(value)
+		if indexOf(s.Values, v) < 0 {
+			return "", fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(s.Values, ", "), value)
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+var (
+	boolProperty   = propertySpec{Type: "bool"}
+	stringProperty = propertySpec{Type: "string"}
+	secretProperty = propertySpec{Type: "string", Secret: true}
+	portProperty   = propertySpec{Type: "int", Min: 1, Max: 65535}
+)
+
+// intProperty returns the spec of an integer property in the range min..max.
+func intProperty(min, max int) propertySpec {
+	return propertySpec{Type: "int", Min: min, Max: max}
+}
+
+// serverPropertySpecs are the keys of a vanilla dedicated server.
+var serverPropertySpecs = map[string]propertySpec{
+	"accepts-transfers":                 boolProperty,
+	"allow-flight":                      boolProperty,
+	"allow-nether":                      boolProperty,
+	"broadcast-console-to-ops":          boolProperty,
+	"broadcast-rcon-to-ops":             boolProperty,
+	"bug-report-link":                   stringProperty,
+	"difficulty":                        {Type: "enum", Values: []string{"peaceful", "easy", "normal", "hard"}, Live: func(v string) string { return "/difficulty " + v }},
+	"enable-command-block":              boolProperty,
+	"enable-jmx-monitoring":             boolProperty,
+	"enable-query":                      boolProperty,
+	"enable-rcon":                       boolProperty,
+	"enable-status":                     boolProperty,
+	"enforce-secure-profile":            boolProperty,
+	"enforce-whitelist":                 boolProperty,
+	"entity-broadcast-range-percentage": intProperty(10, 1000),
+	"force-gamemode":                    boolProperty,
+	"function-permission-level":         intProperty(1, 4),
+	"gamemode":                          {Type: "enum", Values: []string{"survival", "creative", "adventure", "spectator"}, Live: func(v string) string { return "/defaultgamemode " + v }},
+	"generate-structures":               boolProperty,
+	"generator-settings":                stringProperty,
+	"hardcore":                          boolProperty,
+	"hide-online-players":               boolProperty,
+	"initial-disabled-packs":            stringProperty,
+	"initial-enabled-packs":             stringProperty,
+	"level-name":                        stringProperty,
+	"level-seed":                        stringProperty,
+	"level-type":                        stringProperty,
+	"log-ips":                           boolProperty,
+	"max-chained-neighbor-updates":      intProperty(-1, 1<<31-1),
+	"max-players":                       intProperty(0, 1<<31-1),
+	"max-tick-time":                     intProperty(-1, 1<<31-1),
+	"max-world-size":                    intProperty(1, 29999984),
+	"motd":                              stringProperty,
+	"network-compression-threshold":     intProperty(-1, 1<<31-1),
+	"online-mode":                       boolProperty,
+	"op-permission-level":               intProperty(0, 4),
+	"pause-when-empty-seconds":          intProperty(-1, 1<<31-1),
+	"player-idle-timeout":               intProperty(0, 1<<31-1),
+	"prevent-proxy-connections":         boolProperty,
+	"previews-chat":                     boolProperty,
+	"pvp":                               boolProperty,
+	"query.port":                        portProperty,
+	"rate-limit":                        intProperty(0, 1<<31-1),
+	"rcon.password":                     secretProperty,
+	"rcon.port":                         portProperty,
+	"region-file-compression":           {Type: "enum", Values: []string{"deflate", "lz4", "none"}},
+	"require-resource-pack":             boolProperty,
+	"resource-pack":                     stringProperty,
+	"resource-pack-id":                  stringProperty,
+	"resource-pack-prompt":              stringProperty,
+	"resource-pack-sha1":                stringProperty,
+	"server-ip":                         stringProperty,
+	"server-port":                       portProperty,
+	"simulation-distance":               intProperty(3, 32),
+	"spawn-animals":                     boolProperty,
+	"spawn-monsters":                    boolProperty,
+	"spawn-npcs":                        boolProperty,
+	"spawn-protection":                  intProperty(0, 1<<31-1),
+	"sync-chunk-writes":                 boolProperty,
+	"text-filtering-config":             stringProperty,
+	"text-filtering-version":            intProperty(0, 1),
+	"use-native-transport":              boolProperty,
+	"view-distance":                     intProperty(3, 32),
+	"white-list": {Type: "bool", Live: func(v string) string {
+		if v == "true" {
+			return "/whitelist on"
+		}
+		return "/whitelist off"
+	}},
+}
+
+// propertyString converts a tool argument value to its .properties form.
+func propertyString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// propertiesPath returns the path of server.properties.
+func (ms *MinecraftServer) propertiesPath() string {
+	return filepath.Join(ms.config.ServerRootPath, "server.properties")
+}
+
+// registerPropertiesTools registers the server.properties resource and tool.
+func (ms *MinecraftServer) registerPropertiesTools() {
+	ms.AddResource(mcp.NewResource(
+		serverPropertiesURI,
+		"server.properties",
+		mcp.WithResourceDescription("Settings of the Minecraft server, with booleans and numbers typed"),
+		mcp.WithMIMEType("application/json"),
+	), ms.handlePropertiesResource)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_server_properties",
+		mcp.WithDescription("Read or change server.properties. Values are validated against the vanilla settings (e.g. gamemode, difficulty, view-distance, enable-rcon); comments and ordering of the file are kept. The result tells which changes were applied to the running server and which need a restart."),
+		mcp.WithString("keys", mcp.Description("Comma separated keys to read (optional, default: all)")),
+		mcp.WithObject("set", mcp.Description("Properties to change, e.g. {\"difficulty\": \"hard\", \"view-distance\": 12} (optional)")),
+		mcp.WithBoolean("apply", mcp.Description("Apply changes that have a command (difficulty, gamemode, white-list) to the running server right away (optional, default: true)")),
+		mcp.WithBoolean("force", mcp.Description("Allow keys that are not vanilla settings, e.g. of server mods (optional, default: false)")),
+	), ms.handleServerProperties)
+}
+
+// handlePropertiesResource returns server.properties as typed JSON.
+func (ms *MinecraftServer) handlePropertiesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	props, err := ReadServerProperties(ms.propertiesPath())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(props.Typed(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: serverPropertiesURI, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+// handleServerProperties implements the minecraft_server_properties tool.
+func (ms *MinecraftServer) handleServerProperties(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	path := ms.propertiesPath()
+	props, err := ReadServerProperties(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		// The server creates the file on its first start
+		props = &ServerProperties{}
+	}
+
+	set, _ := args["set"].(map[string]interface{})
+	if len(set) == 0 {
+		if _, ok := args["set"]; ok {
+			return mcp.NewToolResultError("set must be an object of properties to change"), nil
+		}
+		return ms.listServerProperties(props, args)
+	}
+
+	force, _ := getBoolArg(args, "force", false)
+	apply := true
+	if _, ok := args["apply"]; ok {
+		if apply, err = getBoolArg(args, "apply", false); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Validate everything before anything is written
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := propertyString(set[key])
+		spec, ok := serverPropertySpecs[key]
+		if !ok {
+			if !force {
+				return mcp.NewToolResultError(fmt.Sprintf("unknown property %s, use force to set it anyway", key)), nil
+			}
+			spec = stringProperty
+		}
+		if spec.Secret && value == redactedProperty {
+			// A redacted value from the session journal keeps the current secret
+			values[key], _ = props.Get(key)
+			continue
+		}
+		if values[key], err = spec.Validate(key, value); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	var changed, unchanged []string
+	changes := make(map[string]string)
+	for _, key := range keys {
+		old, _ := props.Get(key)
+		if old == values[key] {
+			unchanged = append(unchanged, key)
+			continue
+		}
+		props.Set(key, values[key])
+		changed = append(changed, key)
+		if serverPropertySpecs[key].Secret {
+			changes[key] = key + ": changed"
+		} else {
+			changes[key] = fmt.Sprintf("%s: %q -> %q", key, old, values[key])
+		}
+	}
+	// The file is written first so a live change is never lost on the next start
+	if err := props.Write(path); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ms.logger.Info().Strs("keys", keys).Msg("Updated server.properties")
+
+	var live, restart []string
+	running := ms.isServerRunning()
+	for _, key := range changed {
+		spec := serverPropertySpecs[key]
+		if running && apply && spec.Live != nil {
+			if result, err := ms.WriteCommand(spec.Live(values[key])); err == nil && !result.IsError {
+				live = append(live, changes[key])
+				continue
+			}
+			ms.logger.Warn().Str("property", key).Msg("Failed to apply the property to the running server")
+		}
+		restart = append(restart, changes[key])
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Updated server.properties\n")
+	if len(live) > 0 {
+		sb.WriteString("Applied to the running server:\n  " + strings.Join(live, "\n  ") + "\n")
+	}
+	if len(restart) > 0 {
+		if running {
+			sb.WriteString("Takes effect after a restart:\n  ")
+		} else {
+			sb.WriteString("Takes effect on the next start:\n  ")
+		}
+		sb.WriteString(strings.Join(restart, "\n  ") + "\n")
+	}
+	if len(unchanged) > 0 {
+		sb.WriteString("Unchanged: " + strings.Join(unchanged, ", ") + "\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// listServerProperties returns the requested properties as typed JSON.
+func (ms *MinecraftServer) listServerProperties(props *ServerProperties, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	typed := props.Typed()
+	if list, _ := getStringArg(args, "keys", false); list != "" {
+		selected := make(map[string]interface{})
+		for _, key := range strings.Split(list, ",") {
+			key = strings.TrimSpace(key)
+			v, ok := typed[key]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("property %s is not set", key)), nil
+			}
+			selected[key] = v
+		}
+		typed = selected
+	}
+	if len(typed) == 0 {
+		return mcp.NewToolResultText("server.properties does not exist yet, it is created when the server starts"), nil
+	}
+	data, err := json.MarshalIndent(typed, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
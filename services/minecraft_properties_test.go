@@ -0,0 +1,143 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testProperties = `#Minecraft server properties
+#Sat Jan 01 00:00:00 UTC 2025
+difficulty=easy
+level-name=survival
+level-type=minecraft\:normal
+motd=§aHello \
+    World
+view-distance=10
+rcon.password=hunter2
+custom-key = keep me
+`
+
+func TestServerProperties(t *testing.T) {
+	p, err := ParseServerProperties([]byte(testProperties))
+	if err != nil {
+		t.Fatalf("ParseServerProperties failed: %v", err)
+	}
+	for key, want := range map[string]string{"level-type": "minecraft:normal", "motd": "§aHello World", "custom-key": "keep me"} {
+		if v, _ := p.Get(key); v != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+	if string(p.Bytes()) != testProperties {
+		t.Errorf("unchanged file was not kept:\n%s", p.Bytes())
+	}
+
+	p.Set("difficulty", "hard")
+	p.Set("motd", "§bBye: now")
+	p.Set("pvp", "false")
+	want := strings.NewReplacer("difficulty=easy", "difficulty=hard", "motd=§aHello \\\n    World", `motd=\u00A7bBye\: now`).Replace(testProperties) + "pvp=false\n"
+	if string(p.Bytes()) != want {
+		t.Errorf("Bytes =\n%s\nwant\n%s", p.Bytes(), want)
+	}
+
+	typed := p.Typed()
+	if typed["view-distance"] != 10 || typed["pvp"] != false || typed["custom-key"] != "keep me" {
+		t.Errorf("Typed = %v", typed)
+	}
+}
+
+func TestMinecraftServer_handleServerProperties(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "server.properties")
+	if err := os.WriteFile(path, []byte(testProperties), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root}}
+	if dir := ms.worldDir(); dir != filepath.Join(root, "survival") {
+		t.Errorf("worldDir = %s", dir)
+	}
+
+	call := func(args map[string]interface{}) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		result, err := ms.handleServerProperties(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	result := call(map[string]interface{}{"set": map[string]interface{}{"difficulty": "HARD", "view-distance": float64(12), "pvp": false}})
+	text := result.Content[0].(mcp.TextContent).Text
+	if result.IsError || !strings.Contains(text, `difficulty: "easy" -> "hard"`) || !strings.Contains(text, "next start") {
+		t.Errorf("unexpected result:\n%s", text)
+	}
+	props, err := ReadServerProperties(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := props.Get("view-distance"); v != "12" {
+		t.Errorf("view-distance = %s", v)
+	}
+	if v, _ := props.Get("pvp"); v != "false" {
+		t.Errorf("pvp = %s", v)
+	}
+
+	result = call(map[string]interface{}{"keys": "difficulty, view-distance"})
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, `"view-distance": 12`) || strings.Contains(text, "motd") {
+		t.Errorf("unexpected result:\n%s", text)
+	}
+
+	result = call(nil)
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "hunter2") || !strings.Contains(text, `"rcon.password": "`+redactedProperty+`"`) {
+		t.Errorf("rcon.password is not redacted:\n%s", text)
+	}
+	result = call(map[string]interface{}{"set": map[string]interface{}{"rcon.password": "s3cret"}})
+	if text := result.Content[0].(mcp.TextContent).Text; strings.Contains(text, "hunter2") || strings.Contains(text, "s3cret") {
+		t.Errorf("the change shows the password:\n%s", text)
+	}
+	call(map[string]interface{}{"set": map[string]interface{}{"rcon.password": redactedProperty}})
+	if props, err = ReadServerProperties(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := props.Get("rcon.password"); v != "s3cret" {
+		t.Errorf("a replayed redacted password must keep the secret, got %q", v)
+	}
+
+	for _, set := range []map[string]interface{}{
+		{"difficulty": "insane"},
+		{"view-distance": float64(64)},
+		{"enable-rcon": "maybe"},
+		{"no-such-key": "1"},
+	} {
+		if result := call(map[string]interface{}{"set": set}); !result.IsError {
+			t.Errorf("%v should fail", set)
+		}
+	}
+	if result := call(map[string]interface{}{"set": map[string]interface{}{"no-such-key": "1"}, "force": true}); result.IsError {
+		t.Errorf("force should allow unknown keys")
+	}
+}
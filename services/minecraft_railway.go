@@ -0,0 +1,360 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxRailwayLength is the longest track built in one call.
+	maxRailwayLength = 1024
+	// defaultPoweredSpacing keeps an occupied minecart close to top speed on flat track.
+	defaultPoweredSpacing = 32
+	// railPillarSpacing is the distance between the pillars under raised track.
+	railPillarSpacing = 4
+	// railClearance is the number of blocks cleared above the track.
+	railClearance = 2
+)
+
+// railTile is one rail of a railway.
+type railTile struct {
+	Pos     [3]int // absolute position of the rail
+	Shape   string // rail shape block state
+	Powered bool
+}
+
+// Straight reports whether a powered rail can be used here; powered rails cannot curve.
+func (t railTile) Straight() bool {
+	return t.Shape == "north_south" || t.Shape == "east_west" || strings.HasPrefix(t.Shape, "ascending_")
+}
+
+// railRoute lays the track through the points, going along X first and then along Z
+// between each pair. Height changes become ascending rails, spread evenly over the
+// straight parts of each stretch, since curves cannot slope. Every spacing blocks and
+// on every slope a powered rail is used.
+func railRoute(points [][3]int, spacing int) ([]railTile, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("a railway needs at least two points")
+	}
+	cols := [][2]int{{points[0][0], points[0][2]}}
+	ends := []int{0} // index of each point in cols
+	for k := 1; k < len(points); k++ {
+		p, q := cols[len(cols)-1], [2]int{points[k][0], points[k][2]}
+		if p == q {
+			return nil, fmt.Errorf("points %d and %d are above each other, rails cannot go straight up", k, k+1)
+		}
+		for p[0] != q[0] {
+			p[0] += sign(q[0] - p[0])
+			cols = append(cols, p)
+		}
+		for p[1] != q[1] {
+			p[1] += sign(q[1] - p[1])
+			cols = append(cols, p)
+		}
+		ends = append(ends, len(cols)-1)
+		if len(cols) > maxRailwayLength {
+			return nil, fmt.Errorf("the railway is longer than %d blocks, build it in parts", maxRailwayLength)
+		}
+	}
+
+	n := len(cols)
+	step := func(i int) [2]int { // direction from i to i+1
+		return [2]int{cols[i+1][0] - cols[i][0], cols[i+1][1] - cols[i][1]}
+	}
+	curve := func(i int) bool {
+		return i > 0 && i < n-1 && step(i-1) != step(i)
+	}
+
+	// Pick the rises of each stretch; each ascending rail is the lower end of a step
+	ascending := make(map[int][2]int) // tile -> direction it ascends to
+	climbs := make(map[int]int)       // step i -> i+1 -> height change
+	for k := 0; k+1 < len(ends); k++ {
+		a, b := ends[k], ends[k+1]
+		dy := points[k+1][1] - points[k][1]
+		if dy == 0 {
+			continue
+		}
+		var candidates []int
+		for j := a; j < b; j++ {
+			lower := j
+			if dy < 0 {
+				lower = j + 1
+			}
+			if _, used := ascending[lower]; !used && !curve(lower) {
+				candidates = append(candidates, j)
+			}
+		}
+		if len(candidates) < absInt(dy) {
+			return nil, fmt.Errorf("the track from point %d to %d climbs %d blocks over %d straight rails, it needs at least one rail per block of height", k+1, k+2, absInt(dy), len(candidates))
+		}
+		for i := 0; i < absInt(dy); i++ {
+			j := candidates[int((float64(i)+0.5)*float64(len(candidates))/float64(absInt(dy)))]
+			climbs[j] = sign(dy)
+			if dy > 0 {
+				ascending[j] = step(j)
+			} else {
+				d := step(j)
+				ascending[j+1] = [2]int{-d[0], -d[1]}
+			}
+		}
+	}
+
+	tiles := make([]railTile, n)
+	y := points[0][1]
+	sinceBoost := spacing
+	for i, c := range cols {
+		t := railTile{Pos: [3]int{c[0], y, c[1]}}
+		y += climbs[i]
+		switch d, up := ascending[i]; {
+		case up:
+			t.Shape = "ascending_" + directionName(d[0], d[1])
+		case curve(i):
+			in, out := step(i-1), step(i)
+			t.Shape = railCurve([2]int{-in[0], -in[1]}, out)
+		default:
+			d := [2]int{}
+			if i < n-1 {
+				d = step(i)
+			} else {
+				d = step(i - 1)
+			}
+			t.Shape = "north_south"
+			if d[0] != 0 {
+				t.Shape = "east_west"
+			}
+		}
+		// Power the start, every slope and a rail every spacing blocks on the flat
+		if t.Straight() && (i == 0 || strings.HasPrefix(t.Shape, "ascending_") || sinceBoost >= spacing) {
+			t.Powered = true
+			sinceBoost = 0
+		}
+		sinceBoost++
+		tiles[i] = t
+	}
+	return tiles, nil
+}
+
+// railCurve returns the shape of a curved rail connecting the two directions.
+func railCurve(a, b [2]int) string {
+	if a[1] == 0 {
+		a, b = b, a
+	}
+	// a is now north or south, b east or west
+	return directionName(a[0], a[1]) + "_" + directionName(b[0], b[1])
+}
+
+// sign returns -1, 0 or 1.
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
+// railOptions configures the blocks of a railway.
+type railOptions struct {
+	Bed     string // block under the rails
+	Support string // pillar block
+	Power   string // "block" (redstone block under powered rails) or "torch" (redstone torch beside them)
+}
+
+// railwayGrid builds the track relative to the first tile. ground returns the ground
+// height of a column, or false when it is unknown; raised track then gets no pillars.
+func railwayGrid(tiles []railTile, opts railOptions, ground func(x, z int) (int, bool)) BlockGrid {
+	grid := make(BlockGrid)
+	o := tiles[0].Pos
+	rel := func(p [3]int) [3]int { return [3]int{p[0] - o[0], p[1] - o[1], p[2] - o[2]} }
+	occupied := make(map[[2]int]bool, len(tiles))
+	for _, t := range tiles {
+		occupied[[2]int{t.Pos[0], t.Pos[2]}] = true
+	}
+	for i, t := range tiles {
+		p := rel(t.Pos)
+		rail := fmt.Sprintf("minecraft:rail[shape=%s]", t.Shape)
+		bed := opts.Bed
+		if t.Powered {
+			rail = fmt.Sprintf("minecraft:powered_rail[shape=%s,powered=true]", t.Shape)
+			if opts.Power == "block" {
+				bed = "minecraft:redstone_block"
+			}
+		}
+		grid.Set(p[0], p[1], p[2], rail)
+		grid.Set(p[0], p[1]-1, p[2], bed)
+
+		if t.Powered && opts.Power == "torch" {
+			// Beside the rail, on whichever side is free
+			axisX := t.Shape == "east_west" || t.Shape == "ascending_east" || t.Shape == "ascending_west"
+			for _, s := range []int{1, -1} {
+				side := [2]int{t.Pos[0], t.Pos[2] + s}
+				if !axisX {
+					side = [2]int{t.Pos[0] + s, t.Pos[2]}
+				}
+				if occupied[side] {
+					continue
+				}
+				grid.Set(side[0]-o[0], p[1], side[1]-o[2], "minecraft:redstone_torch")
+				grid.SetIfEmpty(side[0]-o[0], p[1]-1, side[1]-o[2], opts.Bed)
+				break
+			}
+		}
+
+		if i%railPillarSpacing == 0 || i == len(tiles)-1 || !t.Straight() {
+			if g, ok := ground(t.Pos[0], t.Pos[2]); ok {
+				for y := g + 1; y < t.Pos[1]-1; y++ {
+					grid.SetIfEmpty(p[0], y-o[1], p[2], opts.Support)
+				}
+			}
+		}
+	}
+	// Clear the way last, so it never replaces the track of a crossing
+	for _, t := range tiles {
+		p := rel(t.Pos)
+		for dy := 1; dy <= railClearance; dy++ {
+			grid.SetIfEmpty(p[0], p[1]+dy, p[2], airBlock)
+		}
+	}
+	return grid
+}
+
+// parseRailPoints parses a list of points separated by ";": waypoint references such as
+// "station" or "station+0,1,5", or absolute "x y z" coordinates.
+func (ms *MinecraftServer) parseRailPoints(s string) ([][3]int, error) {
+	var points [][3]int
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if isWaypointRef(item) {
+			p, err := ms.waypoints.ResolveRef(item, 0)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, p)
+			continue
+		}
+		fields := strings.FieldsFunc(item, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid point %q (expected a waypoint or absolute x y z)", item)
+		}
+		var p [3]int
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid point %q (expected a waypoint or absolute x y z)", item)
+			}
+			p[i] = int(math.Floor(v))
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// registerRailwayTools adds the railway builder.
+func (ms *MinecraftServer) registerRailwayTools() {
+	ms.AddTool(mcp.NewTool("minecraft_build_railway",
+		mcp.WithDescription("Lay a minecart railway through a list of points. The track goes along X and then Z between points with correctly shaped curves and slopes, powered rails keep carts at speed, pillars support raised track and the way is cleared through hills."),
+		mcp.WithString("points", mcp.Description("Points separated by ';': waypoint names (e.g. 'station_a;station_b') or absolute 'x y z' coordinates of the rails"), mcp.Required()),
+		mcp.WithNumber("poweredSpacing", mcp.Description(fmt.Sprintf("Blocks between powered rails on flat track, 8 to 64 (optional, default: %d)", defaultPoweredSpacing))),
+		mcp.WithString("power", mcp.Description("How powered rails are powered: block (redstone block below) or torch (redstone torch beside) (optional, default: block)")),
+		mcp.WithString("bedBlock", mcp.Description("Block under the rails (optional, default: minecraft:stone_bricks)")),
+		mcp.WithString("supportBlock", mcp.Description("Block of the pillars under raised track (optional, default: the bed block)")),
+		mcp.WithString("dimension", mcp.Description("Dimension of the world save read for pillars (optional, default: overworld)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleBuildRailway)
+}
+
+// handleBuildRailway implements the minecraft_build_railway tool.
+func (ms *MinecraftServer) handleBuildRailway(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	pointsArg, err := getStringArg(args, "points", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	points, err := ms.parseRailPoints(pointsArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	spacing, err := getIntArg(args, "poweredSpacing", defaultPoweredSpacing)
+	if err != nil || spacing < 8 || spacing > 64 {
+		return mcp.NewToolResultError("poweredSpacing must be between 8 and 64"), nil
+	}
+	opts := railOptions{Bed: "minecraft:stone_bricks", Power: "block"}
+	if v, _ := getStringArg(args, "power", false); v != "" {
+		opts.Power = v
+	}
+	if opts.Power != "block" && opts.Power != "torch" {
+		return mcp.NewToolResultError("power must be block or torch"), nil
+	}
+	if v, _ := getStringArg(args, "bedBlock", false); v != "" {
+		opts.Bed = v
+	}
+	opts.Support = opts.Bed
+	if v, _ := getStringArg(args, "supportBlock", false); v != "" {
+		opts.Support = v
+	}
+	for _, b := range []string{opts.Bed, opts.Support} {
+		if err := validateBlockID(b); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	dimension, _ := getStringArg(args, "dimension", false)
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+
+	tiles, err := railRoute(points, spacing)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	tr := &terrain{ms: ms, dimension: dimension}
+	note := ""
+	if _, err := tr.World(); err != nil {
+		ms.logger.Warn().Err(err).Msg("World save not readable, building the railway without pillars")
+		note = ", without pillars as the world save is not readable"
+	}
+	ground := func(x, z int) (int, bool) {
+		col, err := tr.Column(x, z)
+		return col.Ground, err == nil
+	}
+	grid := railwayGrid(tiles, opts, ground)
+
+	powered := 0
+	for _, t := range tiles {
+		if t.Powered {
+			powered++
+		}
+	}
+	o := tiles[0].Pos
+	origin := Position{{CoordAbsolute, float64(o[0])}, {CoordAbsolute, float64(o[1])}, {CoordAbsolute, float64(o[2])}}
+	commands, err := grid.Commands(origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	name := fmt.Sprintf("railway of %d rails with %d powered%s", len(tiles), powered, note)
+	return ms.runBuild(ctx, name, commands, dryRun)
+}
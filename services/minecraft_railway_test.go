@@ -0,0 +1,121 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRailRoute(t *testing.T) {
+	// East 6 blocks climbing 2, then south 3 blocks
+	tiles, err := railRoute([][3]int{{0, 64, 0}, {6, 66, 0}, {6, 66, 3}}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shapes []string
+	for _, tile := range tiles {
+		shapes = append(shapes, tile.Shape)
+	}
+	want := []string{
+		"east_west", "ascending_east", "east_west", "east_west", "ascending_east", "east_west",
+		"south_west", "north_south", "north_south", "north_south",
+	}
+	if !reflect.DeepEqual(shapes, want) {
+		t.Errorf("shapes = %q, want %q", shapes, want)
+	}
+	if tiles[2].Pos != [3]int{2, 65, 0} || tiles[6].Pos != [3]int{6, 66, 0} || tiles[9].Pos != [3]int{6, 66, 3} {
+		t.Errorf("unexpected positions: %v", tiles)
+	}
+	for i, powered := range []bool{true, true, false, false, true, false, false, false, false, false} {
+		if tiles[i].Powered != powered {
+			t.Errorf("tile %d (%s) powered = %v", i, tiles[i].Shape, tiles[i].Powered)
+		}
+	}
+
+	// Going down, the lower rail ascends back towards the start
+	tiles, _ = railRoute([][3]int{{0, 64, 0}, {0, 63, -2}}, 8)
+	if tiles[2].Shape != "ascending_south" || tiles[2].Pos[1] != 63 {
+		t.Errorf("descent shapes = %v", tiles)
+	}
+
+	if _, err := railRoute([][3]int{{0, 64, 0}, {2, 70, 0}}, 8); err == nil {
+		t.Errorf("too steep track should fail")
+	}
+	if _, err := railRoute([][3]int{{0, 64, 0}, {0, 70, 0}}, 8); err == nil {
+		t.Errorf("vertical track should fail")
+	}
+}
+
+func TestRailCurve(t *testing.T) {
+	for _, c := range []struct {
+		a, b [2]int
+		want string
+	}{
+		{[2]int{-1, 0}, [2]int{0, 1}, "south_west"},
+		{[2]int{0, -1}, [2]int{1, 0}, "north_east"},
+		{[2]int{1, 0}, [2]int{0, -1}, "north_east"},
+	} {
+		if got := railCurve(c.a, c.b); got != c.want {
+			t.Errorf("railCurve(%v, %v) = %s, want %s", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestRailwayGrid(t *testing.T) {
+	tiles, _ := railRoute([][3]int{{0, 70, 0}, {4, 70, 0}}, 8)
+	grid := railwayGrid(tiles, railOptions{Bed: "minecraft:stone", Support: "minecraft:cobblestone", Power: "torch"},
+		func(x, z int) (int, bool) { return 64, true })
+	for p, want := range map[[3]int]string{
+		{0, 0, 0}:  "minecraft:powered_rail[shape=east_west,powered=true]",
+		{0, 0, 1}:  "minecraft:redstone_torch",
+		{1, 0, 0}:  "minecraft:rail[shape=east_west]",
+		{1, -1, 0}: "minecraft:stone",
+		{0, -2, 0}: "minecraft:cobblestone",
+		{0, -5, 0}: "minecraft:cobblestone",
+		{0, -6, 0}: "",
+		{1, -2, 0}: "",
+		{4, -5, 0}: "minecraft:cobblestone",
+		{2, 2, 0}:  airBlock,
+	} {
+		if grid[p] != want {
+			t.Errorf("grid%v = %q, want %q", p, grid[p], want)
+		}
+	}
+}
+
+func TestMinecraftServer_parseRailPoints(t *testing.T) {
+	ms := &MinecraftServer{waypoints: NewWaypointStore(filepath.Join(t.TempDir(), "waypoints.json"))}
+	if err := ms.waypoints.Set(Waypoint{Name: "station", X: 10, Y: 64, Z: -5}); err != nil {
+		t.Fatal(err)
+	}
+	points, err := ms.parseRailPoints("station; 20 64 -5 ;station+0,1,8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][3]int{{10, 64, -5}, {20, 64, -5}, {10, 65, 3}}; !reflect.DeepEqual(points, want) {
+		t.Errorf("parseRailPoints = %v, want %v", points, want)
+	}
+	if _, err := ms.parseRailPoints("~ 64 0;station"); err == nil {
+		t.Errorf("relative coordinates should fail")
+	}
+}
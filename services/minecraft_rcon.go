@@ -0,0 +1,143 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	rconTypeResponse = 0
+	rconTypeCommand  = 2
+	rconTypeLogin    = 3
+	// rconMaxPacket is the largest packet the server sends.
+	rconMaxPacket = 4096 + 10
+	// rconTimeout is how long a single RCON exchange may take.
+	rconTimeout = 30 * time.Second
+)
+
+// RconClient runs commands on a Minecraft server over RCON. It lets the CLI talk to
+// a server that was started by another process.
+type RconClient struct {
+	conn net.Conn
+	id   int32
+}
+
+// DialRcon connects to the RCON port at addr and logs in with password.
+func DialRcon(addr, password string, timeout time.Duration) (*RconClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &RconClient{conn: conn}
+	id, _, err := c.exchange(rconTypeLogin, password)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("RCON login failed: %w", err)
+	}
+	if id == -1 {
+		_ = conn.Close()
+		return nil, fmt.Errorf("RCON login failed: wrong password")
+	}
+	return c, nil
+}
+
+// Command runs command and returns the response of the server.
+func (c *RconClient) Command(command string) (string, error) {
+	_, body, err := c.exchange(rconTypeCommand, command)
+	return body, err
+}
+
+// Close closes the connection.
+func (c *RconClient) Close() error {
+	return c.conn.Close()
+}
+
+// exchange sends one packet and reads the response packet.
+func (c *RconClient) exchange(typ int32, body string) (int32, string, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(rconTimeout)); err != nil {
+		return 0, "", err
+	}
+	c.id++
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	_ = binary.Write(&buf, binary.LittleEndian, c.id)
+	_ = binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return 0, "", err
+	}
+
+	var size int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return 0, "", err
+	}
+	if size < 10 || size > rconMaxPacket {
+		return 0, "", fmt.Errorf("invalid RCON packet size %d", size)
+	}
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, packet); err != nil {
+		return 0, "", err
+	}
+	id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+	if id != -1 && id != c.id {
+		return 0, "", fmt.Errorf("unexpected RCON response id %d", id)
+	}
+	return id, string(bytes.TrimRight(packet[8:], "\x00")), nil
+}
+
+// dialRcon connects to the RCON port configured in server.properties. It returns nil
+// without an error when RCON is disabled or nothing listens on the port, i.e. no
+// server is reachable.
+func (ms *MinecraftServer) dialRcon() (*RconClient, error) {
+	props, err := ReadServerProperties(ms.propertiesPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if enabled, _ := props.Get("enable-rcon"); enabled != "true" {
+		return nil, nil
+	}
+	port := strconv.Itoa(defaultRconPort)
+	if p, ok := props.Get("rcon.port"); ok && p != "" {
+		port = p
+	}
+	host, _ := props.Get("server-ip")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	password, _ := props.Get("rcon.password")
+	c, err := DialRcon(net.JoinHostPort(host, port), password, 5*time.Second)
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, nil
+	}
+	return c, err
+}
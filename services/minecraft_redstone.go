@@ -0,0 +1,569 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	redstoneWire  = "minecraft:redstone_wire"
+	redstoneTorch = "minecraft:redstone_torch[lit=true]"
+	redstoneLamp  = "minecraft:redstone_lamp[lit=false]"
+	floorLever    = "minecraft:lever[face=floor,facing=east,powered=false]"
+)
+
+// redstoneDirections are the horizontal directions in clockwise order.
+var redstoneDirections = []string{"east", "south", "west", "north"}
+
+// directionVector returns the x, z step of a horizontal direction.
+func directionVector(d string) [2]int {
+	switch d {
+	case "east":
+		return [2]int{1, 0}
+	case "west":
+		return [2]int{-1, 0}
+	case "south":
+		return [2]int{0, 1}
+	}
+	return [2]int{0, -1}
+}
+
+func repeaterState(facing string, delay int) string {
+	return fmt.Sprintf("minecraft:repeater[facing=%s,delay=%d,locked=false,powered=false]", facing, delay)
+}
+
+func wallTorchState(facing string) string {
+	return fmt.Sprintf("minecraft:redstone_wall_torch[facing=%s,lit=true]", facing)
+}
+
+// blockProperty returns a property of a block state, e.g. "east" for facing of
+// "minecraft:repeater[facing=east,delay=1]".
+func blockProperty(state, key string) string {
+	i := strings.IndexByte(state, '[')
+	if i < 0 {
+		return ""
+	}
+	for _, kv := range strings.Split(strings.TrimSuffix(state[i+1:], "]"), ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// isRedstoneComponent reports whether the block is a redstone component that needs a
+// block to stand on or to be attached to.
+func isRedstoneComponent(state string) bool {
+	switch blockName(state) {
+	case "minecraft:redstone_wire", "minecraft:repeater", "minecraft:comparator",
+		"minecraft:redstone_torch", "minecraft:redstone_wall_torch", "minecraft:lever":
+		return true
+	}
+	return false
+}
+
+// connectsToWire reports whether redstone dust connects to the block in direction d.
+func connectsToWire(state, d string) bool {
+	switch blockName(state) {
+	case "minecraft:redstone_wire", "minecraft:redstone_torch", "minecraft:redstone_wall_torch",
+		"minecraft:lever", "minecraft:comparator", "minecraft:redstone_block":
+		return true
+	case "minecraft:repeater":
+		f := directionVector(blockProperty(state, "facing"))
+		v := directionVector(d)
+		return f[0] == v[0] && f[1] == v[1] || f[0] == -v[0] && f[1] == -v[1]
+	}
+	return false
+}
+
+// redstoneCircuit is a circuit laid out with the signal flowing towards +X, the inputs on
+// the west side and y 0 as the floor.
+type redstoneCircuit struct {
+	Blocks  BlockGrid
+	Inputs  map[string][3]int // blocks to power, each with a lever on top when requested
+	Outputs map[string][3]int // lamps showing the outputs
+	Summary string
+}
+
+func newRedstoneCircuit() *redstoneCircuit {
+	return &redstoneCircuit{Blocks: make(BlockGrid), Inputs: make(map[string][3]int), Outputs: make(map[string][3]int)}
+}
+
+// Oriented returns the blocks of the circuit turned so the signal flows towards facing,
+// with the north-west corner at 0, 0 and the connections of the dust filled in, and the
+// positions of the inputs and outputs in the turned circuit.
+func (c *redstoneCircuit) Oriented(facing, base string) (BlockGrid, [2]map[string][3]int, error) {
+	var ports [2]map[string][3]int
+	turns := -1
+	for i, d := range redstoneDirections {
+		if d == facing {
+			turns = i
+		}
+	}
+	if turns < 0 {
+		return nil, ports, fmt.Errorf("invalid facing: %s (expected north, south, east or west)", facing)
+	}
+	turn := func(p [3]int) [3]int {
+		for i := 0; i < turns; i++ {
+			p = [3]int{-p[2], p[1], p[0]}
+		}
+		return p
+	}
+
+	// Everything on the first level stands on the floor
+	blocks := make(BlockGrid, len(c.Blocks))
+	for p, b := range c.Blocks {
+		blocks[p] = b
+		if p[1] == 1 {
+			if _, ok := c.Blocks[[3]int{p[0], 0, p[2]}]; !ok {
+				blocks[[3]int{p[0], 0, p[2]}] = base
+			}
+		}
+	}
+	turned := make(BlockGrid, len(blocks))
+	for p, b := range blocks {
+		if f := blockProperty(b, "facing"); f != "" {
+			for i, d := range redstoneDirections {
+				if d == f {
+					b = strings.Replace(b, "facing="+f, "facing="+redstoneDirections[(i+turns)%4], 1)
+				}
+			}
+		}
+		turned[turn(p)] = b
+	}
+	lo, _ := turned.Bounds()
+	grid := make(BlockGrid, len(turned))
+	for p, b := range turned {
+		grid[[3]int{p[0] - lo[0], p[1] - lo[1], p[2] - lo[2]}] = b
+	}
+	for i, m := range []map[string][3]int{c.Inputs, c.Outputs} {
+		ports[i] = make(map[string][3]int, len(m))
+		for name, p := range m {
+			p = turn(p)
+			ports[i][name] = [3]int{p[0] - lo[0], p[1] - lo[1], p[2] - lo[2]}
+		}
+	}
+
+	// Dust connects to its neighbors; with a single connection it runs straight through
+	for p, b := range grid {
+		if b != redstoneWire {
+			continue
+		}
+		sides := make(map[string]bool, 4)
+		for _, d := range redstoneDirections {
+			v := directionVector(d)
+			if n, ok := grid[[3]int{p[0] + v[0], p[1], p[2] + v[1]}]; ok && connectsToWire(n, d) {
+				sides[d] = true
+			}
+		}
+		if len(sides) == 1 {
+			for d := range sides {
+				sides[redstoneDirections[(indexOf(redstoneDirections, d)+2)%4]] = true
+			}
+		}
+		var props []string
+		for _, d := range []string{"east", "north", "south", "west"} {
+			v := "none"
+			if sides[d] || len(sides) == 0 {
+				v = "side"
+			}
+			props = append(props, d+"="+v)
+		}
+		grid[p] = fmt.Sprintf("%s[%s,power=0]", redstoneWire, strings.Join(props, ","))
+	}
+	return grid, ports, nil
+}
+
+// indexOf returns the index of s in list, or -1.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// plaCircuit lays out a sum of products as a NOR-NOR array. Every input gets a block at
+// x 1 (with a lever on top), its signal runs east on a rail and, when needed, inverted on
+// a second rail three blocks south. Each product term is a collector line running south
+// at y 4 above the rails: a tapped rail powers a block whose torch powers the collector
+// while the literal is false, so the torch at the end of the collector is the AND of the
+// literals. The term torches all feed the output line, which ORs them into a lamp.
+func plaCircuit(vars []string, terms []implicant, base string, levers bool) *redstoneCircuit {
+	c := newRedstoneCircuit()
+	set := func(x, y, z int, b string) { c.Blocks.Set(x, y, z, b) }
+
+	type lane struct {
+		z    int
+		v    int
+		neg  bool
+		last int // last term tapping the lane, -1 if none
+	}
+	tapped := func(t implicant, l lane) bool {
+		return t.Mask>>l.v&1 == 0 && (t.Value>>l.v&1 == 0) == l.neg
+	}
+	var lanes []lane
+	for v := range vars {
+		for _, neg := range []bool{false, true} {
+			l := lane{z: 3 * len(lanes), v: v, neg: neg, last: -1}
+			for k, t := range terms {
+				if tapped(t, l) {
+					l.last = k
+				}
+			}
+			if neg && l.last < 0 {
+				continue
+			}
+			lanes = append(lanes, l)
+		}
+	}
+	termX := func(k int) int { return 5 + 3*k }
+
+	// Inputs
+	for i, l := range lanes {
+		if !l.neg {
+			set(1, 1, l.z, base)
+			if levers {
+				set(1, 2, l.z, floorLever)
+			}
+			c.Inputs[vars[l.v]] = [3]int{1, 1, l.z}
+			if l.last >= 0 {
+				set(2, 1, l.z, redstoneWire)
+				set(3, 1, l.z, redstoneWire)
+			}
+			continue
+		}
+		// The inverted rail: dust from the input block to a block with a torch
+		pos := lanes[i-1].z
+		set(1, 1, pos+1, redstoneWire)
+		set(1, 1, pos+2, redstoneWire)
+		set(1, 1, l.z, base)
+		set(2, 1, l.z, wallTorchState("east"))
+		set(3, 1, l.z, redstoneWire)
+	}
+
+	// Rails, refreshed by a repeater before every term
+	for _, l := range lanes {
+		for k := 0; k <= l.last; k++ {
+			x := termX(k)
+			set(x-1, 1, l.z, repeaterState("west", 1))
+			if tapped(terms[k], l) {
+				set(x, 1, l.z, base)
+				set(x, 2, l.z, redstoneTorch)
+			} else {
+				set(x, 1, l.z, redstoneWire)
+			}
+			if k < l.last {
+				set(x+1, 1, l.z, redstoneWire)
+			}
+		}
+	}
+
+	// Term collectors and the output line
+	end := 3 * len(lanes)
+	out := end + 2
+	for k := range terms {
+		x := termX(k)
+		for z := 0; z < end; z++ {
+			set(x, 3, z, base)
+			if z%3 == 1 {
+				set(x, 4, z, repeaterState("north", 1))
+			} else {
+				set(x, 4, z, redstoneWire)
+			}
+		}
+		set(x, 4, end, base)
+		set(x, 4, end+1, wallTorchState("south"))
+		for dx := 0; dx < 3; dx++ {
+			set(x+dx, 3, out, base)
+		}
+		set(x, 4, out, redstoneWire)
+		set(x+1, 4, out, repeaterState("west", 1))
+		set(x+2, 4, out, redstoneWire)
+	}
+	lamp := [3]int{termX(len(terms)-1) + 3, 4, out}
+	set(lamp[0], lamp[1], lamp[2], redstoneLamp)
+	c.Outputs["out"] = lamp
+	c.Summary = fmt.Sprintf("out = %s", formatSOP(terms, vars))
+	return c
+}
+
+// clockCircuit lays out a torch clock: the torch on the input block feeds a loop of
+// repeaters back into the block. period is in redstone ticks (0.1 s); powering the input
+// block stops the clock.
+func clockCircuit(period int, base string, levers bool) *redstoneCircuit {
+	c := newRedstoneCircuit()
+	set := func(x, y, z int, b string) { c.Blocks.Set(x, y, z, b) }
+
+	// Half a period is the torch delay plus the repeater delays
+	delay := period/2 - 1
+	n := (delay + 3) / 4
+	set(0, 1, 0, base)
+	if levers {
+		set(0, 2, 0, floorLever)
+	}
+	set(1, 1, 0, wallTorchState("east"))
+	for x := 2; x <= n+1; x++ {
+		set(x, 1, 0, redstoneWire)
+	}
+	set(n+1, 1, 1, redstoneWire)
+	set(n+1, 1, 2, redstoneWire)
+	// Spread the delay over the repeaters, at most 4 ticks each
+	for i, left := 0, delay; i < n; i++ {
+		d := min(4, left-(n-1-i))
+		set(n-i, 1, 2, repeaterState("east", d))
+		left -= d
+	}
+	set(0, 1, 2, redstoneWire)
+	set(0, 1, 1, redstoneWire)
+	set(n+2, 1, 0, redstoneWire)
+	set(n+3, 1, 0, redstoneLamp)
+	c.Inputs["stop"] = [3]int{0, 1, 0}
+	c.Outputs["clock"] = [3]int{n + 3, 1, 0}
+	c.Summary = fmt.Sprintf("clock with a period of %d redstone ticks (%.1f s)", period, float64(period)/10)
+	return c
+}
+
+// counterCircuit lays out a ripple counter of copper bulbs, which toggle on every rising
+// edge of their input. A comparator reads each bulb into a lamp showing the bit; the torch
+// on the lamp inverts it, so the next bulb toggles when the bit falls from 1 to 0.
+func counterCircuit(bitCount int, base string, levers bool) *redstoneCircuit {
+	c := newRedstoneCircuit()
+	set := func(x, y, z int, b string) { c.Blocks.Set(x, y, z, b) }
+
+	set(0, 1, 0, base)
+	if levers {
+		set(0, 2, 0, floorLever)
+	}
+	c.Inputs["in"] = [3]int{0, 1, 0}
+	for i := 0; i < bitCount; i++ {
+		x := 5 * i
+		if i > 0 {
+			set(x, 1, 0, redstoneWire)
+		}
+		// The torch of the previous bit is lit while that bit is 0, so the bulb starts
+		// powered; placing it unpowered would toggle it right away
+		set(x+1, 1, 0, fmt.Sprintf("minecraft:waxed_copper_bulb[lit=false,powered=%t]", i > 0))
+		set(x+2, 1, 0, "minecraft:comparator[facing=west,mode=compare,powered=false]")
+		set(x+3, 1, 0, redstoneLamp)
+		if i < bitCount-1 {
+			set(x+4, 1, 0, wallTorchState("east"))
+		}
+		c.Outputs[fmt.Sprintf("bit%d", i)] = [3]int{x + 3, 1, 0}
+	}
+	c.Summary = fmt.Sprintf("%d bit counter, counting the rising edges of in", bitCount)
+	return c
+}
+
+// redstoneCommands orders the commands so blocks exist before the components standing on
+// or attached to them, and copper bulbs come last, once their input has settled.
+func redstoneCommands(grid BlockGrid, origin Position, maxVolume int) ([]string, error) {
+	phases := make([]BlockGrid, 3)
+	for i := range phases {
+		phases[i] = make(BlockGrid)
+	}
+	for p, b := range grid {
+		switch {
+		case strings.HasSuffix(blockName(b), "copper_bulb"):
+			phases[2][p] = b
+		case isRedstoneComponent(b):
+			phases[1][p] = b
+		default:
+			phases[0][p] = b
+		}
+	}
+	var commands []string
+	for _, g := range phases {
+		c, err := g.Commands(origin, maxVolume)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, c...)
+	}
+	return commands, nil
+}
+
+// gateExpression builds the expression of a named gate over the inputs a, b, c, ...
+func gateExpression(gate string, inputs int) (string, error) {
+	names := make([]string, inputs)
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	switch gate {
+	case "not":
+		return "!a", nil
+	case "and", "nand":
+		e := strings.Join(names, " & ")
+		if gate == "nand" {
+			e = "!(" + e + ")"
+		}
+		return e, nil
+	case "or", "nor":
+		e := strings.Join(names, " | ")
+		if gate == "nor" {
+			e = "!(" + e + ")"
+		}
+		return e, nil
+	case "xor", "xnor":
+		e := strings.Join(names, " ^ ")
+		if gate == "xnor" {
+			e = "!(" + e + ")"
+		}
+		return e, nil
+	}
+	return "", fmt.Errorf("unknown circuit: %s", gate)
+}
+
+// registerRedstoneTools adds the redstone circuit compiler.
+func (ms *MinecraftServer) registerRedstoneTools() {
+	ms.AddTool(mcp.NewTool("minecraft_build_redstone",
+		mcp.WithDescription("Build a working redstone circuit from a logical description: a gate, a boolean expression (compiled to a minimal sum of products), a clock, a T flip-flop or a counter. Inputs get levers and outputs get lamps; the result lists where they are."),
+		mcp.WithString("circuit", mcp.Description("and, or, not, nand, nor, xor, xnor, expression, clock, t_flip_flop or counter"), mcp.Required()),
+		mcp.WithString("expression", mcp.Description("Boolean expression for circuit=expression, e.g. '(a & b) | !c' (operators: ! & ^ |, up to 6 inputs)")),
+		mcp.WithNumber("inputs", mcp.Description("Number of inputs of and, or, nand, nor, xor and xnor gates, 2 to 6 (optional, default: 2)")),
+		mcp.WithNumber("period", mcp.Description("Clock period in redstone ticks (0.1 s), 8 to 64 (optional, default: 20)")),
+		mcp.WithNumber("bits", mcp.Description("Number of counter bits, 1 to 8 (optional, default: 4)")),
+		mcp.WithString("x", mcp.Description("X coordinate of the north-west corner of the floor"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate of the floor"), mcp.Required()),
+		mcp.WithString("z", mcp.Description("Z coordinate of the north-west corner of the floor"), mcp.Required()),
+		mcp.WithString("facing", mcp.Description("Direction the signal flows from the inputs to the outputs: north, south, east or west (optional, default: east)")),
+		mcp.WithString("baseBlock", mcp.Description("Block of the floor and the conducting blocks (optional, default: minecraft:smooth_stone)")),
+		mcp.WithBoolean("levers", mcp.Description("Put levers on the inputs (optional, default: true)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleBuildRedstone)
+}
+
+// handleBuildRedstone implements the minecraft_build_redstone tool.
+func (ms *MinecraftServer) handleBuildRedstone(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	circuit, err := getStringArg(args, "circuit", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	origin, err := ms.getPositionArg(args, "x", "y", "z")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	facing, _ := getStringArg(args, "facing", false)
+	if facing == "" {
+		facing = "east"
+	}
+	base, _ := getStringArg(args, "baseBlock", false)
+	if base == "" {
+		base = "minecraft:smooth_stone"
+	}
+	if err := validateBlockID(base); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	levers := true
+	if _, ok := args["levers"]; ok {
+		if levers, err = getBoolArg(args, "levers", false); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+
+	var c *redstoneCircuit
+	switch circuit {
+	case "clock":
+		period, err := getIntArg(args, "period", 20)
+		if err != nil || period < 8 || period > 64 {
+			return mcp.NewToolResultError("period must be between 8 and 64 redstone ticks"), nil
+		}
+		c = clockCircuit(period&^1, base, levers)
+	case "t_flip_flop", "counter":
+		if !ms.config.VersionAtLeast("1.21") {
+			return mcp.NewToolResultError(fmt.Sprintf("%s uses copper bulbs, which need Minecraft 1.21 or newer (game_version is %s)", circuit, ms.config.GameVersion)), nil
+		}
+		bitCount := 1
+		if circuit == "counter" {
+			if bitCount, err = getIntArg(args, "bits", 4); err != nil || bitCount < 1 || bitCount > 8 {
+				return mcp.NewToolResultError("bits must be between 1 and 8"), nil
+			}
+		}
+		c = counterCircuit(bitCount, base, levers)
+		if circuit == "t_flip_flop" {
+			c.Outputs = map[string][3]int{"q": c.Outputs["bit0"]}
+			c.Summary = "T flip-flop, toggling q on every rising edge of in"
+		}
+	default:
+		expression, _ := getStringArg(args, "expression", false)
+		if circuit != "expression" {
+			inputs, err := getIntArg(args, "inputs", 2)
+			if err != nil || inputs < 2 || inputs > maxLogicInputs {
+				return mcp.NewToolResultError(fmt.Sprintf("inputs must be between 2 and %d", maxLogicInputs)), nil
+			}
+			if expression, err = gateExpression(circuit, inputs); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		} else if expression == "" {
+			return mcp.NewToolResultError("expression is required for circuit=expression"), nil
+		}
+		e, vars, err := parseBoolExpr(expression)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(vars) > maxLogicInputs {
+			return mcp.NewToolResultError(fmt.Sprintf("the expression has %d inputs, the limit is %d", len(vars), maxLogicInputs)), nil
+		}
+		minterms := truthTable(e, vars)
+		if len(minterms) == 0 || len(minterms) == 1<<len(vars) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is always %t, it needs no circuit", expression, len(minterms) > 0)), nil
+		}
+		c = plaCircuit(vars, minimizeSOP(minterms), base, levers)
+	}
+
+	grid, ports, err := c.Oriented(facing, base)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	commands, err := redstoneCommands(grid, origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %s", circuit, c.Summary))
+	for g, label := range []string{"inputs", "outputs"} {
+		names := make([]string, 0, len(ports[g]))
+		for name := range ports[g] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			p := ports[g][name]
+			pos, err := origin.Offset(float64(p[0]), float64(p[1]), float64(p[2]))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			sep := ", "
+			if i == 0 {
+				sep = "; " + label + ": "
+			}
+			sb.WriteString(fmt.Sprintf("%s%s at %s", sep, name, pos))
+		}
+	}
+	return ms.runBuild(ctx, sb.String(), commands, dryRun)
+}
@@ -0,0 +1,286 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// redstoneSim settles a circuit without clocks or bulbs, ignoring delays.
+type redstoneSim struct {
+	grid  BlockGrid
+	power map[[3]int]int  // dust
+	on    map[[3]int]bool // torches, repeaters, levers
+}
+
+func simStep(p [3]int, d string, dy int) [3]int {
+	v := directionVector(d)
+	return [3]int{p[0] + v[0], p[1] + dy, p[2] + v[1]}
+}
+
+func opposite(d string) string {
+	return redstoneDirections[(indexOf(redstoneDirections, d)+2)%4]
+}
+
+// attachment returns the block a torch or lever hangs on.
+func (s *redstoneSim) attachment(p [3]int) [3]int {
+	b := s.grid[p]
+	if blockName(b) == "minecraft:redstone_wall_torch" {
+		return simStep(p, opposite(blockProperty(b, "facing")), 0)
+	}
+	return [3]int{p[0], p[1] - 1, p[2]}
+}
+
+// strong reports whether a solid block is powered so it powers dust next to it.
+func (s *redstoneSim) strong(p [3]int) bool {
+	for _, d := range redstoneDirections {
+		n := simStep(p, d, 0)
+		b := s.grid[n]
+		switch blockName(b) {
+		case "minecraft:repeater":
+			if s.on[n] && simStep(n, opposite(blockProperty(b, "facing")), 0) == p {
+				return true
+			}
+		case "minecraft:lever":
+			if s.on[n] && s.attachment(n) == p {
+				return true
+			}
+		}
+	}
+	// Torches only power the block above them
+	below, above := [3]int{p[0], p[1] - 1, p[2]}, [3]int{p[0], p[1] + 1, p[2]}
+	return strings.Contains(blockName(s.grid[below]), "redstone_") && strings.HasSuffix(blockName(s.grid[below]), "torch") && s.on[below] ||
+		blockName(s.grid[above]) == "minecraft:lever" && s.on[above]
+}
+
+// powered reports whether a solid block is powered at all, including by dust.
+func (s *redstoneSim) powered(p [3]int) bool {
+	if s.strong(p) {
+		return true
+	}
+	if s.power[[3]int{p[0], p[1] + 1, p[2]}] > 0 {
+		return true
+	}
+	for _, d := range redstoneDirections {
+		n := simStep(p, opposite(d), 0) // dust west of p points east into it
+		if s.power[n] > 0 && blockProperty(s.grid[n], d) == "side" {
+			return true
+		}
+	}
+	return false
+}
+
+// signal returns the signal a component at p sends to its neighbor in direction d.
+func (s *redstoneSim) signal(p [3]int, d string) int {
+	b := s.grid[p]
+	switch blockName(b) {
+	case "minecraft:redstone_wire":
+		return s.power[p]
+	case "minecraft:redstone_torch", "minecraft:redstone_wall_torch", "minecraft:lever":
+		if s.on[p] && s.attachment(p) != simStep(p, d, 0) {
+			return 15
+		}
+	case "minecraft:repeater":
+		if s.on[p] && blockProperty(b, "facing") == opposite(d) {
+			return 15
+		}
+	case "":
+	default:
+		if s.powered(p) {
+			return 15
+		}
+	}
+	return 0
+}
+
+func (s *redstoneSim) settle(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		if !s.settleOnce() {
+			return
+		}
+	}
+	t.Fatalf("the circuit does not settle")
+}
+
+func (s *redstoneSim) settleOnce() bool {
+	{
+		changed := false
+		for p, b := range s.grid {
+			switch blockName(b) {
+			case "minecraft:redstone_wire":
+				v := 0
+				for _, d := range redstoneDirections {
+					n := simStep(p, d, 0)
+					switch nb := s.grid[n]; {
+					case blockName(nb) == "minecraft:redstone_wire":
+						v = max(v, s.power[n]-1)
+					case isRedstoneComponent(nb):
+						v = max(v, s.signal(n, opposite(d)))
+					case nb != "" && s.strong(n):
+						v = max(v, 15)
+					}
+				}
+				if below := [3]int{p[0], p[1] - 1, p[2]}; s.grid[below] != "" && s.strong(below) {
+					v = 15
+				}
+				if v != s.power[p] {
+					s.power[p], changed = v, true
+				}
+			case "minecraft:redstone_torch", "minecraft:redstone_wall_torch":
+				if lit := !s.powered(s.attachment(p)); lit != s.on[p] {
+					s.on[p], changed = lit, true
+				}
+			case "minecraft:repeater":
+				in := simStep(p, blockProperty(b, "facing"), 0)
+				if on := s.signal(in, opposite(blockProperty(b, "facing"))) > 0; on != s.on[p] {
+					s.on[p], changed = on, true
+				}
+			}
+		}
+		return changed
+	}
+}
+
+func (s *redstoneSim) lit(p [3]int) bool {
+	if s.powered(p) {
+		return true
+	}
+	for _, d := range redstoneDirections {
+		if s.signal(simStep(p, d, 0), opposite(d)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPLACircuit(t *testing.T) {
+	for _, expr := range []string{"!a", "a & b", "a | b | c", "!(a | b)", "a ^ b", "!(a ^ b ^ c)", "(a & b) | !c", "a & !b | c & d"} {
+		e, vars, _ := parseBoolExpr(expr)
+		c := plaCircuit(vars, minimizeSOP(truthTable(e, vars)), "minecraft:stone", true)
+		for _, facing := range []string{"east", "north"} {
+			grid, ports, err := c.Oriented(facing, "minecraft:stone")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for m := 0; m < 1<<len(vars); m++ {
+				s := &redstoneSim{grid: grid, power: make(map[[3]int]int), on: make(map[[3]int]bool)}
+				values := make(map[string]bool)
+				for i, v := range vars {
+					values[v] = m>>i&1 == 1
+					s.on[[3]int{ports[0][v][0], ports[0][v][1] + 1, ports[0][v][2]}] = values[v]
+				}
+				s.settle(t)
+				if got := s.lit(ports[1]["out"]); got != e.Eval(values) {
+					t.Errorf("%s facing %s: output %v for %v", expr, facing, got, values)
+				}
+			}
+		}
+	}
+}
+
+func TestRedstoneCircuit_Oriented(t *testing.T) {
+	c := clockCircuit(20, "minecraft:stone", true)
+	grid, ports, err := c.Oriented("west", "minecraft:stone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Turned around, the input block is the east end and the lamp the west end
+	lo, hi := grid.Bounds()
+	if ports[0]["stop"][0] != hi[0] || ports[1]["clock"][0] != lo[0] || lo != [3]int{0, 0, 0} {
+		t.Errorf("ports = %v, bounds %v %v", ports, lo, hi)
+	}
+	torch := simStep(ports[0]["stop"], "west", 0)
+	if grid[torch] != wallTorchState("west") {
+		t.Errorf("torch = %s", grid[torch])
+	}
+	// 9 ticks of delay: the torch and repeaters of 4, 4 and 1 ticks
+	delays := 0
+	for _, b := range grid {
+		if blockName(b) == "minecraft:repeater" {
+			if blockProperty(b, "facing") != "west" {
+				t.Errorf("repeater %s should take its input from the west", b)
+			}
+			var d int
+			fmt.Sscan(blockProperty(b, "delay"), &d)
+			delays += d
+		}
+	}
+	if delays != 9 {
+		t.Errorf("repeater delays = %d, want 9", delays)
+	}
+	for p, b := range grid {
+		if strings.HasPrefix(b, redstoneWire) && !strings.Contains(b, "=side") {
+			t.Errorf("dust at %v is not connected: %s", p, b)
+		}
+	}
+}
+
+func TestCounterCircuit(t *testing.T) {
+	c := counterCircuit(3, "minecraft:stone", false)
+	commands, err := redstoneCommands(c.Blocks, Position{{CoordAbsolute, 0}, {CoordAbsolute, 64}, {CoordAbsolute, 0}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Bulbs are placed last; the later ones start powered by the torch of the previous bit
+	last := commands[len(commands)-3:]
+	for i, want := range []string{"powered=false", "powered=true", "powered=true"} {
+		if !strings.Contains(last[i], "copper_bulb") || !strings.Contains(last[i], want) {
+			t.Errorf("command %s, want a bulb with %s", last[i], want)
+		}
+	}
+	if len(c.Outputs) != 3 {
+		t.Errorf("outputs = %v", c.Outputs)
+	}
+}
+
+func TestMinecraftServer_handleBuildRedstone(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{GameVersion: "1.20.2"}}
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]interface{}{
+		"circuit": "expression", "expression": "a & b | a & !b", "x": "10", "y": "64", "z": "~", "dryRun": true,
+	}
+	result, err := ms.handleBuildRedstone(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handleBuildRedstone = %v, %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "out = a; inputs: a at 10 65 ~, b at 10 65 ~3; outputs: out at 17 68 ~8") {
+		t.Errorf("unexpected result:\n%s", text)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"circuit": "counter"},
+		{"circuit": "expression", "expression": "a | !a"},
+		{"circuit": "and", "inputs": float64(7)},
+		{"circuit": "latch"},
+	} {
+		args["x"], args["y"], args["z"] = "0", "64", "0"
+		request.Params.Arguments = args
+		if result, _ := ms.handleBuildRedstone(context.Background(), request); !result.IsError {
+			t.Errorf("%v should fail", args)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// coordKeyRegex matches the argument names used for absolute coordinates (x, y1, z2, ...).
+var coordKeyRegex = regexp.MustCompile(`^([xyz])\d*$`)
+
+// pointKeys are the arguments holding whole "x y z" points instead of single
+// coordinates, with the separator between the points ("" for a single point).
+var pointKeys = map[string]string{
+	"destination": "",  // minecraft_teleport
+	"points":      ";", // minecraft_build_railway
+}
+
+// adminTools change the administration of the server or the state of MoLing instead
+// of the world. Replaying them onto another server would, for example, restore a
+// backup or op a player there, so they are only replayed when named in Tools.
+var adminTools = map[string]bool{
+	"minecraft_backup_create":     true,
+	"minecraft_backup_restore":    true,
+	"minecraft_ban":               true,
+	"minecraft_chat_reply":        true,
+	"minecraft_datapack_delete":   true,
+	"minecraft_datapack_write":    true,
+	"minecraft_difficulty":        true,
+	"minecraft_gamerule":          true,
+	"minecraft_kick":              true,
+	"minecraft_log_subscribe":     true,
+	"minecraft_log_unsubscribe":   true,
+	"minecraft_op":                true,
+	"minecraft_schedule_add":      true,
+	"minecraft_schedule_remove":   true,
+	"minecraft_server_properties": true,
+	"minecraft_waypoint_delete":   true,
+	"minecraft_waypoint_set":      true,
+	"minecraft_whitelist":         true,
+}
+
+// ReplayOptions selects and transforms the journal entries to replay.
+type ReplayOptions struct {
+	FromSeq     int      // First sequence number to replay (0: from the beginning)
+	ToSeq       int      // Last sequence number to replay (0: until the end)
+	Tools       []string // Only replay these tools (empty: all tools except the admin tools)
+	Offset      [3]int   // Translation added to every absolute coordinate
+	SkipErrors  bool     // Skip entries that failed when they were recorded
+	StopOnError bool     // Stop at the first entry that fails during replay
+}
+
+// ReplayResult is the outcome of replaying one journal entry.
+type ReplayResult struct {
+	Entry   JournalEntry
+	Result  string
+	IsError bool
+}
+
+// FilterJournal returns the entries selected by opts, in order. Admin tools are left
+// out unless opts.Tools names them.
+func FilterJournal(entries []JournalEntry, opts ReplayOptions) []JournalEntry {
+	var selected []JournalEntry
+	for _, e := range entries {
+		if opts.FromSeq > 0 && e.Seq < opts.FromSeq {
+			continue
+		}
+		if opts.ToSeq > 0 && e.Seq > opts.ToSeq {
+			continue
+		}
+		if opts.SkipErrors && e.IsError {
+			continue
+		}
+		if len(opts.Tools) > 0 {
+			if indexOf(opts.Tools, e.Tool) < 0 {
+				continue
+			}
+		} else if adminTools[e.Tool] {
+			continue
+		}
+		selected = append(selected, e)
+	}
+	return selected
+}
+
+// TranslateArguments returns a copy of args with offset added to every absolute
+// coordinate argument. Relative (~) and local (^) coordinates are left untouched.
+func TranslateArguments(args map[string]interface{}, offset [3]int) map[string]interface{} {
+	translated := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		translated[k] = v
+		if offset == [3]int{} {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if m := coordKeyRegex.FindStringSubmatch(k); m != nil {
+			translated[k] = translateCoord(s, offset[strings.Index("xyz", m[1])])
+		} else if sep, ok := pointKeys[k]; ok {
+			translated[k] = translatePoints(s, sep, offset)
+		}
+	}
+	return translated
+}
+
+// translatePoints adds offset to every "x y z" point of a list separated by sep.
+// Anything else, such as an entity selector, is kept as is.
+func translatePoints(s, sep string, offset [3]int) string {
+	items := []string{s}
+	if sep != "" {
+		items = strings.Split(s, sep)
+	}
+	for i, item := range items {
+		parts := strings.FieldsFunc(item, func(r rune) bool { return r == ' ' || r == ',' })
+		if len(parts) != 3 {
+			continue
+		}
+		for j := range parts {
+			parts[j] = translateCoord(parts[j], offset[j])
+		}
+		items[i] = strings.Join(parts, " ")
+	}
+	return strings.Join(items, sep)
+}
+
+// translateCoord adds delta to an absolute numeric coordinate, anything else is returned as is.
+func translateCoord(coord string, delta int) string {
+	if i, err := strconv.Atoi(coord); err == nil {
+		return strconv.Itoa(i + delta)
+	}
+	if f, err := strconv.ParseFloat(coord, 64); err == nil {
+		return strconv.FormatFloat(f+float64(delta), 'f', -1, 64)
+	}
+	return coord
+}
+
+// WaitReady blocks until the server logged its "Done" line or the timeout is reached.
+func (ms *MinecraftServer) WaitReady(timeout time.Duration) error {
+	ms.mu.Lock()
+	ready := ms.ready
+	ms.mu.Unlock()
+	select {
+	case <-ready:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("minecraft server not ready after %s", timeout)
+	}
+}
+
+// Replay re-executes journal entries through the registered tool handlers, so the
+// same validation and command construction is used as for the live tools.
+func (ms *MinecraftServer) Replay(ctx context.Context, entries []JournalEntry, opts ReplayOptions) ([]ReplayResult, error) {
+	handlers := make(map[string]server.ToolHandlerFunc)
+	for _, st := range ms.Tools() {
+		handlers[st.Tool.Name] = st.Handler
+	}
+
+	var results []ReplayResult
+	for _, e := range FilterJournal(entries, opts) {
+		handler, ok := handlers[e.Tool]
+		if !ok {
+			return results, fmt.Errorf("entry %d: unknown tool %s", e.Seq, e.Tool)
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = e.Tool
+		request.Params.Arguments = TranslateArguments(e.Arguments, opts.Offset)
+
+		ms.logger.Info().Int("seq", e.Seq).Str("tool", e.Tool).Msg("Replaying journal entry")
+		result, err := handler(ctx, request)
+		if err != nil {
+			return results, fmt.Errorf("entry %d: %w", e.Seq, err)
+		}
+		rr := ReplayResult{Entry: e, Result: toolResultText(result), IsError: result.IsError}
+		results = append(results, rr)
+		if rr.IsError && opts.StopOnError {
+			return results, fmt.Errorf("entry %d failed: %s", e.Seq, rr.Result)
+		}
+	}
+	return results, nil
+}
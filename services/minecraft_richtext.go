@@ -0,0 +1,545 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCommandLength is the longest command the server accepts.
+const maxCommandLength = 32500
+
+var (
+	hexColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	// selectorRegex matches a target selector like "@a" or "@p[tag=builder,distance=..10]",
+	// including @n (the nearest entity) of 1.21.
+	selectorRegex = regexp.MustCompile(`^@[paresn](\[.*\])?$`)
+	uuidRegex     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	// markupStyles are the style tags and their component keys.
+	markupStyles = map[string]string{
+		"b": "bold", "bold": "bold",
+		"i": "italic", "italic": "italic", "em": "italic",
+		"u": "underlined", "underlined": "underlined",
+		"s": "strikethrough", "strikethrough": "strikethrough",
+		"obf": "obfuscated", "obfuscated": "obfuscated",
+	}
+	// clickActions are the click event actions and their short names.
+	clickActions = map[string]string{
+		"run": "run_command", "run_command": "run_command",
+		"suggest": "suggest_command", "suggest_command": "suggest_command",
+		"url": "open_url", "open_url": "open_url",
+		"copy": "copy_to_clipboard", "copy_to_clipboard": "copy_to_clipboard",
+		"page": "change_page", "change_page": "change_page",
+	}
+)
+
+// textFormat is the text component layout of a game version.
+type textFormat struct {
+	hexColors  bool   // #RRGGBB colors, since 1.16
+	hoverKey   string // "value" before 1.16, "contents" since
+	snakeEvent bool   // click_event and hover_event with typed keys, since 1.21.5
+}
+
+// newTextFormat returns the text component layout of the configured game version.
+func newTextFormat(mc *MinecraftConfig) textFormat {
+	f := textFormat{hexColors: mc.VersionAtLeast("1.16"), hoverKey: "value"}
+	if f.hexColors {
+		f.hoverKey = "contents"
+	}
+	f.snakeEvent = mc.VersionAtLeast("1.21.5")
+	return f
+}
+
+// clickEvent returns the click event component for an action and value.
+func (f textFormat) clickEvent(action, value string) (string, map[string]interface{}) {
+	if !f.snakeEvent {
+		return "clickEvent", map[string]interface{}{"action": action, "value": value}
+	}
+	event := map[string]interface{}{"action": action}
+	switch action {
+	case "run_command", "suggest_command":
+		event["command"] = value
+	case "open_url":
+		event["url"] = value
+	case "change_page":
+		page, _ := strconv.Atoi(value)
+		event["page"] = page
+	default:
+		event["value"] = value
+	}
+	return "click_event", event
+}
+
+// hoverEvent returns the hover event component showing a text.
+func (f textFormat) hoverEvent(text interface{}) (string, map[string]interface{}) {
+	if f.snakeEvent {
+		return "hover_event", map[string]interface{}{"action": "show_text", "value": text}
+	}
+	return "hoverEvent", map[string]interface{}{"action": "show_text", f.hoverKey: text}
+}
+
+// markupTag is an open tag and the style it applies.
+type markupTag struct {
+	name  string
+	style map[string]interface{}
+}
+
+// markupParser turns markup into text components.
+type markupParser struct {
+	format     textFormat
+	stack      []markupTag
+	components []interface{}
+	text       strings.Builder
+}
+
+// ParseMarkup parses text markup into a JSON text component array. Tags:
+//
+//	<red>, <#ff8800>          color (named colors or hex since 1.16)
+//	<b> <i> <u> <s> <obf>     bold, italic, underlined, strikethrough, obfuscated
+//	<click:run:/spawn>        click event: run, suggest, url, copy or page
+//	<hover:'<red>text'>       hover text, itself markup
+//	<tr:key:arg...>           translated text with markup arguments
+//	<key:key.jump>            key binding
+//	<selector:@p>             entity names
+//	<score:name:objective>    score of a player
+//	<br>                      line break
+//
+// A tag is closed by </name>, </> closes the last one and <reset> all. Arguments
+// containing ':' or '>' can be quoted with ' or ", and \< is a literal '<'.
+func ParseMarkup(markup string, format textFormat) ([]interface{}, error) {
+	p := &markupParser{format: format}
+	if err := p.parse(markup); err != nil {
+		return nil, err
+	}
+	p.flush()
+	// The first element is the parent of the others, keep it empty so they do not
+	// inherit its style.
+	return append([]interface{}{""}, p.components...), nil
+}
+
+// MarkupJSON returns the JSON text component of markup.
+func MarkupJSON(markup string, format textFormat) (string, error) {
+	components, err := ParseMarkup(markup, format)
+	if err != nil {
+		return "", err
+	}
+	return textJSON(components)
+}
+
+// textJSON encodes a text component. <, > and & are kept readable, the game does
+// not need them escaped.
+func textJSON(component interface{}) (string, error) {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(component); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// style returns the current style as component fields.
+func (p *markupParser) style() map[string]interface{} {
+	style := map[string]interface{}{}
+	for _, tag := range p.stack {
+		for k, v := range tag.style {
+			style[k] = v
+		}
+	}
+	return style
+}
+
+// add appends a component with the current style.
+func (p *markupParser) add(fields map[string]interface{}) {
+	component := p.style()
+	for k, v := range fields {
+		component[k] = v
+	}
+	p.components = append(p.components, component)
+}
+
+// flush appends the pending text as a component.
+func (p *markupParser) flush() {
+	if p.text.Len() > 0 {
+		p.add(map[string]interface{}{"text": p.text.String()})
+		p.text.Reset()
+	}
+}
+
+// tagEnd returns the index of the '>' ending the tag starting at s[0], skipping quoted parts.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0 && s[i] == '\\' && i+1 < len(s):
+			i++
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '\'' || s[i] == '"':
+			quote = s[i]
+		case s[i] == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// tagArgs splits the content of a tag at unquoted ':' and removes the quotes.
+func tagArgs(s string) []string {
+	var args []string
+	var sb strings.Builder
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == '\\' && i+1 < len(s):
+			i++
+			sb.WriteByte(s[i])
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			sb.WriteByte(c)
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':':
+			args = append(args, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(args, sb.String())
+}
+
+// parse processes the markup.
+func (p *markupParser) parse(s string) error {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && (s[i+1] == '<' || s[i+1] == '\\') {
+			p.text.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if c != '<' {
+			p.text.WriteByte(c)
+			continue
+		}
+		end := tagEnd(s[i:])
+		if end < 0 {
+			return fmt.Errorf("unclosed tag at %q, write \\< for a literal '<'", s[i:])
+		}
+		p.flush()
+		if err := p.tag(s[i+1 : i+end]); err != nil {
+			return err
+		}
+		i += end
+	}
+	return nil
+}
+
+// tag processes the content of a tag.
+func (p *markupParser) tag(content string) error {
+	if strings.HasPrefix(content, "/") {
+		return p.close(strings.ToLower(strings.TrimSpace(content[1:])))
+	}
+	args := tagArgs(content)
+	name := strings.ToLower(strings.TrimSpace(args[0]))
+	args = args[1:]
+	switch {
+	case name == "reset":
+		p.stack = nil
+		return nil
+	case name == "br" || name == "newline":
+		p.add(map[string]interface{}{"text": "\n"})
+		return nil
+	case hexColorRegex.MatchString(name):
+		if !p.format.hexColors {
+			return fmt.Errorf("hex color %s needs Minecraft 1.16 or newer", name)
+		}
+		p.push(name, map[string]interface{}{"color": name})
+		return nil
+	case markupStyles[name] != "":
+		p.push(name, map[string]interface{}{markupStyles[name]: true})
+		return nil
+	}
+	for _, color := range teamColors {
+		if name == color && color != "reset" {
+			p.push(name, map[string]interface{}{"color": name})
+			return nil
+		}
+	}
+
+	switch name {
+	case "click":
+		if len(args) < 2 || clickActions[strings.ToLower(args[0])] == "" {
+			return fmt.Errorf("invalid click tag <%s>, expected <click:run|suggest|url|copy|page:value>", content)
+		}
+		action := clickActions[strings.ToLower(args[0])]
+		value := strings.Join(args[1:], ":")
+		if action == "open_url" && !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			return fmt.Errorf("click url must start with http:// or https://: %s", value)
+		}
+		if action == "change_page" {
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("click page must be a number: %s", value)
+			}
+		}
+		key, event := p.format.clickEvent(action, value)
+		p.push(name, map[string]interface{}{key: event})
+	case "hover":
+		if len(args) == 0 {
+			return fmt.Errorf("hover tag needs a text, e.g. <hover:'Click to teleport'>")
+		}
+		text, err := ParseMarkup(strings.Join(args, ":"), p.format)
+		if err != nil {
+			return fmt.Errorf("hover text: %w", err)
+		}
+		key, event := p.format.hoverEvent(text)
+		p.push(name, map[string]interface{}{key: event})
+	case "tr", "lang", "translate":
+		if len(args) == 0 || args[0] == "" {
+			return fmt.Errorf("translate tag needs a key, e.g. <tr:item.minecraft.diamond>")
+		}
+		fields := map[string]interface{}{"translate": args[0]}
+		if len(args) > 1 {
+			with := make([]interface{}, len(args)-1)
+			for i, arg := range args[1:] {
+				component, err := ParseMarkup(arg, p.format)
+				if err != nil {
+					return fmt.Errorf("translate argument: %w", err)
+				}
+				with[i] = component
+			}
+			fields["with"] = with
+		}
+		p.add(fields)
+	case "key", "keybind":
+		if len(args) != 1 || args[0] == "" {
+			return fmt.Errorf("key tag needs a key binding, e.g. <key:key.jump>")
+		}
+		p.add(map[string]interface{}{"keybind": args[0]})
+	case "selector":
+		if len(args) == 0 || !selectorRegex.MatchString(strings.Join(args, ":")) {
+			return fmt.Errorf("selector tag needs a selector, e.g. <selector:@p>")
+		}
+		p.add(map[string]interface{}{"selector": strings.Join(args, ":")})
+	case "score":
+		if len(args) != 2 || args[0] == "" || args[1] == "" {
+			return fmt.Errorf("score tag needs a name and an objective, e.g. <score:Steve:kills>")
+		}
+		p.add(map[string]interface{}{"score": map[string]string{"name": args[0], "objective": args[1]}})
+	default:
+		return fmt.Errorf("unknown tag <%s>", content)
+	}
+	return nil
+}
+
+// push opens a tag.
+func (p *markupParser) push(name string, style map[string]interface{}) {
+	p.stack = append(p.stack, markupTag{name: name, style: style})
+}
+
+// close closes the last tag with the name, and the tags opened after it.
+func (p *markupParser) close(name string) error {
+	if name == "" {
+		if len(p.stack) == 0 {
+			return fmt.Errorf("</> without an open tag")
+		}
+		p.stack = p.stack[:len(p.stack)-1]
+		return nil
+	}
+	if style := markupStyles[name]; style != "" {
+		// <b> may be closed by </bold>
+		for i := len(p.stack) - 1; i >= 0; i-- {
+			if markupStyles[p.stack[i].name] == style {
+				p.stack = p.stack[:i]
+				return nil
+			}
+		}
+	}
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].name == name {
+			p.stack = p.stack[:i]
+			return nil
+		}
+	}
+	return fmt.Errorf("</%s> without an open <%s>", name, name)
+}
+
+// checkTarget validates a player name, UUID or target selector for a command.
+// Selector arguments must have balanced brackets and quotes, so the text component
+// following the target is not read as part of it.
+func checkTarget(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if playerNameRegex.MatchString(target) || uuidRegex.MatchString(target) {
+		return target, nil
+	}
+	if !selectorRegex.MatchString(target) || strings.ContainsAny(target, "\n\r") {
+		return "", fmt.Errorf("invalid target %q, expected a player name, UUID or selector like @a[tag=builder]", target)
+	}
+	depth := 0
+	var quote rune
+	for _, c := range target {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+			if depth < 0 {
+				return "", fmt.Errorf("unbalanced brackets in target %q", target)
+			}
+		}
+	}
+	if depth != 0 || quote != 0 {
+		return "", fmt.Errorf("unbalanced brackets or quotes in target %q", target)
+	}
+	return target, nil
+}
+
+// registerRichTextTools registers the tellraw and title tools.
+func (ms *MinecraftServer) registerRichTextTools() {
+	markupHelp := "Text markup: <red> or <#ff8800> colors, <b> <i> <u> <s> <obf> styles, <click:run:/spawn> <click:suggest:/msg > <click:url:https://...> <click:copy:text> click events, <hover:'text'> hover text, <tr:key:arg> translations, <key:key.jump>, <selector:@p>, <score:name:objective>, <br>; close with </name>, </> or <reset>, quote arguments with ' or \", \\< is a literal '<'"
+	ms.AddTool(mcp.NewTool(
+		"minecraft_tellraw",
+		mcp.WithDescription("Send a formatted chat message to players. "+markupHelp),
+		mcp.WithString("target", mcp.Description("Player name or selector (e.g., @a, @p[tag=builder])"), mcp.Required()),
+		mcp.WithString("text", mcp.Description("The message in text markup, e.g. '<gold><b>Welcome!</b></gold> <click:run:/spawn><hover:Teleport><u>Go to spawn</u>'"), mcp.Required()),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the command with the text component, without sending it (optional, default: false)")),
+	), ms.handleTellraw)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_title",
+		mcp.WithDescription("Show a title, subtitle and/or action bar text on the screen of players. "+markupHelp),
+		mcp.WithString("target", mcp.Description("Player name or selector (e.g., @a)"), mcp.Required()),
+		mcp.WithString("title", mcp.Description("Title in text markup (optional)")),
+		mcp.WithString("subtitle", mcp.Description("Subtitle in text markup, shown with the title (optional)")),
+		mcp.WithString("actionbar", mcp.Description("Action bar text above the hotbar in text markup (optional)")),
+		mcp.WithNumber("fadeIn", mcp.Description("Fade in ticks of the title (optional, default: 10)")),
+		mcp.WithNumber("stay", mcp.Description("Ticks the title stays (optional, default: 70)")),
+		mcp.WithNumber("fadeOut", mcp.Description("Fade out ticks of the title (optional, default: 20)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the commands, without sending them (optional, default: false)")),
+	), ms.handleTitle)
+}
+
+// sendCommands runs commands, or returns them for a dry run.
+func (ms *MinecraftServer) sendCommands(commands []string, dryRun bool) (*mcp.CallToolResult, error) {
+	for _, command := range commands {
+		if len(command) > maxCommandLength {
+			return mcp.NewToolResultError(fmt.Sprintf("command is %d characters long, the limit is %d", len(command), maxCommandLength)), nil
+		}
+	}
+	if dryRun {
+		return mcp.NewToolResultText(strings.Join(commands, "\n")), nil
+	}
+	var results []string
+	for _, command := range commands {
+		result, err := ms.WriteCommand(command)
+		if err != nil || result.IsError {
+			return result, err
+		}
+		results = append(results, toolResultText(result))
+	}
+	return mcp.NewToolResultText(strings.Join(results, "\n")), nil
+}
+
+// handleTellraw implements the minecraft_tellraw tool.
+func (ms *MinecraftServer) handleTellraw(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	target, err := getStringArg(args, "target", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if target, err = checkTarget(target); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	text, err := getStringArg(args, "text", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	component, err := MarkupJSON(text, newTextFormat(ms.config))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	return ms.sendCommands([]string{fmt.Sprintf("/tellraw %s %s", target, component)}, dryRun)
+}
+
+// handleTitle implements the minecraft_title tool.
+func (ms *MinecraftServer) handleTitle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	target, err := getStringArg(args, "target", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if target, err = checkTarget(target); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var commands []string
+	_, hasFadeIn := args["fadeIn"]
+	_, hasStay := args["stay"]
+	_, hasFadeOut := args["fadeOut"]
+	if hasFadeIn || hasStay || hasFadeOut {
+		times := make([]int, 3)
+		for i, key := range []string{"fadeIn", "stay", "fadeOut"} {
+			if times[i], err = getIntArg(args, key, []int{10, 70, 20}[i]); err != nil || times[i] < 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("%s must be a non-negative number of ticks", key)), nil
+			}
+		}
+		commands = append(commands, fmt.Sprintf("/title %s times %d %d %d", target, times[0], times[1], times[2]))
+	}
+	// The subtitle is shown with the next title, so it is sent first
+	format := newTextFormat(ms.config)
+	shown := 0
+	for _, kind := range []string{"subtitle", "title", "actionbar"} {
+		text, _ := getStringArg(args, kind, false)
+		if text == "" {
+			continue
+		}
+		component, err := MarkupJSON(text, format)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("%s: %v", kind, err)), nil
+		}
+		commands = append(commands, fmt.Sprintf("/title %s %s %s", target, kind, component))
+		if kind != "subtitle" {
+			shown++
+		}
+	}
+	if shown == 0 {
+		return mcp.NewToolResultError("title or actionbar is required"), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	return ms.sendCommands(commands, dryRun)
+}
@@ -0,0 +1,73 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"testing"
+)
+
+func TestMarkupJSON(t *testing.T) {
+	modern := newTextFormat(&MinecraftConfig{GameVersion: "1.20.2"})
+	tests := []struct {
+		markup, want string
+	}{
+		{"Hello", `["",{"text":"Hello"}]`},
+		{"<gold><b>Welcome</b> home</gold>!", `["",{"bold":true,"color":"gold","text":"Welcome"},{"color":"gold","text":" home"},{"text":"!"}]`},
+		{"<#FF8800>orange</>", `["",{"color":"#ff8800","text":"orange"}]`},
+		{"<click:run:/tp @s 0 64 0><hover:'<red>Teleport'>Go", `["",{"clickEvent":{"action":"run_command","value":"/tp @s 0 64 0"},"hoverEvent":{"action":"show_text","contents":["",{"color":"red","text":"Teleport"}]},"text":"Go"}]`},
+		{"<tr:chat.type.text:Steve:'hi there'>", `["",{"translate":"chat.type.text","with":[["",{"text":"Steve"}],["",{"text":"hi there"}]]}]`},
+		{"Press <key:key.jump><br><score:Steve:kills> \\<3", `["",{"text":"Press "},{"keybind":"key.jump"},{"text":"\n"},{"score":{"name":"Steve","objective":"kills"}},{"text":" <3"}]`},
+		{`<b>a<i>b</bold>c`, `["",{"bold":true,"text":"a"},{"bold":true,"italic":true,"text":"b"},{"text":"c"}]`},
+	}
+	for _, tt := range tests {
+		if got, err := MarkupJSON(tt.markup, modern); err != nil || got != tt.want {
+			t.Errorf("MarkupJSON(%q) = %s, %v\nwant %s", tt.markup, got, err, tt.want)
+		}
+	}
+
+	for _, markup := range []string{"<rainbow>x", "</b>", "<click:url:javascript:alert(1)>x", "<b", "<hover>x", "<score:Steve>"} {
+		if _, err := MarkupJSON(markup, modern); err == nil {
+			t.Errorf("MarkupJSON(%q) should fail", markup)
+		}
+	}
+
+	if _, err := MarkupJSON("<#ff0000>red", newTextFormat(&MinecraftConfig{GameVersion: "1.15.2"})); err == nil {
+		t.Errorf("hex colors should need 1.16")
+	}
+	got, err := MarkupJSON("<click:url:https://example.com><hover:Open>link", newTextFormat(&MinecraftConfig{GameVersion: "1.21.5"}))
+	want := `["",{"click_event":{"action":"open_url","url":"https://example.com"},"hover_event":{"action":"show_text","value":["",{"text":"Open"}]},"text":"link"}]`
+	if err != nil || got != want {
+		t.Errorf("1.21.5 events = %s, %v", got, err)
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	for _, target := range []string{"Steve", "@a", `@a[name="Some One",distance=..10]`, "@e[type=minecraft:zombie,nbt={Tags:[boss]}]", "@n[type=minecraft:villager]", "069a79f4-44e9-4726-a5be-fca90e38aaf5"} {
+		if _, err := checkTarget(target); err != nil {
+			t.Errorf("checkTarget(%q) = %v", target, err)
+		}
+	}
+	for _, target := range []string{"", "Steve Alex", "@a[tag=x", `@a[name="x]`, "@a] {\"text\":\"x\"}", "@x"} {
+		if _, err := checkTarget(target); err == nil {
+			t.Errorf("checkTarget(%q) should fail", target)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxRoadDistance is the largest distance between the ends of a road on each axis.
+	maxRoadDistance = 256
+	// roadMargin is how far the path search may stray outside the rectangle of the two ends.
+	roadMargin = 24
+	// maxRoadSearch limits the number of columns the path search expands.
+	maxRoadSearch = 200000
+	// roadHeadroom is the number of blocks cleared above the road.
+	roadHeadroom = 3
+	// roadPillarSpacing is the distance between the pillars under bridges.
+	roadPillarSpacing = 4
+)
+
+// ErrNoPath is returned when the path search cannot connect the two ends.
+var ErrNoPath = errors.New("no path found")
+
+// roadCost weighs the steps of the path search.
+type roadCost struct {
+	Slope float64 // per step, times the squared height difference
+	Water float64 // per step onto water or lava
+}
+
+// pathItem is an entry of the path search queue.
+type pathItem struct {
+	p [2]int
+	f float64
+}
+
+// pathQueue is a min-heap of path items ordered by estimated cost.
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].f < q[j].f }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// findPath connects two columns of a width x depth area with A*, stepping to the four
+// neighbors. column returns the level and wetness of a column; ok is false for columns
+// that cannot be used. An error from column ends the search.
+func findPath(column func(x, z int) (level int, wet, ok bool, err error), width, depth int, from, to [2]int, cost roadCost) ([][2]int, error) {
+	estimate := func(p [2]int) float64 {
+		return float64(absInt(p[0]-to[0]) + absInt(p[1]-to[1]))
+	}
+	g := map[[2]int]float64{from: 0}
+	prev := make(map[[2]int][2]int)
+	closed := make(map[[2]int]bool)
+	queue := &pathQueue{{from, estimate(from)}}
+	for queue.Len() > 0 {
+		p := heap.Pop(queue).(pathItem).p
+		if closed[p] {
+			continue
+		}
+		if p == to {
+			path := [][2]int{p}
+			for p != from {
+				p = prev[p]
+				path = append(path, p)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, nil
+		}
+		closed[p] = true
+		if len(closed) > maxRoadSearch {
+			return nil, fmt.Errorf("%w within %d columns", ErrNoPath, maxRoadSearch)
+		}
+		level, _, _, err := column(p[0], p[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			n := [2]int{p[0] + d[0], p[1] + d[1]}
+			if n[0] < 0 || n[0] >= width || n[1] < 0 || n[1] >= depth || closed[n] {
+				continue
+			}
+			nl, wet, ok, err := column(n[0], n[1])
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			dh := float64(nl - level)
+			step := 1 + cost.Slope*dh*dh
+			if wet {
+				step += cost.Water
+			}
+			if cg, seen := g[n]; !seen || g[p]+step < cg {
+				g[n] = g[p] + step
+				prev[n] = p
+				heap.Push(queue, pathItem{n, g[n] + estimate(n)})
+			}
+		}
+	}
+	return nil, ErrNoPath
+}
+
+// roadProfile limits the level changes between neighboring tiles to one block, cutting
+// into bumps and filling dips.
+func roadProfile(levels []int) []int {
+	out := append([]int(nil), levels...)
+	for i := 1; i < len(out); i++ {
+		out[i] = min(max(out[i], out[i-1]-1), out[i-1]+1)
+	}
+	for i := len(out) - 2; i >= 0; i-- {
+		out[i] = min(max(out[i], out[i+1]-1), out[i+1]+1)
+	}
+	return out
+}
+
+// roadTile is a column on the center line of a road.
+type roadTile struct {
+	X, Z   int    // relative to the search area
+	Level  int    // y of the road surface block
+	Wet    bool   // the road is a bridge here
+	Shape  string // "", "stairs" or "slab"
+	Facing string // direction a stair ascends to
+}
+
+// directionName returns the horizontal direction of a step.
+func directionName(dx, dz int) string {
+	switch {
+	case dx > 0:
+		return "east"
+	case dx < 0:
+		return "west"
+	case dz > 0:
+		return "south"
+	}
+	return "north"
+}
+
+// shapeRoad places stairs and slabs at the level changes. A change after a flat stretch
+// gets a slab on the lower tile (two half steps); a change in a steeper slope gets a stair
+// on the higher tile.
+func shapeRoad(tiles []roadTile) {
+	for i := 0; i+1 < len(tiles); i++ {
+		a, b := i, i+1
+		if tiles[a].Level == tiles[b].Level {
+			continue
+		}
+		if tiles[a].Level > tiles[b].Level {
+			a, b = b, a
+		}
+		before := a - (b - a) // the tile on the far side of the lower one
+		if before >= 0 && before < len(tiles) && tiles[before].Level == tiles[a].Level && tiles[a].Shape == "" {
+			tiles[a].Shape = "slab"
+			continue
+		}
+		if tiles[b].Shape == "" {
+			tiles[b].Shape = "stairs"
+			tiles[b].Facing = directionName(tiles[b].X-tiles[a].X, tiles[b].Z-tiles[a].Z)
+		}
+	}
+}
+
+// roadVariant derives the stairs or slab block of a material, e.g. minecraft:stone_bricks
+// gives minecraft:stone_brick_stairs.
+func roadVariant(material, kind string) string {
+	namespace, name, ok := strings.Cut(material, ":")
+	if !ok {
+		namespace, name = "minecraft", material
+	}
+	for _, suffix := range []string{"_planks", "_block"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	if strings.HasSuffix(name, "bricks") || strings.HasSuffix(name, "tiles") {
+		name = strings.TrimSuffix(name, "s")
+	}
+	return namespace + ":" + name + "_" + kind
+}
+
+// roadOptions configures the blocks of a road.
+type roadOptions struct {
+	Width        int
+	Material     string
+	Stairs, Slab string // empty to use full blocks
+	LightSpacing int
+	Light, Post  string
+}
+
+// roadPlan lays the road along the tiles.
+func roadPlan(area *terrainArea, tiles []roadTile, opts roadOptions) (ColumnPlan, error) {
+	lo := -(opts.Width - 1) / 2
+	hi := lo + opts.Width - 1
+	// Every road column belongs to the nearest center tile
+	cells := make(map[[2]int]int)
+	distance := func(c [2]int, i int) int {
+		dx, dz := c[0]-tiles[i].X, c[1]-tiles[i].Z
+		return dx*dx + dz*dz
+	}
+	for i, t := range tiles {
+		for dz := lo; dz <= hi; dz++ {
+			for dx := lo; dx <= hi; dx++ {
+				c := [2]int{t.X + dx, t.Z + dz}
+				if j, ok := cells[c]; ok && distance(c, j) <= distance(c, i) {
+					continue
+				}
+				cells[c] = i
+			}
+		}
+	}
+
+	plan := make(ColumnPlan)
+	for c, i := range cells {
+		t := tiles[i]
+		col, err := area.Column(c[0], c[1])
+		if err != nil {
+			return nil, err
+		}
+		if !col.Wet() || (c == [2]int{t.X, t.Z} && i%roadPillarSpacing == 0) {
+			plan.Add(c[0], c[1], col.Ground+1, t.Level-1, opts.Material)
+		}
+		top := t.Level
+		switch {
+		case t.Shape == "stairs" && opts.Stairs != "":
+			plan.Add(c[0], c[1], t.Level, t.Level, fmt.Sprintf("%s[facing=%s,half=bottom]", opts.Stairs, t.Facing))
+		case t.Shape == "slab" && opts.Slab != "":
+			plan.Add(c[0], c[1], t.Level, t.Level, opts.Material)
+			plan.Add(c[0], c[1], t.Level+1, t.Level+1, opts.Slab+"[type=bottom]")
+			top++
+		default:
+			plan.Add(c[0], c[1], t.Level, t.Level, opts.Material)
+		}
+		plan.Add(c[0], c[1], top+1, max(top+roadHeadroom, col.Top), airBlock)
+	}
+
+	if opts.LightSpacing <= 0 {
+		return plan, nil
+	}
+	for i, side := opts.LightSpacing, 1; i < len(tiles)-1; i += opts.LightSpacing {
+		t, p := tiles[i], tiles[i-1]
+		// Perpendicular to the direction of travel, alternating sides
+		px, pz := -(t.Z - p.Z), t.X-p.X
+		offset := hi + 1
+		if side < 0 {
+			offset = lo - 1
+		}
+		side = -side
+		c := [2]int{t.X + px*offset, t.Z + pz*offset}
+		if _, ok := cells[c]; ok {
+			continue
+		}
+		col, err := area.Column(c[0], c[1])
+		if err != nil {
+			return nil, err
+		}
+		// A post on a base at road level, cut into the ground or supported from below
+		plan.Add(c[0], c[1], min(col.Ground+1, t.Level), t.Level, opts.Material)
+		plan.Add(c[0], c[1], t.Level+1, t.Level+1, opts.Post)
+		plan.Add(c[0], c[1], t.Level+2, t.Level+2, opts.Light)
+		plan.Add(c[0], c[1], t.Level+3, col.Top, airBlock)
+	}
+	return plan, nil
+}
+
+// registerRoadTools adds the road planner.
+func (ms *MinecraftServer) registerRoadTools() {
+	ms.AddTool(mcp.NewTool("minecraft_build_road",
+		mcp.WithDescription("Plan a road between two points over the real terrain and build it. The route avoids water and steep slopes, the road is leveled with stairs and slabs where it climbs, bridges cross water and lights can be placed along the side. Reads the world save."),
+		mcp.WithString("x1", mcp.Description("X coordinate of the start (absolute)"), mcp.Required()),
+		mcp.WithString("z1", mcp.Description("Z coordinate of the start (absolute)"), mcp.Required()),
+		mcp.WithString("x2", mcp.Description("X coordinate of the end (absolute)"), mcp.Required()),
+		mcp.WithString("z2", mcp.Description("Z coordinate of the end (absolute)"), mcp.Required()),
+		mcp.WithNumber("width", mcp.Description("Road width in blocks, 1 to 9 (optional, default: 3)")),
+		mcp.WithString("material", mcp.Description("Road block (optional, default: minecraft:stone_bricks)")),
+		mcp.WithString("stairBlock", mcp.Description("Stairs used where the road climbs, or none (optional, default: derived from the material)")),
+		mcp.WithString("slabBlock", mcp.Description("Slab used for half steps, or none (optional, default: derived from the material)")),
+		mcp.WithNumber("lightSpacing", mcp.Description("Place a light every N blocks along the road, 0 for none (optional, default: 0)")),
+		mcp.WithString("lightBlock", mcp.Description("Light block on the posts (optional, default: minecraft:lantern)")),
+		mcp.WithString("postBlock", mcp.Description("Post block under the lights (optional, default: minecraft:oak_fence)")),
+		mcp.WithNumber("slopeCost", mcp.Description("How strongly the route avoids slopes (optional, default: 4)")),
+		mcp.WithNumber("waterCost", mcp.Description("Extra cost per block of bridge over water (optional, default: 8)")),
+		mcp.WithString("dimension", mcp.Description("Dimension of the world save to read (optional, default: overworld)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleBuildRoad)
+}
+
+// getRoadOptions parses the block and width arguments of the road tool.
+func getRoadOptions(args map[string]interface{}) (roadOptions, error) {
+	opts := roadOptions{Material: "minecraft:stone_bricks", Light: "minecraft:lantern", Post: "minecraft:oak_fence"}
+	var err error
+	if opts.Width, err = getIntArg(args, "width", 3); err != nil || opts.Width < 1 || opts.Width > 9 {
+		return opts, fmt.Errorf("width must be between 1 and 9")
+	}
+	if opts.LightSpacing, err = getIntArg(args, "lightSpacing", 0); err != nil || opts.LightSpacing < 0 {
+		return opts, fmt.Errorf("lightSpacing must be 0 or more")
+	}
+	blocks := []struct {
+		key string
+		dst *string
+	}{{"material", &opts.Material}, {"lightBlock", &opts.Light}, {"postBlock", &opts.Post}, {"stairBlock", &opts.Stairs}, {"slabBlock", &opts.Slab}}
+	for _, b := range blocks {
+		if v, _ := getStringArg(args, b.key, false); v != "" {
+			*b.dst = v
+		}
+	}
+	if opts.Stairs == "" {
+		opts.Stairs = roadVariant(opts.Material, "stairs")
+	}
+	if opts.Slab == "" {
+		opts.Slab = roadVariant(opts.Material, "slab")
+	}
+	for _, b := range blocks {
+		if *b.dst == "none" && (b.key == "stairBlock" || b.key == "slabBlock") {
+			*b.dst = ""
+			continue
+		}
+		if err := validateBlockID(*b.dst); err != nil {
+			return opts, fmt.Errorf("%s: %w", b.key, err)
+		}
+	}
+	return opts, nil
+}
+
+// handleBuildRoad implements the minecraft_build_road tool.
+func (ms *MinecraftServer) handleBuildRoad(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	coords, err := ms.getCoordArgs(args, "x1", "z1", "x2", "z2")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var v [4]int
+	for i, c := range coords {
+		p, err := ParseCoord(c)
+		if err != nil || p.Kind != CoordAbsolute {
+			return mcp.NewToolResultError(fmt.Sprintf("roads need absolute coordinates, got %s", c)), nil
+		}
+		v[i] = int(math.Floor(p.Value))
+	}
+	if absInt(v[0]-v[2]) > maxRoadDistance || absInt(v[1]-v[3]) > maxRoadDistance {
+		return mcp.NewToolResultError(fmt.Sprintf("the ends are more than %d blocks apart, split the road with waypoints", maxRoadDistance)), nil
+	}
+	opts, err := getRoadOptions(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	cost := roadCost{Slope: 4, Water: 8}
+	if cost.Slope, err = getFloatArg(args, "slopeCost", cost.Slope); err != nil || cost.Slope < 0 {
+		return mcp.NewToolResultError("slopeCost must be 0 or more"), nil
+	}
+	if cost.Water, err = getFloatArg(args, "waterCost", cost.Water); err != nil || cost.Water < 0 {
+		return mcp.NewToolResultError("waterCost must be 0 or more"), nil
+	}
+	dimension, _ := getStringArg(args, "dimension", false)
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+
+	area := &terrainArea{
+		X: min(v[0], v[2]) - roadMargin, Z: min(v[1], v[3]) - roadMargin,
+		Width: absInt(v[0]-v[2]) + 2*roadMargin + 1, Depth: absInt(v[1]-v[3]) + 2*roadMargin + 1,
+		terrain: &terrain{ms: ms, dimension: dimension},
+	}
+	area.origin = Position{{CoordAbsolute, float64(area.X)}, {CoordAbsolute, 0}, {CoordAbsolute, float64(area.Z)}}
+	if _, err := area.terrain.World(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// Roads run on the ground and as bridges just above water, ungenerated chunks are
+	// left out of the search
+	column := func(x, z int) (int, bool, bool, error) {
+		col, err := area.Column(x, z)
+		if errors.Is(err, ErrChunkNotGenerated) {
+			return 0, false, false, nil
+		}
+		if err != nil {
+			return 0, false, false, err
+		}
+		if col.Wet() {
+			return col.Fluid + 1, true, true, nil
+		}
+		return col.Ground, false, true, nil
+	}
+	from := [2]int{v[0] - area.X, v[1] - area.Z}
+	to := [2]int{v[2] - area.X, v[3] - area.Z}
+	path, err := findPath(column, area.Width, area.Depth, from, to, cost)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("cannot connect %d, %d to %d, %d: %v", v[0], v[1], v[2], v[3], err)), nil
+	}
+
+	tiles := make([]roadTile, len(path))
+	levels := make([]int, len(path))
+	for i, p := range path {
+		levels[i], tiles[i].Wet, _, _ = column(p[0], p[1])
+		tiles[i].X, tiles[i].Z = p[0], p[1]
+	}
+	for i, l := range roadProfile(levels) {
+		tiles[i].Level = l
+	}
+	shapeRoad(tiles)
+	plan, err := roadPlan(area, tiles, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	bridges := 0
+	for _, t := range tiles {
+		if t.Wet {
+			bridges++
+		}
+	}
+	name := fmt.Sprintf("road from %d, %d to %d, %d (%d blocks long, %d over water)", v[0], v[1], v[2], v[3], len(tiles), bridges)
+	return ms.runPlan(ctx, name, plan, area.origin, dryRun)
+}
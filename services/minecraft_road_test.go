@@ -0,0 +1,151 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestFindPath(t *testing.T) {
+	// A river at z 5 with a ford at x 8, a cliff at x 3 and a hole at x 5
+	column := func(x, z int) (int, bool, bool, error) {
+		switch {
+		case z == 5 && x != 8:
+			return 63, true, true, nil
+		case x == 3 && z < 4:
+			return 80, false, true, nil
+		case x == 5 && z < 4:
+			return 0, false, false, nil
+		}
+		return 62, false, true, nil
+	}
+	path, err := findPath(column, 10, 10, [2]int{0, 0}, [2]int{0, 9}, roadCost{Slope: 4, Water: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path[0] != [2]int{0, 0} || path[len(path)-1] != [2]int{0, 9} {
+		t.Fatalf("path does not connect the ends: %v", path)
+	}
+	crossed := false
+	for i, p := range path {
+		if p[1] == 5 {
+			crossed = p[0] == 8
+		}
+		if i > 0 && absInt(p[0]-path[i-1][0])+absInt(p[1]-path[i-1][1]) != 1 {
+			t.Errorf("step %d is not to a neighbor: %v", i, path)
+		}
+	}
+	if !crossed {
+		t.Errorf("expected the path to use the ford: %v", path)
+	}
+
+	// Without a ford the water is crossed at the shortest place
+	path, err = findPath(func(x, z int) (int, bool, bool, error) { return 62, z == 5, true, nil }, 10, 10, [2]int{0, 0}, [2]int{0, 9}, roadCost{Water: 8})
+	if err != nil || len(path) != 10 {
+		t.Errorf("straight path = %v, %v", path, err)
+	}
+
+	if _, err := findPath(func(x, z int) (int, bool, bool, error) { return 0, false, z != 5, nil }, 10, 10, [2]int{0, 0}, [2]int{0, 9}, roadCost{}); err == nil {
+		t.Errorf("expected no path across unusable columns")
+	}
+
+	broken := errors.New("corrupt region file")
+	column = func(x, z int) (int, bool, bool, error) {
+		if z == 3 {
+			return 0, false, false, broken
+		}
+		return 62, false, true, nil
+	}
+	if _, err := findPath(column, 10, 10, [2]int{0, 0}, [2]int{0, 9}, roadCost{}); !errors.Is(err, broken) {
+		t.Errorf("expected the column error, got %v", err)
+	}
+}
+
+func TestRoadProfileAndShape(t *testing.T) {
+	levels := roadProfile([]int{64, 64, 64, 65, 70, 66, 66, 66, 67, 68})
+	if want := []int{64, 64, 64, 65, 66, 66, 66, 66, 67, 68}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("roadProfile = %v, want %v", levels, want)
+	}
+	tiles := make([]roadTile, len(levels))
+	for i, l := range levels {
+		tiles[i] = roadTile{X: i, Level: l}
+	}
+	shapeRoad(tiles)
+	var shapes []string
+	for _, tile := range tiles {
+		shapes = append(shapes, tile.Shape)
+	}
+	// Rises after flat ground get a slab below them, the following ones stairs
+	want := []string{"", "", "slab", "", "stairs", "", "", "slab", "", "stairs"}
+	if !reflect.DeepEqual(shapes, want) {
+		t.Errorf("shapes = %q, want %q", shapes, want)
+	}
+	if tiles[4].Facing != "east" {
+		t.Errorf("stairs should ascend to the east, got %s", tiles[4].Facing)
+	}
+}
+
+func TestRoadVariant(t *testing.T) {
+	for material, want := range map[string]string{
+		"minecraft:stone_bricks":    "minecraft:stone_brick_stairs",
+		"minecraft:oak_planks":      "minecraft:oak_stairs",
+		"minecraft:cobblestone":     "minecraft:cobblestone_stairs",
+		"minecraft:quartz_block":    "minecraft:quartz_stairs",
+		"minecraft:deepslate_tiles": "minecraft:deepslate_tile_stairs",
+	} {
+		if got := roadVariant(material, "stairs"); got != want {
+			t.Errorf("roadVariant(%s) = %s, want %s", material, got, want)
+		}
+	}
+}
+
+func TestMinecraftServer_handleBuildRoad(t *testing.T) {
+	root := t.TempDir()
+	writeTestRegion(t, filepath.Join(root, "world"))
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root}}
+
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]interface{}{
+		"x1": "2", "z1": "8", "x2": "12", "z2": "8", "lightSpacing": float64(5), "dryRun": true,
+	}
+	result, err := ms.handleBuildRoad(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handleBuildRoad = %v, %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, c := range []string{
+		"11 blocks long, 0 over water",
+		"/fill 1 -61 7 13 -61 9 minecraft:stone_bricks",
+		"minecraft:oak_fence",
+		"minecraft:lantern",
+	} {
+		if !strings.Contains(text, c) {
+			t.Errorf("missing %q in:\n%s", c, text)
+		}
+	}
+}
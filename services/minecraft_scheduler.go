@@ -0,0 +1,506 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCronSearch bounds the search for the next run of a schedule that never matches,
+// such as February 30th.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+var (
+	jobNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// jobActions are the kinds of scheduled jobs.
+	jobActions = []string{"command", "announce", "backup", "restart"}
+	// cronMacros are the shorthand schedules.
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+	monthNames   = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// CronSchedule is a parsed five field cron expression (minute, hour, day of month,
+// month and day of week), each field stored as a bit set.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// When both day fields are restricted a day matches either of them, like in cron.
+	domStar, dowStar bool
+}
+
+// ParseCron parses a cron expression like "30 3 * * mon-fri" or a macro like "@daily".
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if m, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	c := &CronSchedule{domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 { // 7 is Sunday as well
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges (a-b) and steps (*/n,
+// a-b/n) into a bit set. names are accepted for the values starting at min.
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	value := func(s string) (int, error) {
+		if i := indexOf(names, strings.ToLower(s)); i >= 0 {
+			return i + min, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("invalid value %q, expected %d-%d", s, min, max)
+		}
+		return n, nil
+	}
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(a); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = value(b); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// matchesDay reports whether the day of t matches the day of month and day of week fields.
+func (c *CronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Matches reports whether the schedule runs in the minute of t.
+func (c *CronSchedule) Matches(t time.Time) bool {
+	return c.minute&(1<<t.Minute()) != 0 && c.hour&(1<<t.Hour()) != 0 &&
+		c.month&(1<<int(t.Month())) != 0 && c.matchesDay(t)
+}
+
+// Next returns the first minute after t the schedule runs in, or the zero time if it never runs.
+// It steps through the wall clock of t's location, so zones with offsets that are not
+// whole hours work like any other.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	from := t
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	end := t.Add(maxCronSearch)
+	for t.Before(end) {
+		var next time.Time
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		default:
+			if t.After(from) {
+				return t
+			}
+			next = t.Add(time.Minute)
+		}
+		// A wall clock time in the hour repeated when clocks go back may resolve to
+		// the earlier instant, keep moving forward instead
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// ScheduledJob is a recurring action, persisted in the Minecraft config.
+type ScheduledJob struct {
+	Name    string `json:"name"`
+	Cron    string `json:"cron"`              // cron expression or macro like @daily
+	Action  string `json:"action"`            // command, announce, backup or restart
+	Command string `json:"command,omitempty"` // commands of a command job, one per line
+	Message string `json:"message,omitempty"` // message of an announce job, or announced before a restart
+}
+
+// Validate checks the job and returns its parsed schedule.
+func (j ScheduledJob) Validate() (*CronSchedule, error) {
+	if !jobNameRegex.MatchString(j.Name) {
+		return nil, fmt.Errorf("invalid job name %q: only letters, digits, '_' and '-' are allowed", j.Name)
+	}
+	schedule, err := ParseCron(j.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", j.Name, err)
+	}
+	switch j.Action {
+	case "command":
+		if strings.TrimSpace(j.Command) == "" {
+			return nil, fmt.Errorf("job %s: a command job needs a command", j.Name)
+		}
+	case "announce":
+		if strings.TrimSpace(j.Message) == "" {
+			return nil, fmt.Errorf("job %s: an announce job needs a message", j.Name)
+		}
+	case "backup", "restart":
+	default:
+		return nil, fmt.Errorf("job %s: invalid action %q, expected one of %s", j.Name, j.Action, strings.Join(jobActions, ", "))
+	}
+	return schedule, nil
+}
+
+// jobRun is the outcome of the last run of a job.
+type jobRun struct {
+	Time    time.Time
+	Result  string
+	IsError bool
+}
+
+// runScheduler runs the due jobs at the start of every minute until ctx is done.
+func (ms *MinecraftServer) runScheduler(ctx context.Context) {
+	for {
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		for _, job := range ms.dueJobs(next) {
+			go ms.runJob(job)
+		}
+	}
+}
+
+// dueJobs returns the jobs scheduled in the minute of t.
+func (ms *MinecraftServer) dueJobs(t time.Time) []ScheduledJob {
+	ms.jobsMu.Lock()
+	defer ms.jobsMu.Unlock()
+	var due []ScheduledJob
+	for _, job := range ms.config.ScheduledJobs {
+		if schedule, err := ParseCron(job.Cron); err == nil && schedule.Matches(t) {
+			due = append(due, job)
+		}
+	}
+	return due
+}
+
+// runJob executes a job and logs and records its result. A job still running from
+// its previous run is skipped.
+func (ms *MinecraftServer) runJob(job ScheduledJob) {
+	ms.jobsMu.Lock()
+	if ms.jobsRunning[job.Name] {
+		ms.jobsMu.Unlock()
+		ms.logger.Warn().Str("job", job.Name).Msg("Scheduled job is still running, skipping this run")
+		return
+	}
+	ms.jobsRunning[job.Name] = true
+	ms.jobsMu.Unlock()
+
+	result, err := ms.executeJob(job)
+	run := jobRun{Time: time.Now(), Result: result}
+	if err != nil {
+		run.Result, run.IsError = err.Error(), true
+		ms.logger.Error().Err(err).Str("job", job.Name).Str("action", job.Action).Msg("Scheduled job failed")
+	} else {
+		ms.logger.Info().Str("job", job.Name).Str("action", job.Action).Str("result", result).Msg("Scheduled job finished")
+	}
+
+	ms.jobsMu.Lock()
+	delete(ms.jobsRunning, job.Name)
+	ms.jobRuns[job.Name] = run
+	ms.jobsMu.Unlock()
+}
+
+// executeJob performs the action of a job.
+func (ms *MinecraftServer) executeJob(job ScheduledJob) (string, error) {
+	switch job.Action {
+	case "backup":
+		info, _, err := ms.CreateBackup(job.Name)
+		if err != nil {
+			return "", err
+		}
+		return "created backup " + info.Name, nil
+	case "restart":
+		if !ms.isServerRunning() {
+			return "", fmt.Errorf("the server is not running")
+		}
+		if job.Message != "" {
+			_, _ = ms.WriteCommand("/say " + job.Message)
+		}
+		if err := ms.StopServer(serverStopTimeout); err != nil {
+			return "", err
+		}
+		if err := ms.StartServer(); err != nil {
+			return "", err
+		}
+		return "restarted the server", nil
+	}
+
+	commands := []string{"/say " + job.Message}
+	if job.Action == "command" {
+		lines, err := functionLines(job.Command)
+		if err != nil {
+			return "", err
+		}
+		commands = commands[:0]
+		for _, line := range lines {
+			commands = append(commands, "/"+line)
+		}
+	}
+	if !ms.isServerRunning() {
+		return "", fmt.Errorf("the server is not running")
+	}
+	var results []string
+	failed := 0
+	for _, c := range commands {
+		result, err := ms.WriteCommand(c)
+		if err != nil {
+			return "", err
+		}
+		if result.IsError {
+			failed++
+		}
+		results = append(results, toolResultText(result))
+	}
+	if failed > 0 {
+		return "", fmt.Errorf("%d of %d commands failed: %s", failed, len(commands), strings.Join(results, "; "))
+	}
+	return strings.Join(results, "; "), nil
+}
+
+// saveScheduledJobs writes the scheduled jobs to the Minecraft section of the config
+// file, keeping the rest of the file. ms.jobsMu must be held.
+func (ms *MinecraftServer) saveScheduledJobs() error {
+	if ms.configPath == "" {
+		return nil
+	}
+	config := make(map[string]interface{})
+	if data, err := os.ReadFile(ms.configPath); err == nil {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", ms.configPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	section, _ := config[MinecraftServerName].(map[string]interface{})
+	if section == nil {
+		section = make(map[string]interface{})
+		config[MinecraftServerName] = section
+	}
+	section["scheduledJobs"] = ms.config.ScheduledJobs
+	return writeJSONFile(ms.configPath, config)
+}
+
+// registerSchedulerTools registers the scheduled job tools.
+func (ms *MinecraftServer) registerSchedulerTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_schedule_add",
+		mcp.WithDescription("Add or replace a recurring job, e.g. a nightly backup, an hourly weather reset or restart warnings. Jobs are saved in the config and run by MoLing at the start of each matching minute (local time)."),
+		mcp.WithString("name", mcp.Description("Job name (letters, digits, '_' and '-'); an existing job with this name is replaced"), mcp.Required()),
+		mcp.WithString("cron", mcp.Description("Cron expression 'minute hour day-of-month month day-of-week', e.g. '0 4 * * *' for 04:00 every day, '*/15 * * * *' or '0 20 * * fri', or a macro like @hourly, @daily, @weekly"), mcp.Required()),
+		mcp.WithString("action", mcp.Description("What the job does: command (run commands), announce (broadcast a message), backup (back up the world) or restart (restart the server)"), mcp.Required(), mcp.Enum(jobActions...)),
+		mcp.WithString("command", mcp.Description("Commands of a command job, one per line (e.g. 'weather clear')")),
+		mcp.WithString("message", mcp.Description("Message of an announce job, or the message announced before a restart")),
+	), ms.handleScheduleAdd)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_schedule_list",
+		mcp.WithDescription("List the scheduled jobs with their next run and the result of their last run"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleScheduleList)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_schedule_remove",
+		mcp.WithDescription("Remove a scheduled job"),
+		mcp.WithString("name", mcp.Description("Job name"), mcp.Required()),
+	), ms.handleScheduleRemove)
+}
+
+// handleScheduleAdd implements the minecraft_schedule_add tool.
+func (ms *MinecraftServer) handleScheduleAdd(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	var job ScheduledJob
+	var err error
+	if job.Name, err = getStringArg(args, "name", true); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if job.Cron, err = getStringArg(args, "cron", true); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if job.Action, err = getStringArg(args, "action", true); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	job.Command, _ = getStringArg(args, "command", false)
+	job.Message, _ = getStringArg(args, "message", false)
+	schedule, err := job.Validate()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return mcp.NewToolResultError(fmt.Sprintf("the schedule %q never runs", job.Cron)), nil
+	}
+
+	ms.jobsMu.Lock()
+	defer ms.jobsMu.Unlock()
+	jobs := ms.config.ScheduledJobs
+	replaced := false
+	for i := range jobs {
+		if jobs[i].Name == job.Name {
+			jobs[i], replaced = job, true
+		}
+	}
+	if !replaced {
+		jobs = append(jobs, job)
+	}
+	ms.config.ScheduledJobs = jobs
+	if err := ms.saveScheduledJobs(); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("the job was added but could not be saved to the config: %v", err)), nil
+	}
+	ms.logger.Info().Str("job", job.Name).Str("cron", job.Cron).Str("action", job.Action).Msg("Scheduled job added")
+	verb := "Added"
+	if replaced {
+		verb = "Replaced"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s job %s, next run at %s", verb, job.Name, next.Format("2006-01-02 15:04 MST"))), nil
+}
+
+// handleScheduleList implements the minecraft_schedule_list tool.
+func (ms *MinecraftServer) handleScheduleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ms.jobsMu.Lock()
+	defer ms.jobsMu.Unlock()
+	if len(ms.config.ScheduledJobs) == 0 {
+		return mcp.NewToolResultText("No scheduled jobs"), nil
+	}
+	type jobStatus struct {
+		ScheduledJob
+		NextRun    string `json:"nextRun,omitempty"`
+		LastRun    string `json:"lastRun,omitempty"`
+		LastResult string `json:"lastResult,omitempty"`
+		Running    bool   `json:"running,omitempty"`
+	}
+	now := time.Now()
+	list := make([]jobStatus, 0, len(ms.config.ScheduledJobs))
+	for _, job := range ms.config.ScheduledJobs {
+		s := jobStatus{ScheduledJob: job, Running: ms.jobsRunning[job.Name]}
+		if schedule, err := ParseCron(job.Cron); err == nil {
+			if next := schedule.Next(now); !next.IsZero() {
+				s.NextRun = next.Format("2006-01-02 15:04 MST")
+			}
+		}
+		if run, ok := ms.jobRuns[job.Name]; ok {
+			s.LastRun = run.Time.Format("2006-01-02 15:04:05 MST")
+			s.LastResult = run.Result
+			if run.IsError {
+				s.LastResult = "failed: " + run.Result
+			}
+		}
+		list = append(list, s)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleScheduleRemove implements the minecraft_schedule_remove tool.
+func (ms *MinecraftServer) handleScheduleRemove(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := getStringArg(request.Params.Arguments, "name", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ms.jobsMu.Lock()
+	defer ms.jobsMu.Unlock()
+	jobs := ms.config.ScheduledJobs
+	for i, job := range jobs {
+		if job.Name == name {
+			ms.config.ScheduledJobs = append(jobs[:i:i], jobs[i+1:]...)
+			delete(ms.jobRuns, name)
+			if err := ms.saveScheduledJobs(); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("the job was removed but the config could not be saved: %v", err)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Removed job %s", name)), nil
+		}
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("job %s not found", name)), nil
+}
@@ -0,0 +1,146 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	from := time.Date(2025, 1, 31, 22, 30, 15, 0, time.UTC) // a Friday
+	for _, c := range []struct {
+		expr, want string
+	}{
+		{"* * * * *", "2025-01-31 22:31"},
+		{"*/15 * * * *", "2025-01-31 22:45"},
+		{"@hourly", "2025-01-31 23:00"},
+		{"0 4 * * *", "2025-02-01 04:00"},
+		{"30 3 * * mon-fri", "2025-02-03 03:30"},
+		{"0 0 29 feb *", "2028-02-29 00:00"},
+		{"0 12 1 * 7", "2025-02-01 12:00"}, // day 1 or Sunday
+		{"5,10 9-17/4 * jan,mar *", "2025-03-01 09:05"},
+	} {
+		s, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", c.expr, err)
+			continue
+		}
+		if next := s.Next(from).Format("2006-01-02 15:04"); next != c.want {
+			t.Errorf("Next(%q) = %s, want %s", c.expr, next, c.want)
+		}
+	}
+	if s, _ := ParseCron("0 0 30 feb *"); !s.Next(from).IsZero() {
+		t.Errorf("February 30th should never run")
+	}
+
+	// Offsets that are not whole hours, like India's +05:30
+	kolkata := time.FixedZone("Asia/Kolkata", 5*3600+1800)
+	for expr, want := range map[string]string{
+		"0 11 * * *":   "2025-01-31 11:00",
+		"@hourly":      "2025-01-31 11:00",
+		"0 10 * * *":   "2025-02-01 10:00",
+		"*/20 * * * *": "2025-01-31 10:20",
+	} {
+		s, _ := ParseCron(expr)
+		if next := s.Next(time.Date(2025, 1, 31, 10, 15, 0, 0, kolkata)).Format("2006-01-02 15:04"); next != want {
+			t.Errorf("Next(%q) in Asia/Kolkata = %s, want %s", expr, next, want)
+		}
+	}
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "@often"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestMinecraftServer_handleScheduleAdd(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"Minecraft": {"game_version": "1.21"}, "MoLingConfig": {"debug": true}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MinecraftServer{
+		config:      &MinecraftConfig{},
+		configPath:  configPath,
+		jobRuns:     make(map[string]jobRun),
+		jobsRunning: make(map[string]bool),
+	}
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if r := call(ms.handleScheduleAdd, map[string]interface{}{"name": "weather", "cron": "@hourly", "action": "command", "command": "/weather clear"}); r.IsError {
+		t.Fatalf("add failed: %v", r.Content)
+	}
+	if r := call(ms.handleScheduleAdd, map[string]interface{}{"name": "warn", "cron": "55 3 * * *", "action": "announce", "message": "Restart in 5 minutes"}); r.IsError {
+		t.Fatalf("add failed: %v", r.Content)
+	}
+	for _, args := range []map[string]interface{}{
+		{"name": "bad name", "cron": "@daily", "action": "backup"},
+		{"name": "x", "cron": "0 0 30 feb *", "action": "backup"},
+		{"name": "x", "cron": "@daily", "action": "announce"},
+		{"name": "x", "cron": "@daily", "action": "explode"},
+	} {
+		if r := call(ms.handleScheduleAdd, args); !r.IsError {
+			t.Errorf("%v should fail", args)
+		}
+	}
+
+	// The jobs are saved to the config, which can be loaded again
+	data, _ := os.ReadFile(configPath)
+	var config map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config["MoLingConfig"]["debug"] != true || config["Minecraft"]["game_version"] != "1.21" {
+		t.Errorf("other settings were not kept: %s", data)
+	}
+	loaded := NewMinecraftConfig()
+	if err := mergeJSONToStruct(loaded, config["Minecraft"]); err != nil || len(loaded.ScheduledJobs) != 2 || loaded.ScheduledJobs[1].Message != "Restart in 5 minutes" {
+		t.Errorf("loaded jobs = %v, %v", loaded.ScheduledJobs, err)
+	}
+
+	ms.runJob(ms.config.ScheduledJobs[1])
+	text := call(ms.handleScheduleList, nil).Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, `"lastResult": "failed: the server is not running"`) || !strings.Contains(text, `"nextRun"`) {
+		t.Errorf("unexpected list:\n%s", text)
+	}
+
+	if r := call(ms.handleScheduleRemove, map[string]interface{}{"name": "weather"}); r.IsError || len(ms.config.ScheduledJobs) != 1 {
+		t.Errorf("remove failed: %v", r.Content)
+	}
+	if r := call(ms.handleScheduleRemove, map[string]interface{}{"name": "weather"}); !r.IsError {
+		t.Errorf("removing a missing job should fail")
+	}
+}
@@ -0,0 +1,706 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var (
+	scoreboardNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+	fakePlayerRegex     = regexp.MustCompile(`^[#$]?[A-Za-z0-9_.+-]+$`)
+	criteriaRegex       = regexp.MustCompile(`^[A-Za-z0-9_.+-]+(:[A-Za-z0-9_.+-]+)?$`)
+	bossbarIDRegex      = regexp.MustCompile(`^([a-z0-9_.-]+:)?[a-z0-9_./-]+$`)
+
+	// Responses of successful changes
+	objectiveAddRegex    = regexp.MustCompile(`^Created new objective `)
+	objectiveRemoveRegex = regexp.MustCompile(`^Removed objective `)
+	setDisplayRegex      = regexp.MustCompile(`^(Set display slot|Cleared objective in display slot) `)
+	scoreSetRegex        = regexp.MustCompile(`^Set \[.+\] for .+ to -?\d+$`)
+	scoreAddRegex        = regexp.MustCompile(`^Added -?\d+ to \[`)
+	scoreRemoveRegex     = regexp.MustCompile(`^Removed -?\d+ from \[`)
+	scoreResetRegex      = regexp.MustCompile(`^Reset `)
+	teamAddRegex         = regexp.MustCompile(`^Created team `)
+	teamRemoveRegex      = regexp.MustCompile(`^Removed team `)
+	teamJoinRegex        = regexp.MustCompile(`^Added .+ to team `)
+	teamLeaveRegex       = regexp.MustCompile(`^Removed .+ from any team`)
+	teamEmptyRegex       = regexp.MustCompile(`^Removed \d+ members?(?:\(s\))? from team `)
+	teamModifyRegex      = regexp.MustCompile(`^(Updated the |Team (?:prefix|suffix) set|Friendly fire is now|Nametag visibility is now)`)
+	bossbarAddRegex      = regexp.MustCompile(`^Created custom bossbar `)
+	bossbarRemoveRegex   = regexp.MustCompile(`^Removed custom bossbar `)
+	bossbarSetRegex      = regexp.MustCompile(`^Custom bossbar .+ (has changed|has been renamed|now has|no longer has any players|is now (visible|hidden))`)
+
+	// Responses of queries
+	bracketListRegex    = regexp.MustCompile(`\[([^\]]+)\]`)
+	objectivesListRegex = regexp.MustCompile(`^There are \d+ objectives?(?:\(s\))?: (.*)$`)
+	trackedListRegex    = regexp.MustCompile(`^There are \d+ tracked entit(?:y|ies)(?:/entities)?: (.*)$`)
+	scoreGetRegex       = regexp.MustCompile(`^(.+) has (-?\d+) \[(.+)\]$`)
+	scoreListHeadRegex  = regexp.MustCompile(`^(.+) has \d+ scores?(?:\(s\))?:$`)
+	scoreListEntryRegex = regexp.MustCompile(`^\[(.+)\]: (-?\d+)$`)
+	teamsListRegex      = regexp.MustCompile(`^There are \d+ teams?(?:\(s\))?: (.*)$`)
+	teamMembersRegex    = regexp.MustCompile(`^Team \[(.+)\] has \d+ members?(?:\(s\))?: (.*)$`)
+	bossbarsListRegex   = regexp.MustCompile(`^There are \d+ custom bossbars?(?:\(s\))? active: (.*)$`)
+	bossbarValueRegex   = regexp.MustCompile(`^Custom bossbar .+ has a value of (-?\d+)$`)
+	bossbarMaxRegex     = regexp.MustCompile(`^Custom bossbar .+ has a maximum of (-?\d+)$`)
+	bossbarPlayersRegex = regexp.MustCompile(`^Custom bossbar .+ has \d+ players?(?:\(s\))? currently online: (.*)$`)
+	bossbarVisibleRegex = regexp.MustCompile(`^Custom bossbar .+ is currently (shown|hidden)$`)
+
+	displaySlots        = []string{"list", "sidebar", "below_name", "belowName"}
+	scoreOperations     = []string{"+=", "-=", "*=", "/=", "%=", "=", "<", ">", "><"}
+	teamColors          = []string{"black", "dark_blue", "dark_green", "dark_aqua", "dark_red", "dark_purple", "gold", "gray", "dark_gray", "blue", "green", "aqua", "red", "light_purple", "yellow", "white", "reset"}
+	nametagVisibilities = []string{"always", "never", "hideForOtherTeams", "hideForOwnTeam"}
+	bossbarColors       = []string{"blue", "green", "pink", "purple", "red", "white", "yellow"}
+	bossbarStyles       = []string{"progress", "notched_6", "notched_10", "notched_12", "notched_20"}
+)
+
+// textComponent returns a JSON text component for s. Text that already is JSON (an
+// object, array or string) is kept, plain text becomes a JSON string.
+func textComponent(s string) string {
+	s = strings.TrimSpace(s)
+	if json.Valid([]byte(s)) && (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[") || strings.HasPrefix(s, `"`)) {
+		// Compacted, a line break between the tokens would end the command
+		var buf bytes.Buffer
+		if json.Compact(&buf, []byte(s)) == nil {
+			return buf.String()
+		}
+	}
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// bracketNames returns the names in brackets of a list like "[kills], [deaths]".
+func bracketNames(s string) []string {
+	names := []string{}
+	for _, m := range bracketListRegex.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// matchLines returns the submatches of the first line matching re.
+func matchLines(lines []string, re *regexp.Regexp) []string {
+	for _, line := range lines {
+		if m := re.FindStringSubmatch(line); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// parseScoreList parses the response of "/scoreboard players list <target>".
+func parseScoreList(lines []string) map[string]int {
+	scores := map[string]int{}
+	inList := false
+	for _, line := range lines {
+		if scoreListHeadRegex.MatchString(line) {
+			inList = true
+			continue
+		}
+		if m := scoreListEntryRegex.FindStringSubmatch(line); inList && m != nil {
+			scores[m[1]], _ = strconv.Atoi(m[2])
+		}
+	}
+	return scores
+}
+
+// oneOf checks that value is one of the allowed values.
+func oneOf(key, value string, allowed []string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s %q, expected one of %s", key, value, strings.Join(allowed, ", "))
+}
+
+// getNameArg reads a required scoreboard objective or team name.
+func getNameArg(args map[string]interface{}, key string) (string, error) {
+	name, err := getStringArg(args, key, true)
+	if err != nil {
+		return "", err
+	}
+	if err := checkName(key, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// checkName checks an optional scoreboard objective or team name.
+func checkName(key, name string) error {
+	if name != "" && !scoreboardNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid %s %q, only letters, digits and _ . + - are allowed", key, name)
+	}
+	return nil
+}
+
+// checkScoreHolder checks the score holders of a scoreboard or team command: a
+// player name, UUID or selector, * for every tracked holder, or a fake player such
+// as #global.
+func checkScoreHolder(key, holder string) (string, error) {
+	holder = strings.TrimSpace(holder)
+	if holder == "*" || fakePlayerRegex.MatchString(holder) {
+		return holder, nil
+	}
+	if _, err := checkTarget(holder); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return holder, nil
+}
+
+// checkDisplaySlot checks a scoreboard display slot, including the team colored
+// sidebars like sidebar.team.red.
+func checkDisplaySlot(slot string) error {
+	if color, ok := strings.CutPrefix(slot, "sidebar.team."); ok {
+		// reset is a team color but has no sidebar
+		if color == "reset" || oneOf("team color", color, teamColors) != nil {
+			return fmt.Errorf("invalid slot %q, the team color must be one of %s", slot, strings.Join(teamColors[:len(teamColors)-1], ", "))
+		}
+		return nil
+	}
+	return oneOf("slot", slot, displaySlots)
+}
+
+// expectCommand runs a command and succeeds if a line of the response matches want.
+// Otherwise the response is the error, e.g. "Unknown scoreboard objective 'kills'".
+func (ms *MinecraftServer) expectCommand(command string, want *regexp.Regexp) (*mcp.CallToolResult, error) {
+	lines, err := ms.commandLines(command)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if m := matchLines(lines, want); m != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Command '%s' executed: %s", command, m[0])), nil
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("Command '%s' failed: %s", command, strings.Join(lines, "\n"))), nil
+}
+
+// queryCommand runs a command and returns its response lines, or an error result.
+func (ms *MinecraftServer) queryCommand(command string) ([]string, *mcp.CallToolResult) {
+	lines, err := ms.commandLines(command)
+	if err != nil {
+		return nil, mcp.NewToolResultError(err.Error())
+	}
+	for _, line := range lines {
+		if isMcFailureLog(line) || strings.HasPrefix(line, "Unknown ") || strings.HasPrefix(line, "No ") {
+			return nil, mcp.NewToolResultError(fmt.Sprintf("Command '%s' failed: %s", command, line))
+		}
+	}
+	return lines, nil
+}
+
+// registerScoreboardTools registers the scoreboard, team and bossbar tools.
+func (ms *MinecraftServer) registerScoreboardTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_objective",
+		mcp.WithDescription("Manage scoreboard objectives: add or remove one, list them, or show one in a display slot (sidebar, list, below_name)"),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("add", "remove", "list", "setdisplay")),
+		mcp.WithString("name", mcp.Description("Objective name (for add, remove and setdisplay; leave empty with setdisplay to clear the slot)")),
+		mcp.WithString("criteria", mcp.Description("Criteria for add (optional, default: dummy), e.g. playerKillCount, deathCount, minecraft.mined:minecraft.diamond_ore")),
+		mcp.WithString("displayName", mcp.Description("Display name for add, plain text or a JSON text component (optional)")),
+		mcp.WithString("slot", mcp.Description("Display slot for setdisplay, e.g. sidebar, list, below_name or sidebar.team.red")),
+	), ms.handleObjective)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_score",
+		mcp.WithDescription("Read or change scoreboard scores. get without targets returns the scores of all tracked players and entities for the objective, sorted from the highest"),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("get", "list", "set", "add", "remove", "reset", "operation")),
+		mcp.WithString("targets", mcp.Description("Player name, selector, * or fake player like #global (required except for get and list)")),
+		mcp.WithString("objective", mcp.Description("Objective name (required except for list and reset)")),
+		mcp.WithNumber("value", mcp.Description("Score for set, amount for add and remove")),
+		mcp.WithString("operation", mcp.Description("Operation: += -= *= /= %= = < > ><"), mcp.Enum(scoreOperations...)),
+		mcp.WithString("source", mcp.Description("Source player name, selector, * or fake player for operation")),
+		mcp.WithString("sourceObjective", mcp.Description("Source objective for operation (optional, default: objective)")),
+	), ms.handleScore)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_team",
+		mcp.WithDescription("Manage teams: add or remove one, join or leave, empty it, list teams or members, or modify its color, prefix, suffix, friendly fire and name tags"),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("add", "remove", "join", "leave", "empty", "list", "modify")),
+		mcp.WithString("team", mcp.Description("Team name (required except for leave and list)")),
+		mcp.WithString("members", mcp.Description("Player name or selector for join and leave")),
+		mcp.WithString("displayName", mcp.Description("Display name for add or modify, plain text or a JSON text component (optional)")),
+		mcp.WithString("color", mcp.Description("Team color for modify (optional)"), mcp.Enum(teamColors...)),
+		mcp.WithString("prefix", mcp.Description("Name prefix for modify, plain text or a JSON text component (optional)")),
+		mcp.WithString("suffix", mcp.Description("Name suffix for modify, plain text or a JSON text component (optional)")),
+		mcp.WithBoolean("friendlyFire", mcp.Description("Whether team members can hurt each other, for modify (optional)")),
+		mcp.WithString("nametagVisibility", mcp.Description("Name tag visibility for modify (optional)"), mcp.Enum(nametagVisibilities...)),
+	), ms.handleTeam)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_bossbar",
+		mcp.WithDescription("Manage custom boss bars: add or remove one, set its name, value, maximum, color, style, visibility and players, get its state or list them"),
+		mcp.WithString("action", mcp.Description("What to do"), mcp.Required(), mcp.Enum("add", "remove", "set", "get", "list")),
+		mcp.WithString("id", mcp.Description("Boss bar ID, e.g. event:timer (required except for list)")),
+		mcp.WithString("name", mcp.Description("Name for add or set, plain text or a JSON text component")),
+		mcp.WithNumber("value", mcp.Description("Value for set (optional)")),
+		mcp.WithNumber("max", mcp.Description("Maximum for set (optional)")),
+		mcp.WithString("color", mcp.Description("Color for set (optional)"), mcp.Enum(bossbarColors...)),
+		mcp.WithString("style", mcp.Description("Style for set (optional)"), mcp.Enum(bossbarStyles...)),
+		mcp.WithBoolean("visible", mcp.Description("Visibility for set (optional)")),
+		mcp.WithString("players", mcp.Description("Players who see it for set, a name or selector, e.g. @a (optional)")),
+	), ms.handleBossbar)
+}
+
+// handleObjective implements the minecraft_objective tool.
+func (ms *MinecraftServer) handleObjective(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	switch action {
+	case "list":
+		lines, errResult := ms.queryCommand("/scoreboard objectives list")
+		if errResult != nil {
+			return errResult, nil
+		}
+		names := []string{}
+		if m := matchLines(lines, objectivesListRegex); m != nil {
+			names = bracketNames(m[1])
+		}
+		return jsonResult(names)
+	case "setdisplay":
+		slot, err := getStringArg(args, "slot", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := checkDisplaySlot(slot); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		command := "/scoreboard objectives setdisplay " + slot
+		name, _ := getStringArg(args, "name", false)
+		if err := checkName("name", name); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if name != "" {
+			command += " " + name
+		}
+		return ms.expectCommand(command, setDisplayRegex)
+	}
+
+	name, err := getNameArg(args, "name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	switch action {
+	case "add":
+		criteria, _ := getStringArg(args, "criteria", false)
+		if criteria == "" {
+			criteria = "dummy"
+		}
+		if !criteriaRegex.MatchString(criteria) {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid criteria %q, e.g. dummy, deathCount or minecraft.mined:minecraft.diamond_ore", criteria)), nil
+		}
+		command := fmt.Sprintf("/scoreboard objectives add %s %s", name, criteria)
+		if displayName, _ := getStringArg(args, "displayName", false); displayName != "" {
+			command += " " + textComponent(displayName)
+		}
+		return ms.expectCommand(command, objectiveAddRegex)
+	case "remove":
+		return ms.expectCommand("/scoreboard objectives remove "+name, objectiveRemoveRegex)
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// scoreEntry is a score of a leaderboard.
+type scoreEntry struct {
+	Target string `json:"target"`
+	Score  int    `json:"score"`
+}
+
+// handleScore implements the minecraft_score tool.
+func (ms *MinecraftServer) handleScore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	targets, _ := getStringArg(args, "targets", false)
+	if targets = strings.TrimSpace(targets); targets != "" {
+		if targets, err = checkScoreHolder("targets", targets); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	switch action {
+	case "list":
+		if targets == "" {
+			lines, errResult := ms.queryCommand("/scoreboard players list")
+			if errResult != nil {
+				return errResult, nil
+			}
+			tracked := []string{}
+			if m := matchLines(lines, trackedListRegex); m != nil {
+				tracked = splitNames(m[1])
+			}
+			return jsonResult(tracked)
+		}
+		lines, errResult := ms.queryCommand("/scoreboard players list " + targets)
+		if errResult != nil {
+			return errResult, nil
+		}
+		return jsonResult(parseScoreList(lines))
+	case "reset":
+		if targets == "" {
+			return mcp.NewToolResultError("targets is required"), nil
+		}
+		command := "/scoreboard players reset " + targets
+		objective, _ := getStringArg(args, "objective", false)
+		if err := checkName("objective", objective); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if objective != "" {
+			command += " " + objective
+		}
+		return ms.expectCommand(command, scoreResetRegex)
+	}
+
+	objective, err := getNameArg(args, "objective")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if action == "get" {
+		return ms.scores(targets, objective)
+	}
+	if targets == "" {
+		return mcp.NewToolResultError("targets is required"), nil
+	}
+	switch action {
+	case "set", "add", "remove":
+		if _, ok := args["value"]; !ok {
+			return mcp.NewToolResultError("value is required"), nil
+		}
+		value, err := getIntArg(args, "value", 0)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		want := map[string]*regexp.Regexp{"set": scoreSetRegex, "add": scoreAddRegex, "remove": scoreRemoveRegex}[action]
+		return ms.expectCommand(fmt.Sprintf("/scoreboard players %s %s %s %d", action, targets, objective, value), want)
+	case "operation":
+		operation, err := getStringArg(args, "operation", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := oneOf("operation", operation, scoreOperations); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		source, err := getStringArg(args, "source", true)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if source, err = checkScoreHolder("source", source); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		sourceObjective, _ := getStringArg(args, "sourceObjective", false)
+		if err := checkName("sourceObjective", sourceObjective); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if sourceObjective == "" {
+			sourceObjective = objective
+		}
+		return ms.expectCommand(fmt.Sprintf("/scoreboard players operation %s %s %s %s %s", targets, objective, operation, source, sourceObjective), scoreSetRegex)
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// scores returns the score of targets for an objective, or the leaderboard of all
+// tracked players and entities that have a score when targets is empty.
+func (ms *MinecraftServer) scores(targets, objective string) (*mcp.CallToolResult, error) {
+	if targets != "" {
+		lines, errResult := ms.queryCommand(fmt.Sprintf("/scoreboard players get %s %s", targets, objective))
+		if errResult != nil {
+			return errResult, nil
+		}
+		m := matchLines(lines, scoreGetRegex)
+		if m == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("no score: %s", strings.Join(lines, "\n"))), nil
+		}
+		score, _ := strconv.Atoi(m[2])
+		return jsonResult(scoreEntry{Target: m[1], Score: score})
+	}
+
+	lines, errResult := ms.queryCommand("/scoreboard players list")
+	if errResult != nil {
+		return errResult, nil
+	}
+	board := []scoreEntry{}
+	if m := matchLines(lines, trackedListRegex); m != nil {
+		for _, target := range splitNames(m[1]) {
+			lines, err := ms.commandLines(fmt.Sprintf("/scoreboard players get %s %s", target, objective))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if m := matchLines(lines, scoreGetRegex); m != nil {
+				score, _ := strconv.Atoi(m[2])
+				board = append(board, scoreEntry{Target: target, Score: score})
+			}
+		}
+	}
+	sort.SliceStable(board, func(i, j int) bool { return board[i].Score > board[j].Score })
+	return jsonResult(board)
+}
+
+// handleTeam implements the minecraft_team tool.
+func (ms *MinecraftServer) handleTeam(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	members, _ := getStringArg(args, "members", false)
+	if members = strings.TrimSpace(members); members != "" {
+		if members, err = checkScoreHolder("members", members); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	switch action {
+	case "list":
+		team, _ := getStringArg(args, "team", false)
+		if err := checkName("team", team); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if team == "" {
+			lines, errResult := ms.queryCommand("/team list")
+			if errResult != nil {
+				return errResult, nil
+			}
+			teams := []string{}
+			if m := matchLines(lines, teamsListRegex); m != nil {
+				teams = bracketNames(m[1])
+			}
+			return jsonResult(teams)
+		}
+		lines, errResult := ms.queryCommand("/team list " + team)
+		if errResult != nil {
+			return errResult, nil
+		}
+		list := []string{}
+		if m := matchLines(lines, teamMembersRegex); m != nil {
+			list = splitNames(m[2])
+		}
+		return jsonResult(list)
+	case "leave":
+		if members == "" {
+			return mcp.NewToolResultError("members is required"), nil
+		}
+		return ms.expectCommand("/team leave "+members, teamLeaveRegex)
+	}
+
+	team, err := getNameArg(args, "team")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	displayName, _ := getStringArg(args, "displayName", false)
+	switch action {
+	case "add":
+		command := "/team add " + team
+		if displayName != "" {
+			command += " " + textComponent(displayName)
+		}
+		return ms.expectCommand(command, teamAddRegex)
+	case "remove":
+		return ms.expectCommand("/team remove "+team, teamRemoveRegex)
+	case "empty":
+		return ms.expectCommand("/team empty "+team, teamEmptyRegex)
+	case "join":
+		if members == "" {
+			return mcp.NewToolResultError("members is required"), nil
+		}
+		return ms.expectCommand(fmt.Sprintf("/team join %s %s", team, members), teamJoinRegex)
+	case "modify":
+		var options [][2]string
+		if displayName != "" {
+			options = append(options, [2]string{"displayName", textComponent(displayName)})
+		}
+		if color, _ := getStringArg(args, "color", false); color != "" {
+			if err := oneOf("color", color, teamColors); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			options = append(options, [2]string{"color", color})
+		}
+		for _, key := range []string{"prefix", "suffix"} {
+			if text, _ := getStringArg(args, key, false); text != "" {
+				options = append(options, [2]string{key, textComponent(text)})
+			}
+		}
+		if _, ok := args["friendlyFire"]; ok {
+			friendlyFire, err := getBoolArg(args, "friendlyFire", false)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			options = append(options, [2]string{"friendlyFire", strconv.FormatBool(friendlyFire)})
+		}
+		if visibility, _ := getStringArg(args, "nametagVisibility", false); visibility != "" {
+			if err := oneOf("nametagVisibility", visibility, nametagVisibilities); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			options = append(options, [2]string{"nametagVisibility", visibility})
+		}
+		if len(options) == 0 {
+			return mcp.NewToolResultError("nothing to modify, give displayName, color, prefix, suffix, friendlyFire or nametagVisibility"), nil
+		}
+		var results []string
+		for _, option := range options {
+			result, err := ms.expectCommand(fmt.Sprintf("/team modify %s %s %s", team, option[0], option[1]), teamModifyRegex)
+			if err != nil || result.IsError {
+				return result, err
+			}
+			results = append(results, toolResultText(result))
+		}
+		return mcp.NewToolResultText(strings.Join(results, "\n")), nil
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// BossbarState is the state of a custom boss bar.
+type BossbarState struct {
+	ID      string   `json:"id"`
+	Value   int      `json:"value"`
+	Max     int      `json:"max"`
+	Visible bool     `json:"visible"`
+	Players []string `json:"players"`
+}
+
+// handleBossbar implements the minecraft_bossbar tool.
+func (ms *MinecraftServer) handleBossbar(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	action, err := getStringArg(args, "action", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if action == "list" {
+		lines, errResult := ms.queryCommand("/bossbar list")
+		if errResult != nil {
+			return errResult, nil
+		}
+		bars := []string{}
+		if m := matchLines(lines, bossbarsListRegex); m != nil {
+			bars = bracketNames(m[1])
+		}
+		return jsonResult(bars)
+	}
+
+	id, err := getStringArg(args, "id", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !bossbarIDRegex.MatchString(id) {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid boss bar ID %q", id)), nil
+	}
+	name, _ := getStringArg(args, "name", false)
+	switch action {
+	case "add":
+		if name == "" {
+			return mcp.NewToolResultError("name is required"), nil
+		}
+		return ms.expectCommand(fmt.Sprintf("/bossbar add %s %s", id, textComponent(name)), bossbarAddRegex)
+	case "remove":
+		return ms.expectCommand("/bossbar remove "+id, bossbarRemoveRegex)
+	case "get":
+		return ms.bossbarState(id)
+	case "set":
+		var options [][2]string
+		if name != "" {
+			options = append(options, [2]string{"name", textComponent(name)})
+		}
+		// The maximum first, so a value above the old maximum is kept
+		for _, key := range []string{"max", "value"} {
+			if _, ok := args[key]; ok {
+				n, err := getIntArg(args, key, 0)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				options = append(options, [2]string{key, strconv.Itoa(n)})
+			}
+		}
+		for key, allowed := range map[string][]string{"color": bossbarColors, "style": bossbarStyles} {
+			if v, _ := getStringArg(args, key, false); v != "" {
+				if err := oneOf(key, v, allowed); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				options = append(options, [2]string{key, v})
+			}
+		}
+		if _, ok := args["visible"]; ok {
+			visible, err := getBoolArg(args, "visible", false)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			options = append(options, [2]string{"visible", strconv.FormatBool(visible)})
+		}
+		if players, _ := getStringArg(args, "players", false); strings.TrimSpace(players) != "" {
+			players, err := checkTarget(players)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			options = append(options, [2]string{"players", players})
+		}
+		if len(options) == 0 {
+			return mcp.NewToolResultError("nothing to set, give name, value, max, color, style, visible or players"), nil
+		}
+		var results []string
+		for _, option := range options {
+			result, err := ms.expectCommand(fmt.Sprintf("/bossbar set %s %s %s", id, option[0], option[1]), bossbarSetRegex)
+			if err != nil || result.IsError {
+				return result, err
+			}
+			results = append(results, toolResultText(result))
+		}
+		return mcp.NewToolResultText(strings.Join(results, "\n")), nil
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("invalid action %q", action)), nil
+}
+
+// bossbarState queries the value, maximum, visibility and players of a boss bar.
+func (ms *MinecraftServer) bossbarState(id string) (*mcp.CallToolResult, error) {
+	state := BossbarState{ID: id, Players: []string{}}
+	for _, key := range []string{"value", "max", "visible", "players"} {
+		lines, errResult := ms.queryCommand(fmt.Sprintf("/bossbar get %s %s", id, key))
+		if errResult != nil {
+			return errResult, nil
+		}
+		switch key {
+		case "value":
+			if m := matchLines(lines, bossbarValueRegex); m != nil {
+				state.Value, _ = strconv.Atoi(m[1])
+			}
+		case "max":
+			if m := matchLines(lines, bossbarMaxRegex); m != nil {
+				state.Max, _ = strconv.Atoi(m[1])
+			}
+		case "visible":
+			if m := matchLines(lines, bossbarVisibleRegex); m != nil {
+				state.Visible = m[1] == "shown"
+			}
+		case "players":
+			if m := matchLines(lines, bossbarPlayersRegex); m != nil {
+				state.Players = splitNames(m[1])
+			}
+		}
+	}
+	return jsonResult(state)
+}
@@ -0,0 +1,141 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestTextComponent(t *testing.T) {
+	tests := map[string]string{
+		"Red Team":                     `"Red Team"`,
+		`Say "hi"`:                     `"Say \"hi\""`,
+		`{"text":"Red","color":"red"}`: `{"text":"Red","color":"red"}`,
+		`"quoted"`:                     `"quoted"`,
+		"[Admin]":                      `"[Admin]"`,
+		"[\"a\",\n\"b\"]":              `["a","b"]`,
+	}
+	for in, want := range tests {
+		if got := textComponent(in); got != want {
+			t.Errorf("textComponent(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestScoreboardResponses(t *testing.T) {
+	if m := matchLines([]string{"There are 2 objective(s): [Kills], [deaths]"}, objectivesListRegex); m == nil || !reflect.DeepEqual(bracketNames(m[1]), []string{"Kills", "deaths"}) {
+		t.Errorf("objectives = %v", m)
+	}
+	if m := matchLines([]string{"There are 2 tracked entity/entities: Steve, Alex"}, trackedListRegex); m == nil || !reflect.DeepEqual(splitNames(m[1]), []string{"Steve", "Alex"}) {
+		t.Errorf("tracked = %v", m)
+	}
+	if m := matchLines([]string{"Steve has -3 [kills]"}, scoreGetRegex); m == nil || m[1] != "Steve" || m[2] != "-3" {
+		t.Errorf("score = %v", m)
+	}
+	scores := parseScoreList([]string{"Steve has 2 score(s):", "[kills]: 10", "[deaths]: 2"})
+	if !reflect.DeepEqual(scores, map[string]int{"kills": 10, "deaths": 2}) {
+		t.Errorf("scores = %v", scores)
+	}
+	if m := matchLines([]string{"Team [red] has 2 member(s): Steve, Alex"}, teamMembersRegex); m == nil || m[2] != "Steve, Alex" {
+		t.Errorf("members = %v", m)
+	}
+	if m := matchLines([]string{"Custom bossbar [Timer] has 1 player(s) currently online: Steve"}, bossbarPlayersRegex); m == nil || m[1] != "Steve" {
+		t.Errorf("bossbar players = %v", m)
+	}
+
+	for _, tt := range []struct {
+		re   *regexp.Regexp
+		line string
+	}{
+		{scoreSetRegex, "Set [kills] for Steve to 5"},
+		{scoreAddRegex, "Added 5 to [kills] for Steve (now 10)"},
+		{teamEmptyRegex, "Removed 2 member(s) from team [red]"},
+		{teamModifyRegex, "Friendly fire is now disabled for team [red]"},
+		{bossbarSetRegex, "Custom bossbar [Timer] has changed value to 30"},
+	} {
+		if !tt.re.MatchString(tt.line) {
+			t.Errorf("response %q not recognized", tt.line)
+		}
+	}
+	if scoreSetRegex.MatchString("Unknown scoreboard objective 'kills'") {
+		t.Errorf("error recognized as success")
+	}
+}
+
+func TestMinecraftServer_scoreboardInputs(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{}}
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) string {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, _ := handler(context.Background(), request)
+		if !result.IsError {
+			t.Errorf("%v should fail", args)
+		}
+		return toolResultText(result)
+	}
+	for _, args := range []map[string]interface{}{
+		{"action": "set", "targets": "Steve\nop Alex", "objective": "kills", "value": float64(1)},
+		{"action": "reset", "targets": "Steve", "objective": "kills\nop Alex"},
+		{"action": "operation", "targets": "Steve", "objective": "kills", "operation": "=", "source": "@a]\nop Alex"},
+		{"action": "list", "targets": "Steve Alex"},
+	} {
+		if text := call(ms.handleScore, args); !strings.Contains(text, "invalid") {
+			t.Errorf("score %v = %s", args, text)
+		}
+	}
+	for _, args := range []map[string]interface{}{
+		{"action": "add", "name": "kills", "criteria": "dummy\nop Alex"},
+		{"action": "setdisplay", "slot": "sidebar.team.red\nop Alex"},
+		{"action": "setdisplay", "slot": "sidebar.team.reset"},
+		{"action": "setdisplay", "slot": "sidebar", "name": "kills\nop Alex"},
+	} {
+		if text := call(ms.handleObjective, args); !strings.Contains(text, "invalid") {
+			t.Errorf("objective %v = %s", args, text)
+		}
+	}
+	for _, args := range []map[string]interface{}{
+		{"action": "join", "team": "red", "members": "Steve\nop Alex"},
+		{"action": "list", "team": "red\nop Alex"},
+		{"action": "modify", "team": "red", "nametagVisibility": "never\nop Alex"},
+	} {
+		if text := call(ms.handleTeam, args); !strings.Contains(text, "invalid") {
+			t.Errorf("team %v = %s", args, text)
+		}
+	}
+	if text := call(ms.handleBossbar, map[string]interface{}{"action": "set", "id": "event:timer", "players": "@a\nop Alex"}); !strings.Contains(text, "invalid") {
+		t.Errorf("bossbar players = %s", text)
+	}
+
+	for _, holder := range []string{"Steve", "*", "#global", "@a[scores={kills=1..}]"} {
+		if _, err := checkScoreHolder("targets", holder); err != nil {
+			t.Errorf("checkScoreHolder(%q): %v", holder, err)
+		}
+	}
+	if err := checkDisplaySlot("sidebar.team.dark_blue"); err != nil {
+		t.Errorf("checkDisplaySlot: %v", err)
+	}
+}
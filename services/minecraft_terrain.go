@@ -0,0 +1,791 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxTerrainSize is the largest side of an area the terrain tools work on.
+	maxTerrainSize = 128
+	// maxTerrainDelta is the largest height change of the terrain tools.
+	maxTerrainDelta = 64
+	// defaultClearHeight is how high above the target flatten clears when the world cannot be read.
+	defaultClearHeight = 32
+	// maxTunnelLength is the longest tunnel in blocks.
+	maxTunnelLength = 256
+)
+
+// columnSpan is a vertical run of one block in a column, Y1 <= Y2.
+type columnSpan struct {
+	Y1, Y2 int
+	Block  string
+}
+
+// ColumnPlan holds the vertical runs to place per column, keyed by x, z relative to an origin.
+type ColumnPlan map[[2]int][]columnSpan
+
+// Add appends a span to the column at x, z. Empty spans are ignored.
+func (c ColumnPlan) Add(x, z, y1, y2 int, block string) {
+	if y1 > y2 {
+		return
+	}
+	c[[2]int{x, z}] = append(c[[2]int{x, z}], columnSpan{y1, y2, block})
+}
+
+// Blocks returns the number of blocks in the plan.
+func (c ColumnPlan) Blocks() int {
+	n := 0
+	for _, spans := range c {
+		for _, s := range spans {
+			n += s.Y2 - s.Y1 + 1
+		}
+	}
+	return n
+}
+
+// Commands converts the plan into /fill commands. Columns with the same span are merged
+// into rectangles (first along X, then Z) of at most maxVolume blocks. Commands are
+// ordered bottom up, so new blocks are supported by what was placed before.
+func (c ColumnPlan) Commands(origin Position, maxVolume int) ([]string, error) {
+	if origin.IsLocal() {
+		return nil, fmt.Errorf("local coordinates (^) cannot be used as the origin of a build")
+	}
+	if maxVolume <= 0 {
+		maxVolume = 32768
+	}
+	bySpan := make(map[columnSpan]map[[2]int]bool)
+	for col, spans := range c {
+		for _, s := range spans {
+			if bySpan[s] == nil {
+				bySpan[s] = make(map[[2]int]bool)
+			}
+			bySpan[s][col] = true
+		}
+	}
+	spans := make([]columnSpan, 0, len(bySpan))
+	for s := range bySpan {
+		spans = append(spans, s)
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		a, b := spans[i], spans[j]
+		if a.Y1 != b.Y1 {
+			return a.Y1 < b.Y1
+		}
+		if a.Y2 != b.Y2 {
+			return a.Y2 < b.Y2
+		}
+		return a.Block < b.Block
+	})
+
+	var commands []string
+	for _, s := range spans {
+		height := s.Y2 - s.Y1 + 1
+		for _, r := range mergeRects(bySpan[s], max(1, maxVolume/height)) {
+			p1, err := origin.Offset(float64(r[0]), float64(s.Y1), float64(r[1]))
+			if err != nil {
+				return nil, err
+			}
+			p2, err := origin.Offset(float64(r[0]+r[2]-1), float64(s.Y2), float64(r[1]+r[3]-1))
+			if err != nil {
+				return nil, err
+			}
+			var command string
+			if p1 == p2 {
+				command, err = setblockCommand(p1, s.Block, "")
+			} else {
+				command, err = fillCommand(p1, p2, s.Block, "")
+			}
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
+}
+
+// mergeRects covers the cells greedily with rectangles (x, z, dx, dz) of at most maxArea cells.
+func mergeRects(cells map[[2]int]bool, maxArea int) [][4]int {
+	keys := make([][2]int, 0, len(cells))
+	for k := range cells {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+	done := make(map[[2]int]bool, len(cells))
+	free := func(x, z int) bool { return cells[[2]int{x, z}] && !done[[2]int{x, z}] }
+
+	var rects [][4]int
+	for _, k := range keys {
+		if done[k] {
+			continue
+		}
+		dx := 1
+		for dx < maxArea && free(k[0]+dx, k[1]) {
+			dx++
+		}
+		dz := 1
+	grow:
+		for dx*(dz+1) <= maxArea {
+			for x := k[0]; x < k[0]+dx; x++ {
+				if !free(x, k[1]+dz) {
+					break grow
+				}
+			}
+			dz++
+		}
+		for z := k[1]; z < k[1]+dz; z++ {
+			for x := k[0]; x < k[0]+dx; x++ {
+				done[[2]int{x, z}] = true
+			}
+		}
+		rects = append(rects, [4]int{k[0], k[1], dx, dz})
+	}
+	return rects
+}
+
+// terrainColumn is a column of the world save.
+type terrainColumn struct {
+	Ground  int    // highest terrain block, below vegetation and fluids
+	Top     int    // highest non-air block, including trees and fluids
+	Surface string // block at Ground
+	Fluid   int    // top of the water or lava above Ground, Ground when the column is dry
+}
+
+// Wet reports whether the ground is covered by water or lava.
+func (c terrainColumn) Wet() bool {
+	return c.Fluid > c.Ground
+}
+
+// isVegetationBlock reports whether the block is part of a tree or plant rather than terrain.
+func isVegetationBlock(state string) bool {
+	name := blockName(state)
+	for _, suffix := range []string{"_log", "_wood", "_leaves", "_stem", "_hyphae", "mushroom_block", "_wart_block"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	switch name {
+	case "minecraft:cactus", "minecraft:bamboo", "minecraft:sugar_cane", "minecraft:vine",
+		"minecraft:mangrove_roots", "minecraft:shroomlight", "minecraft:snow":
+		return true
+	}
+	return false
+}
+
+// Column reads the terrain column at x, z.
+func (t *terrain) Column(x, z int) (terrainColumn, error) {
+	w, err := t.World()
+	if err != nil {
+		return terrainColumn{}, err
+	}
+	top, err := w.SurfaceY(x, z, HeightmapWorldSurface)
+	if err != nil {
+		return terrainColumn{}, err
+	}
+	y, err := w.SurfaceY(x, z, HeightmapMotionBlockingNoLeaves)
+	if err != nil {
+		return terrainColumn{}, err
+	}
+	chunk, err := w.Chunk(x>>4, z>>4)
+	if err != nil {
+		return terrainColumn{}, err
+	}
+	fluid := math.MinInt
+	for ; y > chunk.MinY; y-- {
+		b := chunk.Block(x&15, y, z&15)
+		if isFluidBlock(b) {
+			fluid = max(fluid, y)
+			continue
+		}
+		if !isAirBlock(b) && !isVegetationBlock(b) {
+			break
+		}
+	}
+	return terrainColumn{Ground: y, Top: max(top, y), Surface: chunk.Block(x&15, y, z&15), Fluid: max(fluid, y)}, nil
+}
+
+// surfaceFor returns the surface and fill blocks used when reshaping a column. Empty
+// arguments keep the block the column has now, with dirt under grass-like surfaces.
+func surfaceFor(col terrainColumn, surface, fill string) (string, string) {
+	if surface == "" {
+		surface = blockName(col.Surface)
+	}
+	if fill == "" {
+		switch surface {
+		case "minecraft:grass_block", "minecraft:podzol", "minecraft:mycelium", "minecraft:moss_block", "minecraft:dirt_path":
+			fill = "minecraft:dirt"
+		default:
+			fill = surface
+		}
+	}
+	return surface, fill
+}
+
+// reshapeColumn adds the spans that move the ground of col to target: air above, the
+// surface block at target and fill below it down to the old ground.
+func reshapeColumn(plan ColumnPlan, x, z int, col terrainColumn, target int, surface, fill string) {
+	surface, fill = surfaceFor(col, surface, fill)
+	plan.Add(x, z, col.Ground+1, target-1, fill)
+	if target != col.Ground || surface != blockName(col.Surface) {
+		plan.Add(x, z, target, target, surface)
+	}
+	plan.Add(x, z, target+1, col.Top, airBlock)
+}
+
+// terrainArea is a rectangle of absolute columns.
+type terrainArea struct {
+	X, Z         int
+	Width, Depth int
+	origin       Position
+	terrain      *terrain
+	columns      map[[2]int]terrainColumn
+}
+
+// getTerrainArea parses the x1/z1/x2/z2 arguments, which must be absolute.
+func (ms *MinecraftServer) getTerrainArea(args map[string]interface{}, dimension string) (*terrainArea, error) {
+	coords, err := ms.getCoordArgs(args, "x1", "z1", "x2", "z2")
+	if err != nil {
+		return nil, err
+	}
+	var v [4]int
+	for i, c := range coords {
+		p, err := ParseCoord(c)
+		if err != nil || p.Kind != CoordAbsolute {
+			return nil, fmt.Errorf("terrain tools need absolute coordinates, got %s", c)
+		}
+		v[i] = int(math.Floor(p.Value))
+	}
+	a := &terrainArea{
+		X: min(v[0], v[2]), Z: min(v[1], v[3]),
+		Width: absInt(v[0]-v[2]) + 1, Depth: absInt(v[1]-v[3]) + 1,
+		terrain: &terrain{ms: ms, dimension: dimension},
+	}
+	if a.Width > maxTerrainSize || a.Depth > maxTerrainSize {
+		return nil, fmt.Errorf("the area is %dx%d, the limit is %d per side", a.Width, a.Depth, maxTerrainSize)
+	}
+	a.origin = Position{{CoordAbsolute, float64(a.X)}, {CoordAbsolute, 0}, {CoordAbsolute, float64(a.Z)}}
+	return a, nil
+}
+
+// Column returns the terrain column at the area-relative x, z (which may lie outside the area).
+func (a *terrainArea) Column(x, z int) (terrainColumn, error) {
+	if a.columns == nil {
+		a.columns = make(map[[2]int]terrainColumn)
+	}
+	if c, ok := a.columns[[2]int{x, z}]; ok {
+		return c, nil
+	}
+	c, err := a.terrain.Column(a.X+x, a.Z+z)
+	if err != nil {
+		return terrainColumn{}, fmt.Errorf("cannot read the terrain at %d, %d: %w", a.X+x, a.Z+z, err)
+	}
+	a.columns[[2]int{x, z}] = c
+	return c, nil
+}
+
+// registerTerrainTools adds the terrain sculpting tools.
+func (ms *MinecraftServer) registerTerrainTools() {
+	areaArgs := []mcp.ToolOption{
+		mcp.WithString("x1", mcp.Description("X coordinate of the first corner (absolute)"), mcp.Required()),
+		mcp.WithString("z1", mcp.Description("Z coordinate of the first corner (absolute)"), mcp.Required()),
+		mcp.WithString("x2", mcp.Description("X coordinate of the opposite corner (absolute)"), mcp.Required()),
+		mcp.WithString("z2", mcp.Description("Z coordinate of the opposite corner (absolute)"), mcp.Required()),
+	}
+	commonArgs := []mcp.ToolOption{
+		mcp.WithString("surfaceBlock", mcp.Description("Top block of reshaped columns (optional, default: keep the current surface block)")),
+		mcp.WithString("fillBlock", mcp.Description("Block used below the surface (optional, default: dirt under grass, otherwise the surface block)")),
+		mcp.WithString("dimension", mcp.Description("Dimension of the world save to read (optional, default: overworld)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	}
+	tool := func(name, description string, opts ...[]mcp.ToolOption) mcp.Tool {
+		all := []mcp.ToolOption{mcp.WithDescription(description)}
+		for _, o := range opts {
+			all = append(all, o...)
+		}
+		return mcp.NewTool(name, all...)
+	}
+
+	ms.AddTool(tool("minecraft_terrain_flatten",
+		"Flatten an area so its ground is at the target Y: terrain and trees above are cleared, holes below are filled. Reads the world save; if it cannot be read the whole area is cleared up to clearHeight blocks above the target.",
+		areaArgs, []mcp.ToolOption{
+			mcp.WithNumber("y", mcp.Description("Target ground Y"), mcp.Required()),
+			mcp.WithNumber("clearHeight", mcp.Description(fmt.Sprintf("Blocks to clear above the target when the world cannot be read (optional, default: %d)", defaultClearHeight))),
+		}, commonArgs,
+	), ms.handleTerrainFlatten)
+
+	ms.AddTool(tool("minecraft_terrain_raise",
+		"Raise (or lower, with a negative height) the ground around a point, fading out towards the radius. Reads the world save.",
+		[]mcp.ToolOption{
+			mcp.WithString("x", mcp.Description("X coordinate of the center (absolute)"), mcp.Required()),
+			mcp.WithString("z", mcp.Description("Z coordinate of the center (absolute)"), mcp.Required()),
+			mcp.WithNumber("radius", mcp.Description("Radius in blocks, 1 to 64"), mcp.Required()),
+			mcp.WithNumber("height", mcp.Description(fmt.Sprintf("Height change at the center, -%d to %d", maxTerrainDelta, maxTerrainDelta)), mcp.Required()),
+			mcp.WithString("falloff", mcp.Description("linear (cone), smooth (hill) or none (plateau) (optional, default: smooth)")),
+		}, commonArgs,
+	), ms.handleTerrainRaise)
+
+	ms.AddTool(tool("minecraft_terrain_smooth",
+		"Smooth the ground of an area with a heightmap blur filter, removing spikes and pits. Reads the world save.",
+		areaArgs, []mcp.ToolOption{
+			mcp.WithNumber("radius", mcp.Description("Filter radius in blocks, 1 to 8 (optional, default: 2)")),
+			mcp.WithNumber("iterations", mcp.Description("Filter passes, 1 to 5 (optional, default: 1)")),
+		}, commonArgs,
+	), ms.handleTerrainSmooth)
+
+	ms.AddTool(tool("minecraft_terrain_tunnel",
+		"Carve a straight tunnel between two points; its floor follows the line between them.",
+		[]mcp.ToolOption{
+			mcp.WithString("x1", mcp.Description("X coordinate of the start of the floor"), mcp.Required()),
+			mcp.WithString("y1", mcp.Description("Y coordinate of the start of the floor"), mcp.Required()),
+			mcp.WithString("z1", mcp.Description("Z coordinate of the start of the floor"), mcp.Required()),
+			mcp.WithString("x2", mcp.Description("X coordinate of the end of the floor"), mcp.Required()),
+			mcp.WithString("y2", mcp.Description("Y coordinate of the end of the floor"), mcp.Required()),
+			mcp.WithString("z2", mcp.Description("Z coordinate of the end of the floor"), mcp.Required()),
+			mcp.WithNumber("width", mcp.Description("Width in blocks, 1 to 16 (optional, default: 3)")),
+			mcp.WithNumber("height", mcp.Description("Height in blocks, 1 to 16 (optional, default: 3)")),
+			mcp.WithString("floorBlock", mcp.Description("Block placed under the tunnel as support (optional, default: keep)")),
+			mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+		},
+	), ms.handleTerrainTunnel)
+
+	ms.AddTool(tool("minecraft_terrain_lake",
+		"Carve a bowl shaped lake into the ground and fill it with water (or lava). The water level is the lowest ground at the rim, so it does not overflow. Reads the world save.",
+		[]mcp.ToolOption{
+			mcp.WithString("x", mcp.Description("X coordinate of the center (absolute)"), mcp.Required()),
+			mcp.WithString("z", mcp.Description("Z coordinate of the center (absolute)"), mcp.Required()),
+			mcp.WithNumber("radius", mcp.Description("Radius in blocks, 2 to 32"), mcp.Required()),
+			mcp.WithNumber("depth", mcp.Description("Depth at the center, 1 to 16 (optional, default: 4)")),
+			mcp.WithString("fluid", mcp.Description("minecraft:water or minecraft:lava (optional, default: water)")),
+		}, commonArgs,
+	), ms.handleTerrainLake)
+}
+
+// getSurfaceArgs returns the optional surfaceBlock and fillBlock arguments.
+func getSurfaceArgs(args map[string]interface{}) (string, string, error) {
+	surface, _ := getStringArg(args, "surfaceBlock", false)
+	fill, _ := getStringArg(args, "fillBlock", false)
+	for _, b := range []string{surface, fill} {
+		if b == "" {
+			continue
+		}
+		if err := validateBlockID(b); err != nil {
+			return "", "", err
+		}
+	}
+	return surface, fill, nil
+}
+
+// runPlan builds the column plan relative to origin.
+func (ms *MinecraftServer) runPlan(ctx context.Context, name string, plan ColumnPlan, origin Position, dryRun bool) (*mcp.CallToolResult, error) {
+	commands, err := plan.Commands(origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.runBuild(ctx, fmt.Sprintf("%s (%d blocks)", name, plan.Blocks()), commands, dryRun)
+}
+
+// handleTerrainFlatten implements the minecraft_terrain_flatten tool.
+func (ms *MinecraftServer) handleTerrainFlatten(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	dimension, _ := getStringArg(args, "dimension", false)
+	area, err := ms.getTerrainArea(args, dimension)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if _, ok := args["y"]; !ok {
+		return mcp.NewToolResultError("required parameter y is missing"), nil
+	}
+	y, err := getIntArg(args, "y", 0)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	clearHeight, err := getIntArg(args, "clearHeight", defaultClearHeight)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if clearHeight < 0 || clearHeight > 256 {
+		return mcp.NewToolResultError("clearHeight must be between 0 and 256"), nil
+	}
+	surface, fill, err := getSurfaceArgs(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+
+	plan := make(ColumnPlan)
+	if _, err := area.terrain.World(); err != nil {
+		// Blind mode: clear above the target and put the surface on top of whatever is below
+		ms.logger.Warn().Err(err).Msg("World save not readable, flattening without terrain data")
+		if surface == "" {
+			surface = "minecraft:grass_block"
+		}
+		for z := 0; z < area.Depth; z++ {
+			for x := 0; x < area.Width; x++ {
+				plan.Add(x, z, y, y, surface)
+				plan.Add(x, z, y+1, y+clearHeight, airBlock)
+			}
+		}
+		return ms.runPlan(ctx, fmt.Sprintf("flatten %dx%d at y %d without terrain data", area.Width, area.Depth, y), plan, area.origin, dryRun)
+	}
+	for z := 0; z < area.Depth; z++ {
+		for x := 0; x < area.Width; x++ {
+			col, err := area.Column(x, z)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if absInt(col.Ground-y) > maxTerrainDelta {
+				return mcp.NewToolResultError(fmt.Sprintf("the ground at %d, %d is at y %d, more than %d blocks from the target", area.X+x, area.Z+z, col.Ground, maxTerrainDelta)), nil
+			}
+			reshapeColumn(plan, x, z, col, y, surface, fill)
+		}
+	}
+	return ms.runPlan(ctx, fmt.Sprintf("flatten %dx%d at y %d", area.Width, area.Depth, y), plan, area.origin, dryRun)
+}
+
+// falloff returns the weight (1 at the center, 0 at the radius) of the named falloff curve.
+func falloff(kind string, d float64) (float64, error) {
+	if d >= 1 {
+		return 0, nil
+	}
+	switch kind {
+	case "", "smooth":
+		return 0.5 * (1 + math.Cos(math.Pi*d)), nil
+	case "linear":
+		return 1 - d, nil
+	case "none":
+		return 1, nil
+	}
+	return 0, fmt.Errorf("invalid falloff: %s (expected linear, smooth or none)", kind)
+}
+
+// getTerrainCenter returns the absolute x, z center arguments.
+func (ms *MinecraftServer) getTerrainCenter(args map[string]interface{}) (int, int, error) {
+	coords, err := ms.getCoordArgs(args, "x", "z")
+	if err != nil {
+		return 0, 0, err
+	}
+	var v [2]int
+	for i, c := range coords {
+		p, _ := ParseCoord(c)
+		if p.Kind != CoordAbsolute {
+			return 0, 0, fmt.Errorf("terrain tools need absolute coordinates, got %s", c)
+		}
+		v[i] = int(math.Floor(p.Value))
+	}
+	return v[0], v[1], nil
+}
+
+// handleTerrainRaise implements the minecraft_terrain_raise tool.
+func (ms *MinecraftServer) handleTerrainRaise(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	cx, cz, err := ms.getTerrainCenter(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	radius, err := getFloatArg(args, "radius", 0)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if radius < 1 || radius > 64 {
+		return mcp.NewToolResultError("radius must be between 1 and 64"), nil
+	}
+	height, err := getFloatArg(args, "height", 0)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if height == 0 || math.Abs(height) > maxTerrainDelta {
+		return mcp.NewToolResultError(fmt.Sprintf("height must be between -%d and %d and not 0", maxTerrainDelta, maxTerrainDelta)), nil
+	}
+	kind, _ := getStringArg(args, "falloff", false)
+	if _, err := falloff(kind, 0); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	surface, fill, err := getSurfaceArgs(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	dimension, _ := getStringArg(args, "dimension", false)
+
+	r := int(math.Ceil(radius))
+	area := &terrainArea{X: cx - r, Z: cz - r, Width: 2*r + 1, Depth: 2*r + 1, terrain: &terrain{ms: ms, dimension: dimension}}
+	area.origin = Position{{CoordAbsolute, float64(area.X)}, {CoordAbsolute, 0}, {CoordAbsolute, float64(area.Z)}}
+	plan := make(ColumnPlan)
+	for z := 0; z < area.Depth; z++ {
+		for x := 0; x < area.Width; x++ {
+			d := math.Hypot(float64(x-r), float64(z-r)) / radius
+			w, _ := falloff(kind, d)
+			delta := int(math.Round(height * w))
+			if delta == 0 {
+				continue
+			}
+			col, err := area.Column(x, z)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			reshapeColumn(plan, x, z, col, col.Ground+delta, surface, fill)
+		}
+	}
+	verb := "raise"
+	if height < 0 {
+		verb = "lower"
+	}
+	return ms.runPlan(ctx, fmt.Sprintf("%s terrain at %d, %d by %g", verb, cx, cz, math.Abs(height)), plan, area.origin, dryRun)
+}
+
+// smoothHeights blurs the heights (indexed [z][x], with pad extra columns on every side)
+// with a box filter of the given radius, returning the inner area.
+func smoothHeights(heights [][]float64, pad, radius, iterations int) [][]float64 {
+	for it := 0; it < iterations; it++ {
+		next := make([][]float64, len(heights))
+		for z := range heights {
+			next[z] = make([]float64, len(heights[z]))
+			for x := range heights[z] {
+				var sum, n float64
+				for dz := -radius; dz <= radius; dz++ {
+					for dx := -radius; dx <= radius; dx++ {
+						zz, xx := z+dz, x+dx
+						if zz < 0 || zz >= len(heights) || xx < 0 || xx >= len(heights[zz]) {
+							continue
+						}
+						sum += heights[zz][xx]
+						n++
+					}
+				}
+				next[z][x] = sum / n
+			}
+		}
+		heights = next
+	}
+	inner := heights[pad : len(heights)-pad]
+	for z := range inner {
+		inner[z] = inner[z][pad : len(inner[z])-pad]
+	}
+	return inner
+}
+
+// handleTerrainSmooth implements the minecraft_terrain_smooth tool.
+func (ms *MinecraftServer) handleTerrainSmooth(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	dimension, _ := getStringArg(args, "dimension", false)
+	area, err := ms.getTerrainArea(args, dimension)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	radius, err := getIntArg(args, "radius", 2)
+	if err != nil || radius < 1 || radius > 8 {
+		return mcp.NewToolResultError("radius must be between 1 and 8"), nil
+	}
+	iterations, err := getIntArg(args, "iterations", 1)
+	if err != nil || iterations < 1 || iterations > 5 {
+		return mcp.NewToolResultError("iterations must be between 1 and 5"), nil
+	}
+	surface, fill, err := getSurfaceArgs(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+
+	// Read a border around the area, so the edges blend into the surrounding terrain
+	pad := radius
+	heights := make([][]float64, area.Depth+2*pad)
+	for z := range heights {
+		heights[z] = make([]float64, area.Width+2*pad)
+		for x := range heights[z] {
+			col, err := area.Column(x-pad, z-pad)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			heights[z][x] = float64(col.Ground)
+		}
+	}
+	smoothed := smoothHeights(heights, pad, radius, iterations)
+	plan := make(ColumnPlan)
+	for z := 0; z < area.Depth; z++ {
+		for x := 0; x < area.Width; x++ {
+			col, _ := area.Column(x, z)
+			if target := int(math.Round(smoothed[z][x])); target != col.Ground {
+				reshapeColumn(plan, x, z, col, target, surface, fill)
+			}
+		}
+	}
+	return ms.runPlan(ctx, fmt.Sprintf("smooth %dx%d", area.Width, area.Depth), plan, area.origin, dryRun)
+}
+
+// tunnelGrid carves a tunnel whose floor follows the line from 0, 0, 0 to d.
+func tunnelGrid(d Vec3, width, height int, floor string) BlockGrid {
+	grid := make(BlockGrid)
+	length := math.Sqrt(d[0]*d[0] + d[1]*d[1] + d[2]*d[2])
+	steps := max(1, int(math.Ceil(length*2)))
+	lo := -(width - 1) / 2
+	for s := 0; s <= steps; s++ {
+		f := float64(s) / float64(steps)
+		c := Vec3{d[0]*f + 0.5, d[1]*f + 0.5, d[2]*f + 0.5}.BlockPos()
+		for dz := lo; dz < lo+width; dz++ {
+			for dx := lo; dx < lo+width; dx++ {
+				for dy := 0; dy < height; dy++ {
+					grid.Set(c[0]+dx, c[1]+dy, c[2]+dz, airBlock)
+				}
+			}
+		}
+	}
+	if floor != "" {
+		for p, b := range grid {
+			below := [3]int{p[0], p[1] - 1, p[2]}
+			if _, ok := grid[below]; !ok && b == airBlock {
+				grid[below] = floor
+			}
+		}
+	}
+	return grid
+}
+
+// handleTerrainTunnel implements the minecraft_terrain_tunnel tool.
+func (ms *MinecraftServer) handleTerrainTunnel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	p1, err := ms.getPositionArg(args, "x1", "y1", "z1")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	p2, err := ms.getPositionArg(args, "x2", "y2", "z2")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var d Vec3
+	for i := range d {
+		if p1[i].Kind != p2[i].Kind || p1[i].Kind == CoordLocal {
+			return mcp.NewToolResultError("both ends must use the same notation (absolute or ~) per axis"), nil
+		}
+		d[i] = math.Floor(p2[i].Value) - math.Floor(p1[i].Value)
+	}
+	if length := math.Sqrt(d[0]*d[0] + d[1]*d[1] + d[2]*d[2]); length > maxTunnelLength {
+		return mcp.NewToolResultError(fmt.Sprintf("the tunnel is %.0f blocks long, the limit is %d", length, maxTunnelLength)), nil
+	}
+	width, err := getIntArg(args, "width", 3)
+	if err != nil || width < 1 || width > 16 {
+		return mcp.NewToolResultError("width must be between 1 and 16"), nil
+	}
+	height, err := getIntArg(args, "height", 3)
+	if err != nil || height < 1 || height > 16 {
+		return mcp.NewToolResultError("height must be between 1 and 16"), nil
+	}
+	floor, _ := getStringArg(args, "floorBlock", false)
+	if floor != "" {
+		if err := validateBlockID(floor); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+
+	for i := range p1 {
+		p1[i].Value = math.Floor(p1[i].Value)
+	}
+	grid := tunnelGrid(d, width, height, floor)
+	commands, err := grid.Commands(p1, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return ms.runBuild(ctx, fmt.Sprintf("tunnel from %s to %s (%d blocks)", p1, p2, len(grid)), commands, dryRun)
+}
+
+// handleTerrainLake implements the minecraft_terrain_lake tool.
+func (ms *MinecraftServer) handleTerrainLake(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	cx, cz, err := ms.getTerrainCenter(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	radius, err := getFloatArg(args, "radius", 0)
+	if err != nil || radius < 2 || radius > 32 {
+		return mcp.NewToolResultError("radius must be between 2 and 32"), nil
+	}
+	depth, err := getFloatArg(args, "depth", 4)
+	if err != nil || depth < 1 || depth > 16 {
+		return mcp.NewToolResultError("depth must be between 1 and 16"), nil
+	}
+	fluid, _ := getStringArg(args, "fluid", false)
+	if fluid == "" {
+		fluid = "minecraft:water"
+	}
+	if fluid != "minecraft:water" && fluid != "minecraft:lava" {
+		return mcp.NewToolResultError("fluid must be minecraft:water or minecraft:lava"), nil
+	}
+	surface, fill, err := getSurfaceArgs(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, _ := getBoolArg(args, "dryRun", false)
+	dimension, _ := getStringArg(args, "dimension", false)
+
+	r := int(math.Ceil(radius)) + 1
+	area := &terrainArea{X: cx - r, Z: cz - r, Width: 2*r + 1, Depth: 2*r + 1, terrain: &terrain{ms: ms, dimension: dimension}}
+	area.origin = Position{{CoordAbsolute, float64(area.X)}, {CoordAbsolute, 0}, {CoordAbsolute, float64(area.Z)}}
+
+	// The water level is the lowest ground on the rim, so the lake cannot spill over
+	level := math.MaxInt
+	for z := 0; z < area.Depth; z++ {
+		for x := 0; x < area.Width; x++ {
+			if d := math.Hypot(float64(x-r), float64(z-r)); d < radius || d >= radius+1 {
+				continue
+			}
+			col, err := area.Column(x, z)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			level = min(level, col.Ground)
+		}
+	}
+
+	plan := make(ColumnPlan)
+	for z := 0; z < area.Depth; z++ {
+		for x := 0; x < area.Width; x++ {
+			d := math.Hypot(float64(x-r), float64(z-r)) / radius
+			if d >= 1 {
+				continue
+			}
+			col, err := area.Column(x, z)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			bottom := level - max(1, int(math.Round(depth*(1-d*d))))
+			bed, below := surfaceFor(col, surface, fill)
+			if surface == "" && (bed == "minecraft:grass_block" || isFluidBlock(col.Surface)) {
+				bed = "minecraft:dirt"
+			}
+			plan.Add(x, z, col.Ground+1, bottom-1, below)
+			plan.Add(x, z, bottom, bottom, bed)
+			plan.Add(x, z, bottom+1, level, fluid)
+			plan.Add(x, z, level+1, col.Top, airBlock)
+		}
+	}
+	return ms.runPlan(ctx, fmt.Sprintf("lake at %d, %d with level %d", cx, cz, level), plan, area.origin, dryRun)
+}
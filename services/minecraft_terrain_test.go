@@ -0,0 +1,107 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestColumnPlan_Commands(t *testing.T) {
+	plan := make(ColumnPlan)
+	for z := 0; z < 3; z++ {
+		for x := 0; x < 4; x++ {
+			plan.Add(x, z, 0, 1, "minecraft:dirt")
+			plan.Add(x, z, 2, 5, "minecraft:air")
+		}
+	}
+	plan.Add(9, 9, 3, 3, "minecraft:stone")
+	plan.Add(9, 9, 5, 4, "minecraft:stone") // empty
+	origin, _ := ParsePosition("10", "64", "~")
+
+	commands, err := plan.Commands(origin, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/fill 10 64 ~ 13 65 ~2 minecraft:dirt",
+		"/fill 10 66 ~ 13 69 ~2 minecraft:air",
+		"/setblock 19 67 ~9 minecraft:stone",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("Commands = %q, want %q", commands, want)
+	}
+	if n := plan.Blocks(); n != 4*3*6+1 {
+		t.Errorf("Blocks = %d", n)
+	}
+
+	// A volume limit of 16 allows 8 columns of dirt and 4 columns of air per fill
+	commands, _ = plan.Commands(origin, 16)
+	if len(commands) != 2+3+1 {
+		t.Errorf("limited Commands = %d: %q", len(commands), commands)
+	}
+}
+
+func TestSmoothHeights(t *testing.T) {
+	heights := [][]float64{
+		{0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0},
+		{0, 0, 9, 0, 0},
+		{0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0},
+	}
+	got := smoothHeights(heights, 1, 1, 1)
+	if len(got) != 3 || len(got[0]) != 3 || got[1][1] != 1 || got[0][0] != 1 {
+		t.Errorf("smoothHeights = %v", got)
+	}
+}
+
+func TestMinecraftServer_handleTerrainFlatten(t *testing.T) {
+	root := t.TempDir()
+	writeTestRegion(t, filepath.Join(root, "world"))
+	ms := &MinecraftServer{config: &MinecraftConfig{ServerRootPath: root}}
+
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]interface{}{
+		"x1": "0", "z1": "0", "x2": "3", "z2": "3", "y": float64(-60), "dryRun": true,
+	}
+	result, err := ms.handleTerrainFlatten(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handleTerrainFlatten = %v, %v", result, err)
+	}
+	// The ground is stone at -61 (-58 for the pillar) and the log section above ends at -33
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, c := range []string{"fill 0 -60 0 3 -60 3 minecraft:stone", "fill 0 -59 0 3 -33 3 minecraft:air"} {
+		if !strings.Contains(text, c) {
+			t.Errorf("missing %q in:\n%s", c, text)
+		}
+	}
+
+	request.Params.Arguments["x1"] = "~"
+	if result, _ := ms.handleTerrainFlatten(context.Background(), request); !result.IsError {
+		t.Errorf("relative coordinates should fail")
+	}
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxTextLength is the longest text (in characters) the text tool renders.
+	maxTextLength = 200
+	// maxTextScale is the largest number of blocks per font pixel.
+	maxTextScale = 16
+	// maxTextDepth is the largest depth of the letters in blocks.
+	maxTextDepth = 16
+	// maxTextSize is the largest width or height of rendered text in blocks.
+	maxTextSize = 1024
+	// maxTextVolume is the largest bounding volume of rendered text in blocks.
+	maxTextVolume = 1 << 20
+)
+
+// missingGlyphs returns the distinct characters of text that font cannot render.
+func missingGlyphs(font *BitmapFont, text string) []string {
+	var missing []string
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if r == '\n' || seen[r] {
+			continue
+		}
+		seen[r] = true
+		if _, ok := font.Glyph(r); !ok {
+			missing = append(missing, string(r))
+		}
+	}
+	return missing
+}
+
+// textFont selects the font for text. Without a font name the built-in font is used
+// if it covers the text, otherwise the Unifont file from the assets directory if there
+// is one, or the embedded Unifont subset.
+func (ms *MinecraftServer) textFont(name, text string) (*BitmapFont, error) {
+	if name != "" {
+		return ms.loadFont(name)
+	}
+	missing := missingGlyphs(builtinFont, text)
+	if len(missing) == 0 {
+		return builtinFont, nil
+	}
+	override := filepath.Join(ms.config.AssetsPath, filepath.FromSlash(DefaultCJKFont))
+	if _, err := ms.resolveAssetPath(DefaultCJKFont); err == nil {
+		font, err := ms.loadFont(DefaultCJKFont)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the font %s: %w", override, err)
+		}
+		return font, nil
+	}
+	font, err := embeddedCJKFont()
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingGlyphs(font, text); len(missing) > 0 {
+		return nil, fmt.Errorf("the built-in fonts cannot render %s; place the full GNU Unifont (unifont.hex) at %s or pass a .hex font file as font",
+			strings.Join(missing, " "), override)
+	}
+	return font, nil
+}
+
+// renderText lays out text (lines separated by "\n") into a bitmap, row 0 at the top.
+// letterSpacing and lineSpacing are in font pixels, align is left, center or right.
+func renderText(font *BitmapFont, text string, letterSpacing, lineSpacing int, align string) ([][]bool, error) {
+	if missing := missingGlyphs(font, text); len(missing) > 0 {
+		return nil, fmt.Errorf("font %s has no glyph for %s", font.Name, strings.Join(missing, " "))
+	}
+	if align != "left" && align != "center" && align != "right" {
+		return nil, fmt.Errorf("invalid align: %s (expected left, center or right)", align)
+	}
+
+	lines := strings.Split(text, "\n")
+	widths := make([]int, len(lines))
+	width := 0
+	for i, line := range lines {
+		for j, r := range []rune(line) {
+			if j > 0 {
+				widths[i] += letterSpacing
+			}
+			g, _ := font.Glyph(r)
+			widths[i] += g.Width
+		}
+		width = max(width, widths[i])
+	}
+	height := len(lines)*font.Height + (len(lines)-1)*lineSpacing
+	if width == 0 {
+		return nil, fmt.Errorf("text is empty")
+	}
+
+	bitmap := make([][]bool, height)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, width)
+	}
+	for i, line := range lines {
+		x := 0
+		switch align {
+		case "center":
+			x = (width - widths[i]) / 2
+		case "right":
+			x = width - widths[i]
+		}
+		top := i * (font.Height + lineSpacing)
+		for _, r := range line {
+			g, _ := font.Glyph(r)
+			for row := 0; row < font.Height && row < len(g.Rows); row++ {
+				for col := 0; col < g.Width; col++ {
+					if g.Pixel(col, row) {
+						bitmap[top+row][x+col] = true
+					}
+				}
+			}
+			x += g.Width + letterSpacing
+		}
+	}
+	return bitmap, nil
+}
+
+// textGrid converts the text bitmap into blocks, see surfacePos for the layout. Every
+// font pixel becomes scale x scale blocks, depth blocks deep. A background block forms
+// a board behind the letters (below them for horizontal text).
+func textGrid(bitmap [][]bool, orientation, facing string, scale, depth int, block, background string) (BlockGrid, error) {
+	height := len(bitmap) * scale
+	grid := make(BlockGrid)
+	for row, pixels := range bitmap {
+		for col, set := range pixels {
+			if !set && background == "" {
+				continue
+			}
+			for sy := 0; sy < scale; sy++ {
+				for sx := 0; sx < scale; sx++ {
+					u, v := col*scale+sx, row*scale+sy
+					if background != "" {
+						layer := depth
+						if orientation == "horizontal" {
+							layer = -1
+						}
+						p, err := surfacePos(orientation, facing, u, v, height, layer)
+						if err != nil {
+							return nil, err
+						}
+						grid[p] = background
+					}
+					if !set {
+						continue
+					}
+					for layer := 0; layer < depth; layer++ {
+						p, err := surfacePos(orientation, facing, u, v, height, layer)
+						if err != nil {
+							return nil, err
+						}
+						grid[p] = block
+					}
+				}
+			}
+		}
+	}
+	return grid, nil
+}
+
+// registerTextTools adds the block lettering tool.
+func (ms *MinecraftServer) registerTextTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_text",
+		mcp.WithDescription("Build large block lettering (signs, plaza names, shop fronts) from text. ASCII uses the built-in 5x8 font; other characters (e.g. Chinese and Japanese) use the built-in 16x16 GNU Unifont subset, or "+DefaultCJKFont+" from the assets directory if it exists."),
+		mcp.WithString("text", mcp.Description("Text to build, use \\n for multiple lines"), mcp.Required()),
+		mcp.WithString("x", mcp.Description("X coordinate of the origin (bottom-left corner for vertical, north-west corner for horizontal text)"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate of the origin"), mcp.Required()),
+		mcp.WithString("z", mcp.Description("Z coordinate of the origin"), mcp.Required()),
+		mcp.WithString("block", mcp.Description("Block of the letters (optional, default: minecraft:white_concrete)")),
+		mcp.WithString("background", mcp.Description("Block of a board behind the letters (optional, default: none)")),
+		mcp.WithString("orientation", mcp.Description("vertical (wall) or horizontal (floor) (optional, default: vertical)")),
+		mcp.WithString("facing", mcp.Description("Direction vertical text faces: north, south, east or west (optional, default: south)")),
+		mcp.WithNumber("scale", mcp.Description(fmt.Sprintf("Blocks per font pixel (optional, default: 1, max: %d)", maxTextScale))),
+		mcp.WithNumber("depth", mcp.Description(fmt.Sprintf("Depth of the letters in blocks (optional, default: 1, max: %d)", maxTextDepth))),
+		mcp.WithString("align", mcp.Description("Alignment of multiple lines: left, center or right (optional, default: left)")),
+		mcp.WithNumber("letterSpacing", mcp.Description("Font pixels between characters (optional, default: 1)")),
+		mcp.WithNumber("lineSpacing", mcp.Description("Font pixels between lines (optional, default: 2)")),
+		mcp.WithString("font", mcp.Description("'builtin', '"+EmbeddedCJKFontName+"' or a .hex font file in the assets directory (optional, chosen automatically)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleText)
+}
+
+// handleText implements the minecraft_text tool.
+func (ms *MinecraftServer) handleText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	text, err := getStringArg(args, "text", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\t", " ")
+	if utf8.RuneCountInString(text) > maxTextLength {
+		return mcp.NewToolResultError(fmt.Sprintf("text is longer than %d characters", maxTextLength)), nil
+	}
+	origin, err := ms.getPositionArg(args, "x", "y", "z")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	block, _ := getStringArg(args, "block", false)
+	if block == "" {
+		block = "minecraft:white_concrete"
+	}
+	background, _ := getStringArg(args, "background", false)
+	for _, b := range []string{block, background} {
+		if b == "" {
+			continue
+		}
+		if err := validateBlockID(b); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	orientation, _ := getStringArg(args, "orientation", false)
+	if orientation == "" {
+		orientation = "vertical"
+	}
+	facing, _ := getStringArg(args, "facing", false)
+	if facing == "" {
+		facing = "south"
+	}
+	align, _ := getStringArg(args, "align", false)
+	if align == "" {
+		align = "left"
+	}
+
+	var scale, depth, letterSpacing, lineSpacing int
+	for key, spec := range map[string]struct {
+		dst           *int
+		def, min, max int
+	}{
+		"scale":         {&scale, 1, 1, maxTextScale},
+		"depth":         {&depth, 1, 1, maxTextDepth},
+		"letterSpacing": {&letterSpacing, 1, 0, 16},
+		"lineSpacing":   {&lineSpacing, 2, 0, 16},
+	} {
+		v, err := getIntArg(args, key, spec.def)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if v < spec.min || v > spec.max {
+			return mcp.NewToolResultError(fmt.Sprintf("%s must be between %d and %d", key, spec.min, spec.max)), nil
+		}
+		*spec.dst = v
+	}
+	dryRun, err := getBoolArg(args, "dryRun", false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	fontName, _ := getStringArg(args, "font", false)
+	font, err := ms.textFont(fontName, text)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	bitmap, err := renderText(font, text, letterSpacing, lineSpacing, align)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	width, height := len(bitmap[0])*scale, len(bitmap)*scale
+	if width > maxTextSize || height > maxTextSize {
+		return mcp.NewToolResultError(fmt.Sprintf("text would be %dx%d blocks, the limit is %d per side", width, height, maxTextSize)), nil
+	}
+	if volume := width * height * depth; volume > maxTextVolume {
+		return mcp.NewToolResultError(fmt.Sprintf("text would fill a %dx%dx%d volume (%d blocks), the limit is %d, use a smaller scale or depth", width, height, depth, volume, maxTextVolume)), nil
+	}
+	grid, err := textGrid(bitmap, orientation, facing, scale, depth, block, background)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	commands, err := grid.Commands(origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	name := fmt.Sprintf("text %q %dx%d (%s font, %d blocks)", text, width, height, font.Name, len(grid))
+	return ms.runBuild(ctx, name, commands, dryRun)
+}
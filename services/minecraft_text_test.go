@@ -0,0 +1,109 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRenderText(t *testing.T) {
+	bitmap, err := renderText(builtinFont, "I\nII", 1, 2, "center")
+	if err != nil {
+		t.Fatalf("renderText failed: %v", err)
+	}
+	if len(bitmap) != 18 || len(bitmap[0]) != 11 {
+		t.Fatalf("bitmap is %dx%d, want 11x18", len(bitmap[0]), len(bitmap))
+	}
+	// The single "I" is centered: its stem is column 2 of the glyph, offset by 3
+	if !bitmap[3][5] || bitmap[3][2] {
+		t.Errorf("first line is not centered")
+	}
+	if _, err := renderText(builtinFont, "中", 1, 2, "left"); err == nil || !strings.Contains(err.Error(), "中") {
+		t.Errorf("missing glyph should be reported, got %v", err)
+	}
+
+	grid, err := textGrid([][]bool{{true, false}}, "vertical", "east", 2, 3, "minecraft:stone", "minecraft:oak_planks")
+	if err != nil {
+		t.Fatalf("textGrid failed: %v", err)
+	}
+	// 2x2 pixel, 3 deep (12 blocks) plus a 4x2 board behind it (8 blocks)
+	if len(grid) != 20 || grid[[3]int{-2, 1, -1}] != "minecraft:stone" || grid[[3]int{-3, 0, -3}] != "minecraft:oak_planks" {
+		t.Errorf("unexpected grid %v", grid)
+	}
+}
+
+func TestMinecraftServer_textFont(t *testing.T) {
+	dir := t.TempDir()
+	ms := &MinecraftServer{config: &MinecraftConfig{AssetsPath: dir}}
+	if f, err := ms.textFont("", "Hello"); err != nil || f != builtinFont {
+		t.Errorf("ASCII text should use the built-in font: %v", err)
+	}
+	embedded, err := ms.textFont("", "你好, Minecraft。")
+	if err != nil || embedded.Name != EmbeddedCJKFontName {
+		t.Fatalf("CJK text should use the embedded font: %v", err)
+	}
+	if g, ok := embedded.Glyph('中'); !ok || g.Width != 16 || g.Rows[4] != 0x3FF8 {
+		t.Errorf("unexpected embedded glyph %+v", g)
+	}
+	if _, err := ms.textFont("", "한글"); err == nil || !strings.Contains(err.Error(), "unifont.hex") {
+		t.Errorf("characters outside of the embedded font should be reported, got %v", err)
+	}
+
+	// The file in the assets directory replaces the embedded font: 16x16 glyph for U+4E2D (中) and 8x16 for "A"
+	hex := "0041:0000000018242442427E424242420000\n" +
+		"4E2D:010001000100FFFE810481048104810481048104FFFC81040100010001000100\n"
+	if err := os.MkdirAll(filepath.Join(dir, "fonts"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, DefaultCJKFont), []byte(hex), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ms.textFont("", "A中")
+	if err != nil {
+		t.Fatalf("textFont failed: %v", err)
+	}
+	g, ok := f.Glyph('中')
+	if !ok || g.Width != 16 || !g.Pixel(7, 0) || g.Pixel(0, 0) {
+		t.Errorf("unexpected glyph %+v", g)
+	}
+	if cached, _ := ms.loadFont(DefaultCJKFont); cached != f {
+		t.Errorf("font should be cached")
+	}
+}
+
+func TestMinecraftServer_handleTextVolume(t *testing.T) {
+	ms := &MinecraftServer{config: &MinecraftConfig{}}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"text": "ABCDEFGH", "x": "0", "y": "64", "z": "0",
+		"scale": float64(16), "depth": float64(16), "dryRun": true,
+	}
+	result, _ := ms.handleText(context.Background(), request)
+	if got := toolResultText(result); !result.IsError || !strings.Contains(got, "volume") {
+		t.Errorf("oversized text should be rejected before building the grid, got %s", got)
+	}
+}
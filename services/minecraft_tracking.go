@@ -0,0 +1,343 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// playersURI is the resource with the online players.
+	playersURI = "minecraft://players"
+	// playersMaxAge is how old the player states may be when the resource is read or
+	// a player position resolves coordinates.
+	playersMaxAge = 5 * time.Second
+)
+
+// entityDataRegex matches the response of "/data get entity <player> <path>".
+var entityDataRegex = regexp.MustCompile(`has the following entity data: (.*)$`)
+
+// PlayerState is what is known about an online player.
+type PlayerState struct {
+	Name      string     `json:"name"`
+	UUID      string     `json:"uuid,omitempty"`
+	Position  [3]float64 `json:"position"`
+	Block     string     `json:"block"` // block position, usable as coordinates
+	Dimension string     `json:"dimension"`
+	GameMode  string     `json:"gameMode"`
+	Health    float64    `json:"health"`
+	Updated   time.Time  `json:"updated"`
+}
+
+// entityData returns the value in the response of "/data get entity".
+func entityData(response string) (string, bool) {
+	m := entityDataRegex.FindStringSubmatch(strings.TrimSpace(response))
+	if m == nil {
+		return "", false
+	}
+	return strings.TrimSpace(m[1]), true
+}
+
+// parseNBTNumber parses an NBT number like "20.0f" or "64.5d".
+func parseNBTNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimRight(s, "bsLfdBSFD"), 64)
+}
+
+// blockPosition returns the coordinates of the block containing pos, like "12 64 -4".
+func blockPosition(pos []float64) string {
+	coords := make([]string, len(pos))
+	for i, v := range pos {
+		coords[i] = strconv.Itoa(int(math.Floor(v)))
+	}
+	return strings.Join(coords, " ")
+}
+
+// parseNBTDoubles parses an NBT list of doubles like "[1.5d, 64.0d, -2.5d]".
+func parseNBTDoubles(s string) ([]float64, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("not a list: %s", s)
+	}
+	var values []float64
+	for _, part := range strings.Split(s[1:len(s)-1], ",") {
+		v, err := parseNBTNumber(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// playerTracker caches the states of the online players.
+type playerTracker struct {
+	mu        sync.Mutex
+	players   map[string]PlayerState // by lower case name
+	refreshed time.Time
+	refreshMu sync.Mutex // serializes refreshes
+}
+
+// Snapshot returns the cached players sorted by name and the time of the last refresh.
+func (pt *playerTracker) Snapshot() ([]PlayerState, time.Time) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	players := make([]PlayerState, 0, len(pt.players))
+	for _, p := range pt.players {
+		players = append(players, p)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	return players, pt.refreshed
+}
+
+// Set replaces the cached players.
+func (pt *playerTracker) Set(players []PlayerState) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.players = make(map[string]PlayerState, len(players))
+	for _, p := range players {
+		pt.players[strings.ToLower(p.Name)] = p
+	}
+	pt.refreshed = time.Now()
+}
+
+// Invalidate marks the cached players as stale, e.g. when a player joined.
+func (pt *playerTracker) Invalidate() {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.refreshed = time.Time{}
+}
+
+// Remove drops a player that left the game.
+func (pt *playerTracker) Remove(name string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	delete(pt.players, strings.ToLower(name))
+}
+
+// entityValue runs "/data get entity <player> <path>" and returns the value.
+func (ms *MinecraftServer) entityValue(player, path string) (string, error) {
+	result, err := ms.WriteCommand(fmt.Sprintf("/data get entity %s %s", player, path))
+	if err != nil {
+		return "", err
+	}
+	text := toolResultText(result)
+	value, ok := entityData(text)
+	if !ok {
+		return "", fmt.Errorf("no %s of %s: %s", path, player, text)
+	}
+	return value, nil
+}
+
+// queryPlayer reads the position, dimension, game mode and health of an online player.
+func (ms *MinecraftServer) queryPlayer(p OnlinePlayer) (PlayerState, error) {
+	state := PlayerState{Name: p.Name, UUID: p.UUID, Updated: time.Now()}
+	pos, err := ms.entityValue(p.Name, "Pos")
+	if err != nil {
+		return state, err
+	}
+	coords, err := parseNBTDoubles(pos)
+	if err != nil || len(coords) != 3 {
+		return state, fmt.Errorf("invalid position of %s: %s", p.Name, pos)
+	}
+	copy(state.Position[:], coords)
+	state.Block = blockPosition(coords)
+
+	if state.Dimension, err = ms.entityValue(p.Name, "Dimension"); err != nil {
+		return state, err
+	}
+	state.Dimension = strings.Trim(state.Dimension, `"`)
+
+	mode, err := ms.entityValue(p.Name, "playerGameType")
+	if err != nil {
+		return state, err
+	}
+	if n, err := strconv.Atoi(mode); err == nil && n >= 0 && n < len(gameModes) {
+		state.GameMode = gameModes[n]
+	}
+
+	health, err := ms.entityValue(p.Name, "Health")
+	if err != nil {
+		return state, err
+	}
+	if state.Health, err = parseNBTNumber(health); err != nil {
+		return state, fmt.Errorf("invalid health of %s: %s", p.Name, health)
+	}
+	return state, nil
+}
+
+// RefreshPlayers queries the online players and their states and updates the cache.
+// Players that left while they were queried are skipped.
+func (ms *MinecraftServer) RefreshPlayers() ([]PlayerState, error) {
+	ms.tracker.refreshMu.Lock()
+	defer ms.tracker.refreshMu.Unlock()
+
+	lines, err := ms.commandLines("/list uuids")
+	if err != nil {
+		return nil, err
+	}
+	list, ok := parseListOutput(lines)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response to /list: %s", strings.Join(lines, "\n"))
+	}
+	var players []PlayerState
+	for _, p := range list.Players {
+		state, err := ms.queryPlayer(p)
+		if err != nil {
+			ms.logger.Debug().Err(err).Str("player", p.Name).Msg("Failed to query player")
+			continue
+		}
+		players = append(players, state)
+	}
+	ms.tracker.Set(players)
+	players, _ = ms.tracker.Snapshot()
+	return players, nil
+}
+
+// players returns the cached players, refreshing them when they are older than maxAge.
+func (ms *MinecraftServer) players(maxAge time.Duration) ([]PlayerState, error) {
+	players, refreshed := ms.tracker.Snapshot()
+	if time.Since(refreshed) <= maxAge {
+		return players, nil
+	}
+	return ms.RefreshPlayers()
+}
+
+// playerState returns the tracked state of an online player.
+func (ms *MinecraftServer) playerState(name string) (PlayerState, error) {
+	players, err := ms.players(playersMaxAge)
+	if err != nil {
+		return PlayerState{}, err
+	}
+	for _, p := range players {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return PlayerState{}, fmt.Errorf("%s is not online", name)
+}
+
+// playerExecutor returns the tracked position of an online player, and with rotation
+// also where the player looks, to resolve the coordinates of commands run at the player.
+func (ms *MinecraftServer) playerExecutor(name string, rotation bool) (*Executor, error) {
+	p, err := ms.playerState(name)
+	if err != nil {
+		return nil, err
+	}
+	exec := &Executor{Pos: Vec3(p.Position)}
+	if rotation {
+		value, err := ms.entityValue(p.Name, "Rotation")
+		if err != nil {
+			return nil, err
+		}
+		rot, err := parseNBTDoubles(value)
+		if err != nil || len(rot) != 2 {
+			return nil, fmt.Errorf("invalid rotation of %s: %s", p.Name, value)
+		}
+		exec.Rot = Rotation{Yaw: rot[0], Pitch: rot[1]}
+	}
+	return exec, nil
+}
+
+// runPlayerTracker follows the join and leave events until ctx is done: a join marks
+// the cached players stale, so the next caller queries them, and a leave forgets the
+// player. It sends no commands itself, so its responses never mix with the output
+// other callers wait for.
+func (ms *MinecraftServer) runPlayerTracker(ctx context.Context) {
+	events, cancel := ms.SubscribeEvents(EventPlayerJoined, EventPlayerLeft)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if event.Type == EventPlayerLeft {
+				ms.tracker.Remove(event.Player)
+			} else {
+				ms.tracker.Invalidate()
+			}
+		}
+	}
+}
+
+// registerTrackingTools registers the players resource and tool.
+func (ms *MinecraftServer) registerTrackingTools() {
+	ms.AddResource(mcp.NewResource(
+		playersURI,
+		"Minecraft online players",
+		mcp.WithResourceDescription("The online players with UUID, position, dimension, game mode and health"),
+		mcp.WithMIMEType("application/json"),
+	), ms.handlePlayersResource)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_players",
+		mcp.WithDescription("Show the online players with UUID, position, dimension, game mode and health as JSON"),
+		mcp.WithString("player", mcp.Description("Only this player (optional)")),
+		mcp.WithBoolean("refresh", mcp.Description("Query the server now instead of using the last known states (optional, default: false)")),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handlePlayers)
+}
+
+// handlePlayersResource returns the online players.
+func (ms *MinecraftServer) handlePlayersResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	players, err := ms.players(playersMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(players, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{URI: playersURI, MIMEType: "application/json", Text: string(data)}}, nil
+}
+
+// handlePlayers implements the minecraft_players tool.
+func (ms *MinecraftServer) handlePlayers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	refresh, err := getBoolArg(request.Params.Arguments, "refresh", false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	maxAge := time.Duration(ms.config.PlayerRefresh) * time.Second
+	if refresh || maxAge <= 0 {
+		maxAge = 0
+	}
+	players, err := ms.players(maxAge)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if name, _ := getStringArg(request.Params.Arguments, "player", false); name != "" {
+		for _, p := range players {
+			if strings.EqualFold(p.Name, name) {
+				return jsonResult(p)
+			}
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not online", name)), nil
+	}
+	return jsonResult(players)
+}
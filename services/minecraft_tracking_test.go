@@ -0,0 +1,67 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntityData(t *testing.T) {
+	value, ok := entityData(`Command '/data get entity Steve Dimension' executed: Steve has the following entity data: "minecraft:the_nether"`)
+	if !ok || value != `"minecraft:the_nether"` {
+		t.Errorf("entityData = %q, %v", value, ok)
+	}
+	if _, ok := entityData("No entity was found"); ok {
+		t.Errorf("entityData should fail without data")
+	}
+
+	coords, err := parseNBTDoubles("[12.5d, 64.0d, -3.25d]")
+	if err != nil || len(coords) != 3 || coords[2] != -3.25 {
+		t.Errorf("parseNBTDoubles = %v, %v", coords, err)
+	}
+	if pos := blockPosition(coords); pos != "12 64 -4" {
+		t.Errorf("blockPosition = %s", pos)
+	}
+	if _, err := parseNBTDoubles("20.0f"); err == nil {
+		t.Errorf("parseNBTDoubles should fail on a number")
+	}
+	if health, err := parseNBTNumber("17.5f"); err != nil || health != 17.5 {
+		t.Errorf("parseNBTNumber = %v, %v", health, err)
+	}
+}
+
+func TestPlayerTracker(t *testing.T) {
+	var pt playerTracker
+	if players, refreshed := pt.Snapshot(); len(players) != 0 || !refreshed.IsZero() {
+		t.Errorf("new tracker = %v, %s", players, refreshed)
+	}
+	pt.Set([]PlayerState{{Name: "Steve"}, {Name: "Alex"}})
+	pt.Remove("steve")
+	players, refreshed := pt.Snapshot()
+	if len(players) != 1 || players[0].Name != "Alex" || time.Since(refreshed) > time.Minute {
+		t.Errorf("Snapshot = %v, %s", players, refreshed)
+	}
+	pt.Invalidate()
+	if players, refreshed = pt.Snapshot(); len(players) != 1 || !refreshed.IsZero() {
+		t.Errorf("invalidated tracker = %v, %s", players, refreshed)
+	}
+}
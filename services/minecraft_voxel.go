@@ -0,0 +1,299 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultModelSize is the longest side of a voxelized model when neither size nor scale is given.
+	defaultModelSize = 32
+	// maxModelSize is the largest side of a voxelized model in blocks.
+	maxModelSize = 256
+	// voxelSampleSpacing is the distance (in blocks) between surface samples of a triangle.
+	voxelSampleSpacing = 0.3
+)
+
+// voxelOptions controls how a mesh is converted into blocks.
+type voxelOptions struct {
+	Size     int     // longest side in blocks, used when Scale is 0
+	Scale    float64 // blocks per model unit
+	UpAxis   string  // "y" or "z"
+	Rotate   int     // clockwise rotation around the vertical axis (0, 90, 180 or 270)
+	Hollow   bool    // only the surface shell
+	Palette  *Palette
+	Block    string // block of uncolored surfaces
+	Interior string // block of the solid interior
+}
+
+// voxelizeMesh converts the mesh into blocks. The minimum corner of the model is placed at 0, 0, 0.
+func voxelizeMesh(mesh *Mesh, opts voxelOptions) (BlockGrid, [3]int, error) {
+	// Convert to Minecraft axes (Y up, right-handed) and apply the rotation
+	transform := func(p Vec3) Vec3 {
+		if opts.UpAxis == "z" {
+			p = Vec3{p[0], p[2], -p[1]}
+		}
+		switch opts.Rotate {
+		case 90:
+			p[0], p[2] = -p[2], p[0]
+		case 180:
+			p[0], p[2] = -p[0], -p[2]
+		case 270:
+			p[0], p[2] = p[2], -p[0]
+		}
+		return p
+	}
+	tris := make([]meshTriangle, len(mesh.Triangles))
+	lo := Vec3{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := Vec3{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for i, t := range mesh.Triangles {
+		for v := range t.V {
+			t.V[v].Pos = transform(t.V[v].Pos)
+			for a := 0; a < 3; a++ {
+				lo[a] = math.Min(lo[a], t.V[v].Pos[a])
+				hi[a] = math.Max(hi[a], t.V[v].Pos[a])
+			}
+		}
+		tris[i] = t
+	}
+
+	longest := math.Max(hi[0]-lo[0], math.Max(hi[1]-lo[1], hi[2]-lo[2]))
+	scale := opts.Scale
+	if scale <= 0 {
+		if longest == 0 {
+			return nil, [3]int{}, fmt.Errorf("model has no extent")
+		}
+		scale = float64(max(opts.Size, 1)) / longest
+	}
+	var dims [3]int
+	for a := range dims {
+		dims[a] = max(1, int(math.Ceil((hi[a]-lo[a])*scale-1e-9)))
+	}
+	if max(dims[0], dims[1], dims[2]) > maxModelSize {
+		return nil, [3]int{}, fmt.Errorf("voxelized model would be %dx%dx%d blocks, the limit is %d per side; use a smaller size or scale",
+			dims[0], dims[1], dims[2], maxModelSize)
+	}
+	cell := func(p Vec3) [3]int {
+		var c [3]int
+		for a := range c {
+			c[a] = min(max(int(math.Floor((p[a]-lo[a])*scale)), 0), dims[a]-1)
+		}
+		return c
+	}
+
+	// Sample each triangle densely enough that every touched block is hit
+	type colorSum struct {
+		r, g, b, n float64
+	}
+	surface := make(map[[3]int]*colorSum)
+	for i := range tris {
+		t := &tris[i]
+		p0, p1, p2 := t.V[0].Pos, t.V[1].Pos, t.V[2].Pos
+		edge := 0.0
+		for _, e := range [][2]Vec3{{p0, p1}, {p1, p2}, {p2, p0}} {
+			d := math.Sqrt((e[0][0]-e[1][0])*(e[0][0]-e[1][0])+(e[0][1]-e[1][1])*(e[0][1]-e[1][1])+(e[0][2]-e[1][2])*(e[0][2]-e[1][2])) * scale
+			edge = math.Max(edge, d)
+		}
+		n := int(math.Ceil(edge/voxelSampleSpacing)) + 1
+		for s := 0; s <= n; s++ {
+			for u := 0; u <= n-s; u++ {
+				a, b := float64(s)/float64(n), float64(u)/float64(n)
+				c := 1 - a - b
+				p := Vec3{
+					a*p0[0] + b*p1[0] + c*p2[0],
+					a*p0[1] + b*p1[1] + c*p2[1],
+					a*p0[2] + b*p1[2] + c*p2[2],
+				}
+				key := cell(p)
+				sum := surface[key]
+				if sum == nil {
+					sum = &colorSum{}
+					surface[key] = sum
+				}
+				if t.HasColor && opts.Palette != nil {
+					col := t.ColorAt(a, b, c)
+					sum.r += col[0]
+					sum.g += col[1]
+					sum.b += col[2]
+					sum.n++
+				}
+			}
+		}
+	}
+
+	grid := make(BlockGrid, len(surface))
+	for key, sum := range surface {
+		block := opts.Block
+		if sum.n > 0 {
+			block = opts.Palette.Color(opts.Palette.Nearest(sum.r/sum.n, sum.g/sum.n, sum.b/sum.n)).Block
+		}
+		grid.Set(key[0], key[1], key[2], block)
+	}
+	if opts.Hollow {
+		return grid, dims, nil
+	}
+
+	// Flood fill the outside from a one block border; what is not reached is interior
+	w, h, d := dims[0]+2, dims[1]+2, dims[2]+2
+	outside := make([]bool, w*h*d)
+	idx := func(x, y, z int) int { return (y*d+z)*w + x }
+	queue := [][3]int{{0, 0, 0}}
+	outside[0] = true
+	for len(queue) > 0 {
+		c := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for _, n := range [6][3]int{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}} {
+			x, y, z := c[0]+n[0], c[1]+n[1], c[2]+n[2]
+			if x < 0 || y < 0 || z < 0 || x >= w || y >= h || z >= d || outside[idx(x, y, z)] {
+				continue
+			}
+			if _, ok := surface[[3]int{x - 1, y - 1, z - 1}]; ok {
+				continue
+			}
+			outside[idx(x, y, z)] = true
+			queue = append(queue, [3]int{x, y, z})
+		}
+	}
+	for y := 0; y < dims[1]; y++ {
+		for z := 0; z < dims[2]; z++ {
+			for x := 0; x < dims[0]; x++ {
+				if _, ok := surface[[3]int{x, y, z}]; !ok && !outside[idx(x+1, y+1, z+1)] {
+					grid.Set(x, y, z, opts.Interior)
+				}
+			}
+		}
+	}
+	return grid, dims, nil
+}
+
+// registerModelTools adds the 3D model voxelization tool.
+func (ms *MinecraftServer) registerModelTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_build_model",
+		mcp.WithDescription("Voxelize a 3D model (OBJ with MTL textures/colors, STL, glTF/GLB with base color textures/colors) from the assets directory and build it. The model is scaled to the given size, surface colors are matched against a block palette and the blocks are placed with merged /fill commands."),
+		mcp.WithString("path", mcp.Description("Model file path (.obj, .stl, .gltf or .glb), relative to (or inside) the configured assets directory"), mcp.Required()),
+		mcp.WithString("x", mcp.Description("X coordinate of the minimum (bottom north-west) corner"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Y coordinate of the minimum corner"), mcp.Required()),
+		mcp.WithString("z", mcp.Description("Z coordinate of the minimum corner"), mcp.Required()),
+		mcp.WithNumber("size", mcp.Description(fmt.Sprintf("Longest side of the build in blocks (optional, default: %d, max: %d)", defaultModelSize, maxModelSize))),
+		mcp.WithNumber("scale", mcp.Description("Blocks per model unit, overrides size (optional)")),
+		mcp.WithString("upAxis", mcp.Description("Up axis of the model: y or z (optional, default: z for STL, y otherwise)")),
+		mcp.WithNumber("rotate", mcp.Description("Clockwise rotation seen from above: 0, 90, 180 or 270 (optional, default: 0)")),
+		mcp.WithBoolean("hollow", mcp.Description("Only build the surface shell instead of a solid model (optional, default: false)")),
+		mcp.WithString("palette", mcp.Description("Block palette(s) for colored models, comma separated: wool, concrete, terracotta, map (optional, default: concrete; 'none' ignores colors)")),
+		mcp.WithString("block", mcp.Description("Block for surfaces without color (optional, default: minecraft:white_concrete)")),
+		mcp.WithString("interiorBlock", mcp.Description("Block for the solid interior (optional, default: the value of block)")),
+		mcp.WithBoolean("dryRun", mcp.Description("Only return the generated commands without executing them (optional)")),
+	), ms.handleBuildModel)
+}
+
+// handleBuildModel implements the minecraft_build_model tool.
+func (ms *MinecraftServer) handleBuildModel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	path, err := getStringArg(args, "path", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	origin, err := ms.getPositionArg(args, "x", "y", "z")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	opts := voxelOptions{}
+	if opts.Size, err = getIntArg(args, "size", defaultModelSize); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if opts.Size <= 0 || opts.Size > maxModelSize {
+		return mcp.NewToolResultError(fmt.Sprintf("size must be between 1 and %d", maxModelSize)), nil
+	}
+	if opts.Scale, err = getFloatArg(args, "scale", 0); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if opts.Scale < 0 || math.IsNaN(opts.Scale) || math.IsInf(opts.Scale, 0) {
+		return mcp.NewToolResultError("scale must be a positive number"), nil
+	}
+	if opts.Rotate, err = getIntArg(args, "rotate", 0); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if opts.Rotate = ((opts.Rotate % 360) + 360) % 360; opts.Rotate%90 != 0 {
+		return mcp.NewToolResultError("rotate must be 0, 90, 180 or 270"), nil
+	}
+	if opts.Hollow, err = getBoolArg(args, "hollow", false); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun, err := getBoolArg(args, "dryRun", false)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	opts.Block, _ = getStringArg(args, "block", false)
+	if opts.Block == "" {
+		opts.Block = "minecraft:white_concrete"
+	}
+	opts.Interior, _ = getStringArg(args, "interiorBlock", false)
+	if opts.Interior == "" {
+		opts.Interior = opts.Block
+	}
+	for _, b := range []string{opts.Block, opts.Interior} {
+		if err := validateBlockID(b); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	paletteName, _ := getStringArg(args, "palette", false)
+	switch strings.ToLower(paletteName) {
+	case "none":
+	case "":
+		opts.Palette, _ = NewPalette("concrete")
+	default:
+		if opts.Palette, err = NewPalette(paletteName); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	mesh, err := ms.loadModel(path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts.UpAxis, _ = getStringArg(args, "upAxis", false)
+	if opts.UpAxis == "" {
+		opts.UpAxis = mesh.UpAxis
+	}
+	if opts.UpAxis != "y" && opts.UpAxis != "z" {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid upAxis: %s (expected y or z)", opts.UpAxis)), nil
+	}
+
+	grid, dims, err := voxelizeMesh(mesh, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	commands, err := grid.Commands(origin, ms.config.MaxFillVolume)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	ms.logger.Info().Str("model", path).Int("triangles", len(mesh.Triangles)).Int("blocks", len(grid)).Msg("Voxelized model")
+	name := fmt.Sprintf("model %s %dx%dx%d (%d blocks)", filepath.Base(path), dims[0], dims[1], dims[2], len(grid))
+	return ms.runBuild(ctx, name, commands, dryRun)
+}
@@ -0,0 +1,231 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cubeOBJ is a unit cube with a red material on every face.
+const cubeOBJ = `mtllib cube.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+usemtl red
+f 1 2 3 4
+f 5 8 7 6
+f 1 5 6 2
+f 4 3 7 8
+f 1 4 8 5
+f 2 6 7 3
+`
+
+func TestVoxelizeModel(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cube.obj"), []byte(cubeOBJ), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cube.mtl"), []byte("newmtl red\nKd 0.6 0.12 0.12\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ms := &MinecraftServer{config: &MinecraftConfig{AssetsPath: dir}}
+	mesh, err := ms.loadModel("cube.obj")
+	if err != nil {
+		t.Fatalf("loadModel failed: %v", err)
+	}
+	if len(mesh.Triangles) != 12 || !mesh.Triangles[0].HasColor {
+		t.Fatalf("unexpected mesh: %d triangles", len(mesh.Triangles))
+	}
+	if _, err := ms.loadModel("../cube.obj"); err == nil {
+		t.Errorf("model outside of the assets directory should be rejected")
+	}
+
+	palette, _ := NewPalette("wool")
+	opts := voxelOptions{Size: 4, UpAxis: "y", Palette: palette, Block: "minecraft:stone", Interior: "minecraft:dirt"}
+	grid, dims, err := voxelizeMesh(mesh, opts)
+	if err != nil {
+		t.Fatalf("voxelizeMesh failed: %v", err)
+	}
+	if dims != [3]int{4, 4, 4} || len(grid) != 64 {
+		t.Fatalf("solid cube = %v, %d blocks", dims, len(grid))
+	}
+	if grid[[3]int{0, 0, 0}] != "minecraft:red_wool" || grid[[3]int{1, 1, 1}] != "minecraft:dirt" {
+		t.Errorf("unexpected blocks %s, %s", grid[[3]int{0, 0, 0}], grid[[3]int{1, 1, 1}])
+	}
+
+	opts.Hollow = true
+	if grid, _, _ = voxelizeMesh(mesh, opts); len(grid) != 56 {
+		t.Errorf("hollow cube has %d blocks, want 56", len(grid))
+	}
+
+	opts.Size = 1000
+	if _, _, err := voxelizeMesh(mesh, opts); err == nil {
+		t.Errorf("oversized model should fail")
+	}
+}
+
+func TestParseSTL(t *testing.T) {
+	ascii := "solid t\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 2 0 0\nvertex 0 2 0\nendloop\nendfacet\nendsolid t\n"
+	mesh, err := parseSTL([]byte(ascii))
+	if err != nil || len(mesh.Triangles) != 1 || mesh.Triangles[0].V[1].Pos != (Vec3{2, 0, 0}) {
+		t.Errorf("ASCII STL = %+v, %v", mesh, err)
+	}
+
+	bin := make([]byte, 84+50)
+	copy(bin, "solid but binary")
+	binary.LittleEndian.PutUint32(bin[80:], 1)
+	for i, v := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.LittleEndian.PutUint32(bin[84+12+i*4:], math.Float32bits(v))
+	}
+	mesh, err = parseSTL(bin)
+	if err != nil || len(mesh.Triangles) != 1 || mesh.Triangles[0].V[2].Pos != (Vec3{0, 1, 0}) || mesh.UpAxis != "z" {
+		t.Errorf("binary STL = %+v, %v", mesh, err)
+	}
+
+	binary.LittleEndian.PutUint32(bin[84+12:], math.Float32bits(float32(math.NaN())))
+	if _, err := parseSTL(bin); err == nil {
+		t.Errorf("a NaN vertex should be rejected")
+	}
+	binary.LittleEndian.PutUint32(bin[84+12:], math.Float32bits(float32(math.Inf(-1))))
+	if _, err := parseSTL(bin); err == nil {
+		t.Errorf("an infinite vertex should be rejected")
+	}
+}
+
+func TestParseGLTF(t *testing.T) {
+	buf := make([]byte, 36)
+	for i, v := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	doc := fmt.Sprintf(`{
+		"scenes": [{"nodes": [0]}],
+		"nodes": [{"mesh": 0, "translation": [10, 0, 0], "scale": [2, 2, 2]}],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}, "material": 0}]}],
+		"materials": [{"pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 1]}}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}],
+		"buffers": [{"uri": "data:application/octet-stream;base64,%s", "byteLength": 36}]
+	}`, base64.StdEncoding.EncodeToString(buf))
+
+	ms := &MinecraftServer{config: &MinecraftConfig{AssetsPath: t.TempDir()}}
+	mesh, err := ms.parseGLTF([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("parseGLTF failed: %v", err)
+	}
+	tri := mesh.Triangles[0]
+	if len(mesh.Triangles) != 1 || tri.V[1].Pos != (Vec3{12, 0, 0}) || !tri.HasColor || tri.V[0].Color != [3]float64{255, 0, 0} {
+		t.Errorf("unexpected glTF mesh %+v", mesh.Triangles)
+	}
+
+	binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(float32(math.NaN())))
+	nan := fmt.Sprintf(`{
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}],
+		"buffers": [{"uri": "data:application/octet-stream;base64,%s", "byteLength": 36}]
+	}`, base64.StdEncoding.EncodeToString(buf))
+	if _, err := ms.parseGLTF([]byte(nan), ""); err == nil {
+		t.Errorf("a NaN position should be rejected")
+	}
+}
+
+func TestParseGLTF_Texture(t *testing.T) {
+	// Positions followed by UVs: the triangle covers the top half of the texture
+	buf := make([]byte, 36+24)
+	for i, v := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0.4, 1, 0.4, 0, 0} {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(v))
+	}
+	// 1x2 texture, red on top and blue below
+	img := image.NewRGBA(image.Rect(0, 0, 1, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(0, 1, color.RGBA{B: 255, A: 255})
+	var texture bytes.Buffer
+	if err := png.Encode(&texture, img); err != nil {
+		t.Fatal(err)
+	}
+	doc := fmt.Sprintf(`{
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0, "TEXCOORD_0": 1}, "material": 0}]}],
+		"materials": [{"pbrMetallicRoughness": {"baseColorTexture": {"index": 0}}}],
+		"textures": [{"source": 0}],
+		"images": [{"uri": "data:image/png;base64,%s"}],
+		"accessors": [
+			{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			{"bufferView": 0, "byteOffset": 36, "componentType": 5126, "count": 3, "type": "VEC2"}
+		],
+		"bufferViews": [{"buffer": 0, "byteLength": 60}],
+		"buffers": [{"uri": "data:application/octet-stream;base64,%s", "byteLength": 60}]
+	}`, base64.StdEncoding.EncodeToString(texture.Bytes()), base64.StdEncoding.EncodeToString(buf))
+
+	ms := &MinecraftServer{config: &MinecraftConfig{AssetsPath: t.TempDir()}}
+	mesh, err := ms.parseGLTF([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("parseGLTF failed: %v", err)
+	}
+	tri := mesh.Triangles[0]
+	if tri.Texture == nil || !tri.HasColor {
+		t.Fatalf("the texture was not used: %+v", tri)
+	}
+	if c := tri.ColorAt(1.0/3, 1.0/3, 1.0/3); c != [3]float64{255, 0, 0} {
+		t.Errorf("color = %v, want the red top half of the texture", c)
+	}
+}
+
+func TestReadGLTFAccessor_OutOfRange(t *testing.T) {
+	tests := map[string]struct {
+		accessor, view string
+	}{
+		"negative accessor offset": {`"byteOffset": -8, "count": 3`, `"byteLength": 36`},
+		"negative view offset":     {`"count": 3`, `"byteOffset": -8, "byteLength": 36`},
+		"negative stride":          {`"count": 3`, `"byteLength": 36, "byteStride": -12`},
+		"stride below element":     {`"count": 3`, `"byteLength": 36, "byteStride": 4`},
+		"count beyond buffer":      {`"count": 4`, `"byteLength": 36`},
+		"huge count":               {`"count": 9223372036854775807`, `"byteLength": 36`},
+		"offset beyond buffer":     {`"byteOffset": 30, "count": 1`, `"byteLength": 36`},
+		"view beyond buffer":       {`"count": 3`, `"byteOffset": 9223372036854775800, "byteLength": 36`},
+	}
+	buffers := [][]byte{make([]byte, 36)}
+	for name, tt := range tests {
+		var doc gltfDocument
+		data := fmt.Sprintf(`{"accessors": [{"bufferView": 0, "componentType": 5126, "type": "VEC3", %s}], "bufferViews": [{"buffer": 0, %s}]}`, tt.accessor, tt.view)
+		if err := json.Unmarshal([]byte(data), &doc); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := readGLTFAccessor(&doc, buffers, 0); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
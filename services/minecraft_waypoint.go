@@ -0,0 +1,343 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WaypointFileName is the file under BasePath/data where waypoints are persisted.
+const WaypointFileName = "waypoints.json"
+
+var (
+	waypointNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// waypointRefRegex matches anchor references such as "plaza", "plaza+5" or "plaza+5,0,-3".
+	waypointRefRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?:([+-])([0-9.,+\- ]+))?$`)
+
+	ErrWaypointNotFound = errors.New("waypoint not found")
+)
+
+// Waypoint is a named anchor position in the world.
+type Waypoint struct {
+	Name        string    `json:"name"`
+	X           int       `json:"x"`
+	Y           int       `json:"y"`
+	Z           int       `json:"z"`
+	Dimension   string    `json:"dimension,omitempty"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Pos returns the position of the waypoint as x, y, z.
+func (w Waypoint) Pos() [3]int {
+	return [3]int{w.X, w.Y, w.Z}
+}
+
+// WaypointStore keeps the waypoints in memory and persists them to a JSON file.
+type WaypointStore struct {
+	path      string
+	mu        sync.Mutex
+	loaded    bool
+	waypoints map[string]Waypoint
+}
+
+// NewWaypointStore creates a store persisted at path. The file is read on first use.
+func NewWaypointStore(path string) *WaypointStore {
+	return &WaypointStore{
+		path:      path,
+		waypoints: make(map[string]Waypoint),
+	}
+}
+
+// load reads the waypoint file once, a missing file is an empty store. Caller must hold mu.
+func (s *WaypointStore) load() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.loaded = true
+			return nil
+		}
+		return fmt.Errorf("failed to read waypoints from %s: %w", s.path, err)
+	}
+	var list []Waypoint
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("failed to parse waypoints from %s: %w", s.path, err)
+	}
+	for _, w := range list {
+		s.waypoints[w.Name] = w
+	}
+	s.loaded = true
+	return nil
+}
+
+// save writes all waypoints to disk. Caller must hold mu.
+func (s *WaypointStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
+
+// sorted returns the waypoints ordered by name. Caller must hold mu.
+func (s *WaypointStore) sorted() []Waypoint {
+	list := make([]Waypoint, 0, len(s.waypoints))
+	for _, w := range s.waypoints {
+		list = append(list, w)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Set creates or replaces a waypoint.
+func (s *WaypointStore) Set(w Waypoint) error {
+	if !waypointNameRegex.MatchString(w.Name) {
+		return fmt.Errorf("invalid waypoint name %q (letters, digits and '_' only, must not start with a digit)", w.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	w.UpdatedAt = time.Now()
+	s.waypoints[w.Name] = w
+	return s.save()
+}
+
+// Get returns the waypoint with the given name.
+func (s *WaypointStore) Get(name string) (Waypoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return Waypoint{}, err
+	}
+	w, ok := s.waypoints[name]
+	if !ok {
+		return Waypoint{}, fmt.Errorf("%w: %s", ErrWaypointNotFound, name)
+	}
+	return w, nil
+}
+
+// Delete removes a waypoint.
+func (s *WaypointStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.waypoints[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrWaypointNotFound, name)
+	}
+	delete(s.waypoints, name)
+	return s.save()
+}
+
+// List returns all waypoints ordered by name.
+func (s *WaypointStore) List() ([]Waypoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.sorted(), nil
+}
+
+// isWaypointRef reports whether a coordinate argument looks like an anchor reference.
+func isWaypointRef(coord string) bool {
+	return waypointRefRegex.MatchString(strings.TrimSpace(coord))
+}
+
+// ResolveRef resolves an anchor reference like "plaza", "plaza+5" or "plaza+5,0,-3".
+// axis (0: x, 1: y, 2: z) selects the component a single offset ("plaza+5") applies to.
+// It returns the absolute position of the reference.
+func (s *WaypointStore) ResolveRef(ref string, axis int) ([3]int, error) {
+	m := waypointRefRegex.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return [3]int{}, fmt.Errorf("invalid waypoint reference: %s", ref)
+	}
+	w, err := s.Get(m[1])
+	if err != nil {
+		return [3]int{}, err
+	}
+	pos := w.Pos()
+	if m[2] == "" {
+		return pos, nil
+	}
+
+	offsetStr := m[3]
+	if m[2] == "-" {
+		offsetStr = "-" + offsetStr
+	}
+	parts := strings.Split(offsetStr, ",")
+	switch len(parts) {
+	case 1:
+		d, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return [3]int{}, fmt.Errorf("invalid offset in waypoint reference %s: %w", ref, err)
+		}
+		pos[axis] += d
+	case 3:
+		for i, p := range parts {
+			d, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return [3]int{}, fmt.Errorf("invalid offset in waypoint reference %s: %w", ref, err)
+			}
+			pos[i] += d
+		}
+	default:
+		return [3]int{}, fmt.Errorf("invalid offset in waypoint reference %s (expected name+d or name+dx,dy,dz)", ref)
+	}
+	return pos, nil
+}
+
+// resolveCoordRefs replaces anchor references in the coordinate arguments keys with
+// absolute coordinates. If the first key of an x/y/z triple holds a reference and the
+// other two are empty, all three are filled from that reference.
+func (ms *MinecraftServer) resolveCoordRefs(args map[string]interface{}, keys []string) (map[string]string, error) {
+	resolved := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if s, ok := args[key].(string); ok {
+			resolved[key] = s
+		}
+	}
+	for i, key := range keys {
+		coord := resolved[key]
+		if !isWaypointRef(coord) {
+			continue
+		}
+		axis := i % 3
+		if m := coordKeyRegex.FindStringSubmatch(key); m != nil {
+			axis = strings.Index("xyz", m[1])
+		}
+		pos, err := ms.waypoints.ResolveRef(coord, axis)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinate for %s: %w", key, err)
+		}
+		resolved[key] = strconv.Itoa(pos[axis])
+
+		// Fill the rest of the triple, e.g. x1="plaza+5,0,-3" with empty y1 and z1
+		if axis == 0 && i+2 < len(keys) && resolved[keys[i+1]] == "" && resolved[keys[i+2]] == "" {
+			resolved[keys[i+1]] = strconv.Itoa(pos[1])
+			resolved[keys[i+2]] = strconv.Itoa(pos[2])
+		}
+	}
+	return resolved, nil
+}
+
+// registerWaypointTools adds the tools to manage waypoints.
+func (ms *MinecraftServer) registerWaypointTools() {
+	ms.AddTool(mcp.NewTool(
+		"minecraft_waypoint_set",
+		mcp.WithDescription("Create or move a named waypoint (anchor). Any coordinate argument of the other tools accepts a waypoint reference such as 'plaza', 'plaza+5' (offset on that axis) or 'plaza+5,0,-3' (offset x,y,z); a reference in the X argument also fills empty Y and Z."),
+		mcp.WithString("name", mcp.Description("Waypoint name (letters, digits and '_')"), mcp.Required()),
+		mcp.WithString("x", mcp.Description("Absolute X coordinate or waypoint reference"), mcp.Required()),
+		mcp.WithString("y", mcp.Description("Absolute Y coordinate or waypoint reference"), mcp.Required()),
+		mcp.WithString("z", mcp.Description("Absolute Z coordinate or waypoint reference"), mcp.Required()),
+		mcp.WithString("dimension", mcp.Description("Dimension of the waypoint (optional, e.g., minecraft:overworld)")),
+		mcp.WithString("description", mcp.Description("Description of the waypoint (optional)")),
+	), ms.handleWaypointSet)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_waypoint_list",
+		mcp.WithDescription("List all named waypoints (anchors) with their coordinates"),
+		mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: true}),
+	), ms.handleWaypointList)
+
+	ms.AddTool(mcp.NewTool(
+		"minecraft_waypoint_delete",
+		mcp.WithDescription("Delete a named waypoint (anchor)"),
+		mcp.WithString("name", mcp.Description("Waypoint name"), mcp.Required()),
+	), ms.handleWaypointDelete)
+}
+
+// handleWaypointSet implements the minecraft_waypoint_set tool.
+func (ms *MinecraftServer) handleWaypointSet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := getStringArg(request.Params.Arguments, "name", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	coords, err := ms.getCoordArgs(request.Params.Arguments, "x", "y", "z")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var pos [3]int
+	for i, c := range coords {
+		f, err := strconv.ParseFloat(c, 64)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("waypoint coordinates must be absolute, got %s", c)), nil
+		}
+		pos[i] = int(math.Floor(f))
+	}
+	dimension, _ := getStringArg(request.Params.Arguments, "dimension", false)
+	description, _ := getStringArg(request.Params.Arguments, "description", false)
+
+	w := Waypoint{Name: name, X: pos[0], Y: pos[1], Z: pos[2], Dimension: dimension, Description: description}
+	if err := ms.waypoints.Set(w); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Waypoint '%s' set to %d %d %d", w.Name, w.X, w.Y, w.Z)), nil
+}
+
+// handleWaypointList implements the minecraft_waypoint_list tool.
+func (ms *MinecraftServer) handleWaypointList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	list, err := ms.waypoints.List()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(list) == 0 {
+		return mcp.NewToolResultText("No waypoints defined"), nil
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleWaypointDelete implements the minecraft_waypoint_delete tool.
+func (ms *MinecraftServer) handleWaypointDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := getStringArg(request.Params.Arguments, "name", true)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := ms.waypoints.Delete(name); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Waypoint '%s' deleted", name)), nil
+}
@@ -0,0 +1,108 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestWaypointStore_ResolveRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), WaypointFileName)
+	store := NewWaypointStore(path)
+	if err := store.Set(Waypoint{Name: "plaza", X: 100, Y: 64, Z: -20}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := store.Set(Waypoint{Name: "1bad"}); err == nil {
+		t.Errorf("expected an error for an invalid waypoint name")
+	}
+
+	tests := []struct {
+		ref  string
+		axis int
+		want [3]int
+	}{
+		{"plaza", 0, [3]int{100, 64, -20}},
+		{"plaza+5", 1, [3]int{100, 69, -20}},
+		{"plaza-5", 2, [3]int{100, 64, -25}},
+		{"plaza+5,0,-3", 0, [3]int{105, 64, -23}},
+		{"plaza-1,2,3", 0, [3]int{99, 66, -17}},
+	}
+	for _, tt := range tests {
+		got, err := store.ResolveRef(tt.ref, tt.axis)
+		if err != nil {
+			t.Errorf("ResolveRef(%q) failed: %v", tt.ref, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveRef(%q) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+	if _, err := store.ResolveRef("castle", 0); !errors.Is(err, ErrWaypointNotFound) {
+		t.Errorf("expected ErrWaypointNotFound, got %v", err)
+	}
+
+	// A new store must read the persisted waypoints
+	reloaded := NewWaypointStore(path)
+	if w, err := reloaded.Get("plaza"); err != nil || w.Pos() != [3]int{100, 64, -20} {
+		t.Errorf("reloaded waypoint mismatch: %+v, %v", w, err)
+	}
+}
+
+func TestMinecraftServer_getCoordArgsWaypoint(t *testing.T) {
+	ms := &MinecraftServer{waypoints: NewWaypointStore(filepath.Join(t.TempDir(), WaypointFileName))}
+	if err := ms.waypoints.Set(Waypoint{Name: "plaza", X: 10, Y: 70, Z: 30}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	coords, err := ms.getCoordArgs(map[string]interface{}{"x1": "plaza+5,0,-3"}, "x1", "y1", "z1")
+	if err != nil {
+		t.Fatalf("getCoordArgs failed: %v", err)
+	}
+	if coords[0] != "15" || coords[1] != "70" || coords[2] != "27" {
+		t.Errorf("unexpected coordinates: %v", coords)
+	}
+
+	coords, err = ms.getCoordArgs(map[string]interface{}{"x": "plaza", "y": "~1", "z": "plaza+2"}, "x", "y", "z")
+	if err != nil {
+		t.Fatalf("getCoordArgs failed: %v", err)
+	}
+	if coords[0] != "10" || coords[1] != "~1" || coords[2] != "32" {
+		t.Errorf("unexpected coordinates: %v", coords)
+	}
+}
+
+func TestMinecraftServer_handleWaypointSet(t *testing.T) {
+	ms := &MinecraftServer{waypoints: NewWaypointStore(filepath.Join(t.TempDir(), WaypointFileName))}
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]interface{}{"name": "camp", "x": "-0.5", "y": "64.9", "z": "-12.01"}
+	if result, err := ms.handleWaypointSet(context.Background(), request); err != nil || result.IsError {
+		t.Fatalf("handleWaypointSet = %v, %v", result, err)
+	}
+	w, err := ms.waypoints.Get("camp")
+	if err != nil || w.X != -1 || w.Y != 64 || w.Z != -13 {
+		t.Errorf("waypoint = %+v, want the block at -1 64 -13", w)
+	}
+}
@@ -0,0 +1,484 @@
+/*
+ * Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Repository: https://github.com/gojue/moling
+ */
+
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Heightmap types stored in every chunk.
+const (
+	HeightmapMotionBlocking = "MOTION_BLOCKING" // highest block that blocks motion or contains a fluid
+	HeightmapWorldSurface   = "WORLD_SURFACE"   // highest non-air block
+	HeightmapOceanFloor     = "OCEAN_FLOOR"     // highest solid block
+
+	HeightmapMotionBlockingNoLeaves = "MOTION_BLOCKING_NO_LEAVES" // MOTION_BLOCKING without leaves
+)
+
+const (
+	// airBlock is returned for blocks in sections that are not stored.
+	airBlock = "minecraft:air"
+	// dataVersion1_16 is the first data version (20w17a) whose packed arrays do not span longs.
+	dataVersion1_16 = 2529
+	// regionSectorSize is the size of a sector in region files.
+	regionSectorSize = 4096
+)
+
+var (
+	ErrChunkNotGenerated = errors.New("chunk is not generated")
+	ErrWorldNotFound     = errors.New("world data not found")
+)
+
+// chunkSection is a 16x16x16 section of block states.
+type chunkSection struct {
+	palette []string
+	data    []int64
+}
+
+// Chunk is the block and heightmap data of a 16x16 column read from a region file.
+type Chunk struct {
+	X, Z        int
+	MinY        int
+	DataVersion int
+	sections    map[int]*chunkSection
+	heightmaps  map[string][]int64
+}
+
+// unpackBits returns entry i of a packed long array with the given bits per entry.
+// Since 1.16 entries do not span two longs, before that they do.
+func unpackBits(data []int64, bitsPerEntry, i int, spanning bool) int {
+	mask := uint64(1)<<bitsPerEntry - 1
+	if !spanning {
+		perLong := 64 / bitsPerEntry
+		idx := i / perLong
+		if idx >= len(data) {
+			return 0
+		}
+		return int(uint64(data[idx]) >> ((i % perLong) * bitsPerEntry) & mask)
+	}
+	bit := i * bitsPerEntry
+	idx, off := bit/64, bit%64
+	if idx >= len(data) {
+		return 0
+	}
+	v := uint64(data[idx]) >> off
+	if off+bitsPerEntry > 64 && idx+1 < len(data) {
+		v |= uint64(data[idx+1]) << (64 - off)
+	}
+	return int(v & mask)
+}
+
+// blockStateString formats a palette entry as name[key=value,...].
+func blockStateString(entry NBTCompound) string {
+	name := entry.String("Name")
+	props, ok := entry.Compound("Properties")
+	if !ok || len(props) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + props.String(k)
+	}
+	return name + "[" + strings.Join(parts, ",") + "]"
+}
+
+// blockName strips the block state properties from a block state string.
+func blockName(state string) string {
+	if i := strings.IndexByte(state, '['); i >= 0 {
+		return state[:i]
+	}
+	return state
+}
+
+// isAirBlock reports whether the block state is one of the air blocks.
+func isAirBlock(state string) bool {
+	switch blockName(state) {
+	case "minecraft:air", "minecraft:cave_air", "minecraft:void_air":
+		return true
+	}
+	return false
+}
+
+// parseChunk decodes the chunk NBT of 1.16+ (Level compound) and 1.18+ (flat) formats.
+func parseChunk(root NBTCompound) (*Chunk, error) {
+	c := &Chunk{sections: make(map[int]*chunkSection), heightmaps: make(map[string][]int64)}
+	dv, _ := root.Int("DataVersion")
+	c.DataVersion = int(dv)
+
+	level := root
+	if l, ok := root.Compound("Level"); ok {
+		// 1.17 and older
+		level = l
+	}
+	if status := level.String("Status"); status != "" && !strings.HasSuffix(status, "full") {
+		return nil, ErrChunkNotGenerated
+	}
+	x, _ := level.Int("xPos")
+	z, _ := level.Int("zPos")
+	c.X, c.Z = int(x), int(z)
+	if y, ok := level.Int("yPos"); ok {
+		c.MinY = int(y) * 16
+	}
+
+	if hm, ok := level.Compound("Heightmaps"); ok {
+		for k := range hm {
+			c.heightmaps[k] = hm.LongArray(k)
+		}
+	}
+
+	sections := level.List("sections")
+	if sections == nil {
+		sections = level.List("Sections")
+	}
+	for _, s := range sections {
+		sec, ok := s.(NBTCompound)
+		if !ok {
+			continue
+		}
+		y, _ := sec.Int("Y")
+		cs := &chunkSection{}
+		var palette []interface{}
+		if states, ok := sec.Compound("block_states"); ok {
+			palette, cs.data = states.List("palette"), states.LongArray("data")
+		} else {
+			palette, cs.data = sec.List("Palette"), sec.LongArray("BlockStates")
+		}
+		if len(palette) == 0 {
+			continue
+		}
+		for _, p := range palette {
+			entry, _ := p.(NBTCompound)
+			cs.palette = append(cs.palette, blockStateString(entry))
+		}
+		c.sections[int(y)] = cs
+	}
+	if _, ok := level.Int("yPos"); !ok && len(c.sections) > 0 {
+		// Without yPos the lowest stored section (ignoring the lighting-only section below) is used
+		lowest := 0
+		for y := range c.sections {
+			lowest = min(lowest, y)
+		}
+		c.MinY = lowest * 16
+	}
+	return c, nil
+}
+
+// Block returns the block state at the chunk-local x, z (0-15) and world y.
+func (c *Chunk) Block(x, y, z int) string {
+	sy := y >> 4 // arithmetic shift, rounds down for negative y
+	sec := c.sections[sy]
+	if sec == nil {
+		return airBlock
+	}
+	if len(sec.palette) == 1 || len(sec.data) == 0 {
+		return sec.palette[0]
+	}
+	bitsPerEntry := max(4, bits.Len(uint(len(sec.palette)-1)))
+	i := (y-sy*16)*256 + z*16 + x
+	idx := unpackBits(sec.data, bitsPerEntry, i, c.DataVersion < dataVersion1_16)
+	if idx >= len(sec.palette) {
+		return airBlock
+	}
+	return sec.palette[idx]
+}
+
+// maxY returns the top of the highest stored section.
+func (c *Chunk) maxY() int {
+	top := c.MinY
+	for y := range c.sections {
+		top = max(top, y*16+16)
+	}
+	return top
+}
+
+// SurfaceY returns the Y of the highest block at the chunk-local x, z according to the
+// heightmap. It falls back to scanning for the highest non-air block when the heightmap
+// is not stored. ok is false for columns without any block.
+func (c *Chunk) SurfaceY(x, z int, heightmap string) (int, bool) {
+	if data := c.heightmaps[heightmap]; len(data) > 0 {
+		// Find the entry size from the array length: 256 entries, spanning before 1.16
+		spanning := c.DataVersion < dataVersion1_16
+		for b := 1; b <= 16; b++ {
+			var longs int
+			if spanning {
+				longs = (256*b + 63) / 64
+			} else {
+				longs = (256 + 64/b - 1) / (64 / b)
+			}
+			if longs == len(data) {
+				v := unpackBits(data, b, z*16+x, spanning)
+				return v - 1 + c.MinY, v > 0
+			}
+		}
+	}
+	for y := c.maxY() - 1; y >= c.MinY; y-- {
+		if !isAirBlock(c.Block(x, y, z)) {
+			return y, true
+		}
+	}
+	return 0, false
+}
+
+// World reads chunks of one dimension from the region files of a world save. Chunks
+// are cached, so a World should only be used for the duration of a single operation.
+type World struct {
+	Dir       string
+	regionDir string
+	chunks    map[[2]int]*Chunk
+}
+
+// OpenWorld opens the dimension ("overworld", "the_nether" or "the_end") of the world in dir.
+func OpenWorld(dir, dimension string) (*World, error) {
+	var sub string
+	switch strings.TrimPrefix(dimension, "minecraft:") {
+	case "", "overworld":
+		sub = "region"
+	case "the_nether":
+		sub = filepath.Join("DIM-1", "region")
+	case "the_end":
+		sub = filepath.Join("DIM1", "region")
+	default:
+		return nil, fmt.Errorf("unsupported dimension: %s", dimension)
+	}
+	w := &World{Dir: dir, regionDir: filepath.Join(dir, sub), chunks: make(map[[2]int]*Chunk)}
+	if info, err := os.Stat(w.regionDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrWorldNotFound, w.regionDir)
+	}
+	return w, nil
+}
+
+// Chunk returns the chunk at chunk coordinates cx, cz.
+func (w *World) Chunk(cx, cz int) (*Chunk, error) {
+	key := [2]int{cx, cz}
+	if c, ok := w.chunks[key]; ok {
+		if c == nil {
+			return nil, ErrChunkNotGenerated
+		}
+		return c, nil
+	}
+	c, err := w.readChunk(cx, cz)
+	if errors.Is(err, ErrChunkNotGenerated) {
+		w.chunks[key] = nil
+	} else if err == nil {
+		w.chunks[key] = c
+	}
+	return c, err
+}
+
+// readChunk loads a chunk from its region file.
+func (w *World) readChunk(cx, cz int) (*Chunk, error) {
+	rx, rz := cx>>5, cz>>5
+	f, err := os.Open(filepath.Join(w.regionDir, fmt.Sprintf("r.%d.%d.mca", rx, rz)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrChunkNotGenerated
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var loc [4]byte
+	if _, err := f.ReadAt(loc[:], int64(4*((cx&31)+(cz&31)*32))); err != nil {
+		return nil, fmt.Errorf("invalid region file header: %w", err)
+	}
+	offset := int64(loc[0])<<16 | int64(loc[1])<<8 | int64(loc[2])
+	if offset == 0 {
+		return nil, ErrChunkNotGenerated
+	}
+	var head [5]byte
+	if _, err := f.ReadAt(head[:], offset*regionSectorSize); err != nil {
+		return nil, fmt.Errorf("invalid chunk header: %w", err)
+	}
+	length := int64(binary.BigEndian.Uint32(head[:4]))
+	if length < 1 || length > int64(loc[3])*regionSectorSize {
+		return nil, fmt.Errorf("invalid chunk length %d", length)
+	}
+	compression := head[4]
+	var raw io.Reader = io.NewSectionReader(f, offset*regionSectorSize+5, length-1)
+	if compression&0x80 != 0 {
+		// Oversized chunks are stored in a separate file
+		ext, err := os.Open(filepath.Join(w.regionDir, fmt.Sprintf("c.%d.%d.mcc", cx, cz)))
+		if err != nil {
+			return nil, err
+		}
+		defer ext.Close()
+		raw = ext
+		compression &^= 0x80
+	}
+
+	var r io.Reader
+	switch compression {
+	case 1:
+		gz, err := gzip.NewReader(raw)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	case 2:
+		zr, err := zlib.NewReader(raw)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case 3:
+		r = raw
+	default:
+		return nil, fmt.Errorf("unsupported chunk compression %d", compression)
+	}
+	root, err := ReadNBT(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d,%d: %w", cx, cz, err)
+	}
+	return parseChunk(root)
+}
+
+// Block returns the block state at the world position x, y, z.
+func (w *World) Block(x, y, z int) (string, error) {
+	c, err := w.Chunk(x>>4, z>>4)
+	if err != nil {
+		return "", err
+	}
+	return c.Block(x&15, y, z&15), nil
+}
+
+// SurfaceY returns the Y of the highest block at x, z according to the heightmap.
+func (w *World) SurfaceY(x, z int, heightmap string) (int, error) {
+	c, err := w.Chunk(x>>4, z>>4)
+	if err != nil {
+		return 0, err
+	}
+	y, ok := c.SurfaceY(x&15, z&15, heightmap)
+	if !ok {
+		return 0, fmt.Errorf("no blocks at %d, %d", x, z)
+	}
+	return y, nil
+}
+
+// worldDir returns the directory of the world save, from level-name in server.properties.
+func (ms *MinecraftServer) worldDir() string {
+	level := "world"
+	if props, err := ReadServerProperties(ms.propertiesPath()); err == nil {
+		if name, _ := props.Get("level-name"); name != "" {
+			level = name
+		}
+	}
+	return filepath.Join(ms.config.ServerRootPath, level)
+}
+
+// openWorld opens the world save for reading. A running server is asked to flush its
+// chunks to disk first and waited for, so the region files reflect the current terrain.
+func (ms *MinecraftServer) openWorld(dimension string) (*World, error) {
+	if ms.isServerRunning() {
+		if err := ms.waitForCommand("/save-all flush", "Saved the game", serverSaveTimeout); err != nil {
+			ms.logger.Warn().Err(err).Msg("Failed to flush the world before reading it")
+		}
+	}
+	return OpenWorld(ms.worldDir(), dimension)
+}
+
+// isFluidBlock reports whether the block state is water or lava.
+func isFluidBlock(state string) bool {
+	switch blockName(state) {
+	case "minecraft:water", "minecraft:lava", "minecraft:bubble_column":
+		return true
+	}
+	return false
+}
+
+// terrain lazily opens the world save for tools that follow the ground.
+type terrain struct {
+	ms        *MinecraftServer
+	dimension string
+	world     *World
+	err       error
+}
+
+// World opens the world save on first use.
+func (t *terrain) World() (*World, error) {
+	if t.world == nil && t.err == nil {
+		t.world, t.err = t.ms.openWorld(t.dimension)
+	}
+	return t.world, t.err
+}
+
+// Ground returns the Y and block state of the highest motion blocking block at x, z,
+// ignoring leaves (so trees are not planted on other trees).
+func (t *terrain) Ground(x, z int) (int, string, error) {
+	w, err := t.World()
+	if err != nil {
+		return 0, "", err
+	}
+	y, err := w.SurfaceY(x, z, HeightmapMotionBlockingNoLeaves)
+	if err != nil {
+		return 0, "", err
+	}
+	block, err := w.Block(x, y, z)
+	return y, block, err
+}
+
+// groundPosition returns the position of the xKey/yKey/zKey arguments. When the y
+// argument is empty the position is placed on top of the ground read from the world
+// save, which needs absolute x and z.
+func (ms *MinecraftServer) groundPosition(args map[string]interface{}, xKey, yKey, zKey string, t *terrain) (Position, error) {
+	x, _ := getStringArg(args, xKey, false)
+	y, _ := getStringArg(args, yKey, false)
+	if y != "" || isWaypointRef(x) {
+		return ms.getPositionArg(args, xKey, yKey, zKey)
+	}
+	coords, err := ms.getCoordArgs(args, xKey, zKey)
+	if err != nil {
+		return Position{}, err
+	}
+	p, err := ParsePosition(coords[0], "0", coords[1])
+	if err != nil {
+		return Position{}, err
+	}
+	if !p.IsAbsolute() {
+		return Position{}, fmt.Errorf("following the terrain needs absolute %s and %s coordinates, or pass %s", xKey, zKey, yKey)
+	}
+	bx, bz := int(math.Floor(p[0].Value)), int(math.Floor(p[2].Value))
+	ground, block, err := t.Ground(bx, bz)
+	if err != nil {
+		return Position{}, fmt.Errorf("cannot read the ground height at %d, %d (pass %s instead): %w", bx, bz, yKey, err)
+	}
+	if isFluidBlock(block) {
+		return Position{}, fmt.Errorf("the ground at %d, %d is %s", bx, bz, blockName(block))
+	}
+	p[1] = Coord{Kind: CoordAbsolute, Value: float64(ground + 1)}
+	return p, nil
+}
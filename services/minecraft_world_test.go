@@ -0,0 +1,207 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling-minecraft
+ *
+ */
+
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// writeTestNBT encodes v (NBTCompound, []interface{} of compounds, string, int8, int32 or []int64).
+func writeTestNBT(buf *bytes.Buffer, name string, v interface{}) {
+	tagOf := func(v interface{}) byte {
+		switch v.(type) {
+		case int8:
+			return nbtByte
+		case int32:
+			return nbtInt
+		case string:
+			return nbtString
+		case []interface{}:
+			return nbtList
+		case NBTCompound:
+			return nbtCompound
+		case []int64:
+			return nbtLongArray
+		}
+		panic(fmt.Sprintf("unsupported %T", v))
+	}
+	var payload func(v interface{})
+	str := func(s string) {
+		_ = binary.Write(buf, binary.BigEndian, uint16(len(s)))
+		buf.WriteString(s)
+	}
+	payload = func(v interface{}) {
+		switch v := v.(type) {
+		case int8:
+			buf.WriteByte(byte(v))
+		case int32:
+			_ = binary.Write(buf, binary.BigEndian, v)
+		case string:
+			str(v)
+		case []interface{}:
+			buf.WriteByte(nbtCompound)
+			_ = binary.Write(buf, binary.BigEndian, int32(len(v)))
+			for _, e := range v {
+				payload(e)
+			}
+		case NBTCompound:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				buf.WriteByte(tagOf(v[k]))
+				str(k)
+				payload(v[k])
+			}
+			buf.WriteByte(nbtEnd)
+		case []int64:
+			_ = binary.Write(buf, binary.BigEndian, int32(len(v)))
+			for _, e := range v {
+				_ = binary.Write(buf, binary.BigEndian, e)
+			}
+		}
+	}
+	buf.WriteByte(tagOf(v))
+	str(name)
+	payload(v)
+}
+
+// writeTestRegion writes a region file with a single 1.18+ chunk at chunk 0, 0 whose
+// bottom section (y -64..-49) is stone up to y -61, with a stone pillar at x 1, z 2 up to y -58.
+func writeTestRegion(t *testing.T, dir string) {
+	data := make([]int64, 256) // 4 bits per block, 16 per long
+	set := func(x, y, z, v int) {
+		i := y*256 + z*16 + x
+		data[i/16] |= int64(v) << ((i % 16) * 4)
+	}
+	heights := make([]int64, 37) // 9 bits per entry, 7 per long
+	for z := 0; z < 16; z++ {
+		for x := 0; x < 16; x++ {
+			top := 3
+			if x == 1 && z == 2 {
+				top = 6
+			}
+			for y := 0; y <= top; y++ {
+				set(x, y, z, 1)
+			}
+			i := z*16 + x
+			heights[i/7] |= int64(top+1) << ((i % 7) * 9)
+		}
+	}
+	chunk := NBTCompound{
+		"DataVersion": int32(3465),
+		"xPos":        int32(0),
+		"zPos":        int32(0),
+		"yPos":        int32(-4),
+		"Status":      "minecraft:full",
+		"Heightmaps":  NBTCompound{HeightmapMotionBlockingNoLeaves: heights},
+		"sections": []interface{}{
+			NBTCompound{"Y": int8(-4), "block_states": NBTCompound{
+				"palette": []interface{}{NBTCompound{"Name": "minecraft:air"}, NBTCompound{"Name": "minecraft:stone"}},
+				"data":    data,
+			}},
+			NBTCompound{"Y": int8(-3), "block_states": NBTCompound{
+				"palette": []interface{}{NBTCompound{"Name": "minecraft:oak_log", "Properties": NBTCompound{"axis": "y"}}},
+			}},
+		},
+	}
+	var raw, compressed bytes.Buffer
+	writeTestNBT(&raw, "", chunk)
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(raw.Bytes())
+	_ = zw.Close()
+
+	region := make([]byte, 2*regionSectorSize)
+	sectors := (compressed.Len() + 5 + regionSectorSize - 1) / regionSectorSize
+	region[0], region[1], region[2], region[3] = 0, 0, 2, byte(sectors)
+	body := make([]byte, 5, 5+compressed.Len())
+	binary.BigEndian.PutUint32(body, uint32(compressed.Len()+1))
+	body[4] = 2
+	body = append(body, compressed.Bytes()...)
+	region = append(region, body...)
+
+	if err := os.MkdirAll(filepath.Join(dir, "region"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "region", "r.0.0.mca"), region, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorld(t *testing.T) {
+	dir := t.TempDir()
+	writeTestRegion(t, dir)
+	w, err := OpenWorld(dir, "overworld")
+	if err != nil {
+		t.Fatalf("OpenWorld failed: %v", err)
+	}
+	if y, err := w.SurfaceY(1, 2, HeightmapMotionBlockingNoLeaves); err != nil || y != -58 {
+		t.Errorf("SurfaceY(1, 2) = %d, %v", y, err)
+	}
+	if y, err := w.SurfaceY(5, 5, HeightmapMotionBlockingNoLeaves); err != nil || y != -61 {
+		t.Errorf("SurfaceY(5, 5) = %d, %v", y, err)
+	}
+	// Without the heightmap the column is scanned, finding the log section on top
+	if y, err := w.SurfaceY(5, 5, HeightmapWorldSurface); err != nil || y != -33 {
+		t.Errorf("scanned SurfaceY(5, 5) = %d, %v", y, err)
+	}
+	for _, c := range []struct {
+		x, y, z int
+		want    string
+	}{
+		{1, -58, 2, "minecraft:stone"},
+		{1, -57, 2, "minecraft:air"},
+		{0, -40, 0, "minecraft:oak_log[axis=y]"},
+		{0, 100, 0, "minecraft:air"},
+	} {
+		if b, err := w.Block(c.x, c.y, c.z); err != nil || b != c.want {
+			t.Errorf("Block(%d, %d, %d) = %s, %v", c.x, c.y, c.z, b, err)
+		}
+	}
+	if _, err := w.Block(40, 0, 0); err != ErrChunkNotGenerated {
+		t.Errorf("expected ErrChunkNotGenerated, got %v", err)
+	}
+	if _, err := OpenWorld(dir, "the_nether"); err == nil {
+		t.Errorf("missing dimension should fail")
+	}
+}
+
+func TestUnpackBits(t *testing.T) {
+	// 5 bit entries spanning two longs (before 1.16): entry 12 starts at bit 60
+	high := uint64(0b1011) << 60
+	data := []int64{int64(high), 0b1}
+	if v := unpackBits(data, 5, 12, true); v != 0b11011 {
+		t.Errorf("spanning entry = %b", v)
+	}
+	// Without spanning entry 12 is the first entry of the second long
+	if v := unpackBits(data, 5, 12, false); v != 1 {
+		t.Errorf("padded entry = %b", v)
+	}
+}